@@ -6,10 +6,13 @@ package app
 import (
 	"context"
 	"os"
+	"time"
 
 	"github.com/leg100/pug/internal/logging"
 	"github.com/leg100/pug/internal/module"
 	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/schedule"
 	"github.com/leg100/pug/internal/state"
 	"github.com/leg100/pug/internal/task"
 	"github.com/leg100/pug/internal/workspace"
@@ -41,6 +44,7 @@ func New(cfg Config) (*App, error) {
 		"program", cfg.Program,
 		"work_dir", cfg.Workdir,
 		"data_dir", cfg.DataDir,
+		"max_task_memory", cfg.MaxTaskMemory,
 	)
 
 	// Instantiate services
@@ -51,7 +55,23 @@ func New(cfg Config) (*App, error) {
 		UserEnvs:   cfg.Envs,
 		UserArgs:   cfg.Args,
 		Terragrunt: cfg.Terragrunt,
+		DataDir:    cfg.DataDir,
+		MaxMemory:  cfg.MaxTaskMemory,
+		History:    cfg.TaskHistory,
+		Timeouts: map[task.Identifier]time.Duration{
+			module.InitTask: cfg.Timeouts.Init,
+			plan.PlanTask:   cfg.Timeouts.Plan,
+			plan.ApplyTask:  cfg.Timeouts.Apply,
+		},
+		GracePeriod: cfg.Timeouts.Grace,
+		RetryPolicy: &cfg.Retry,
+		PreHooks:    cfg.Hooks.Pre,
+		PostHooks:   cfg.Hooks.Post,
 	})
+	// Load tasks from previous sessions.
+	if err := tasks.LoadHistory(); err != nil {
+		logger.Error("loading task history", "error", err)
+	}
 	modules := module.NewService(module.ServiceOptions{
 		Tasks:       tasks,
 		Workdir:     cfg.Workdir,
@@ -60,11 +80,12 @@ func New(cfg Config) (*App, error) {
 		Terragrunt:  cfg.Terragrunt,
 	})
 	workspaces := workspace.NewService(workspace.ServiceOptions{
-		Tasks:   tasks,
-		Modules: modules,
-		Logger:  logger,
-		DataDir: cfg.DataDir,
-		Workdir: cfg.Workdir,
+		Tasks:    tasks,
+		Modules:  modules,
+		Logger:   logger,
+		DataDir:  cfg.DataDir,
+		Workdir:  cfg.Workdir,
+		VarFiles: cfg.VarFiles,
 	})
 	states := state.NewService(state.ServiceOptions{
 		Modules:    modules,
@@ -81,13 +102,40 @@ func New(cfg Config) (*App, error) {
 		Workdir:    cfg.Workdir,
 		Logger:     logger,
 		Terragrunt: cfg.Terragrunt,
+		Policy:     cfg.Policy,
+		Archive:    cfg.PlanRetention,
 	})
+	// Load plans retained by previous sessions.
+	if err := plans.LoadArchive(); err != nil {
+		logger.Error("loading archived plans", "error", err)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// Start daemons
 	task.StartEnqueuer(tasks)
-	waitTasks := task.StartRunner(ctx, logger, tasks, cfg.MaxTasks)
+	waitTasks := task.StartRunner(ctx, logger, tasks, task.RunnerOptions{
+		MaxTasks: cfg.MaxTasks,
+		Caps:     cfg.ConcurrencyCaps,
+		Labels:   cfg.ModuleLabels,
+		Backend: func(moduleID resource.ID) string {
+			mod, err := modules.Get(moduleID)
+			if err != nil {
+				return ""
+			}
+			return mod.Backend
+		},
+	})
+	go plans.DetectStalePlans(ctx, states.Subscribe(ctx))
+	tasks.StartHistoryGC(ctx)
+	plans.StartArchiveGC(ctx)
+	schedule.Start(ctx, schedule.Options{
+		Schedules:  cfg.Schedules,
+		Tasks:      tasks,
+		Plans:      plans,
+		Workspaces: workspaces,
+		Logger:     logger,
+	})
 
 	// cleanup function to be invoked when app is terminated.
 	cleanup := func() {
@@ -101,6 +149,7 @@ func New(cfg Config) (*App, error) {
 		modules.Shutdown()
 		workspaces.Shutdown()
 		plans.Shutdown()
+		plans.ArchiveBroker.Shutdown()
 		states.Shutdown()
 
 		// Wait for running tasks to terminate. Canceling the context (above)
@@ -112,6 +161,8 @@ func New(cfg Config) (*App, error) {
 		for _, plan := range plans.List() {
 			_ = os.RemoveAll(plan.ArtefactsPath)
 		}
+		// Remove task output written to disk
+		_ = tasks.RemoveOutput()
 	}
 
 	return &App{
@@ -5,12 +5,18 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform/command/cliconfig"
 	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/schedule"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/workspace"
 	"github.com/peterbourgon/ff/v4"
 	"github.com/peterbourgon/ff/v4/ffhelp"
 	"github.com/peterbourgon/ff/v4/ffyaml"
@@ -25,6 +31,17 @@ type Config struct {
 	DisableReloadAfterApply bool
 	Workdir                 internal.Workdir
 	DataDir                 string
+	MaxTaskMemory           int
+	TaskHistory             task.HistoryOptions
+	Timeouts                Timeouts
+	Retry                   task.RetryPolicy
+	ConcurrencyCaps         []task.ConcurrencyCap
+	ModuleLabels            []task.ModuleLabel
+	Schedules               []schedule.Schedule
+	Policy                  []plan.PolicyRule
+	PlanRetention           plan.ArchiveOptions
+	VarFiles                []workspace.VarFileTemplate
+	Hooks                   Hooks
 	Envs                    []string
 	Args                    []string
 	Terragrunt              bool
@@ -33,6 +50,25 @@ type Config struct {
 	Version bool
 }
 
+// minTaskMemory is the minimum bytes of output per task to hold in memory.
+const minTaskMemory = 4 << 10
+
+// Timeouts are the default timeouts for tasks.
+type Timeouts struct {
+	Init  time.Duration
+	Plan  time.Duration
+	Apply time.Duration
+	// Grace is the duration to wait after interrupting a task before killing
+	// it.
+	Grace time.Duration
+}
+
+// Hooks are user-defined shell commands run before and after tasks.
+type Hooks struct {
+	Pre  []task.Hook
+	Post []task.Hook
+}
+
 // set config in order of precedence:
 // 1. flags > 2. env vars > 3. config file
 func Parse(stderr io.Writer, args []string) (Config, error) {
@@ -50,6 +86,9 @@ func Parse(stderr io.Writer, args []string) (Config, error) {
 	workdir := fs.String('w', "workdir", ".", "The working directory containing modules.")
 	fs.IntVar(&cfg.MaxTasks, 't', "max-tasks", 2*runtime.NumCPU(), "The maximum number of parallel tasks.")
 	fs.StringVar(&cfg.DataDir, 0, "data-dir", defaultDataDir, "Directory in which to store plan files.")
+	fs.IntVar(&cfg.MaxTaskMemory, 0, "max-task-memory", 1<<20, "Maximum bytes of output per task to hold in memory; the remainder is written to the data directory. Must be at least 4096.")
+	fs.DurationVar(&cfg.TaskHistory.MaxAge, 0, "history.max-age", 0, "Maximum age of a task retained in the history. Zero disables the limit.")
+	fs.IntVar(&cfg.TaskHistory.MaxCount, 0, "history.max-count", 500, "Maximum number of tasks retained in the history. Zero disables the limit.")
 	fs.StringListVar(&cfg.Envs, 'e', "env", "Environment variable to pass to terraform process. Can set more than once.")
 	fs.StringListVar(&cfg.Args, 'a', "arg", "CLI arg to pass to terraform process. Can set more than once.")
 	fs.StringEnumVar(&cfg.FirstPage, 'f', "first-page", "The first page to open on startup.", "modules", "workspaces", "runs", "tasks", "logs")
@@ -57,6 +96,32 @@ func Parse(stderr io.Writer, args []string) (Config, error) {
 	fs.BoolVar(&cfg.Version, 'v', "version", "Print version.")
 	_ = fs.String('c', "config", defaultConfigFile, "Path to config file.")
 
+	fs.DurationVar(&cfg.Timeouts.Init, 0, "timeout.init", 0, "Timeout for init tasks. Zero disables the timeout.")
+	fs.DurationVar(&cfg.Timeouts.Plan, 0, "timeout.plan", 0, "Timeout for plan tasks. Zero disables the timeout.")
+	fs.DurationVar(&cfg.Timeouts.Apply, 0, "timeout.apply", 0, "Timeout for apply tasks. Zero disables the timeout.")
+	fs.DurationVar(&cfg.Timeouts.Grace, 0, "timeout.grace", 10*time.Second, "Time to wait after interrupting a task before killing it. Must be greater than zero.")
+
+	fs.IntVar(&cfg.Retry.MaxAttempts, 0, "retry.max-attempts", 1, "Maximum attempts of a task failing with a transient error. One disables retries.")
+	fs.DurationVar(&cfg.Retry.Backoff, 0, "retry.backoff", 10*time.Second, "Delay before retrying a task, doubling with each retry.")
+	fs.DurationVar(&cfg.Retry.MaxBackoff, 0, "retry.max-backoff", 5*time.Minute, "Maximum delay before retrying a task.")
+	retryPatterns := fs.StringList(0, "retry.pattern", "Regex matching output of a transient error. Can set more than once. Defaults to common transient errors.")
+
+	preHooks := fs.StringList(0, "hook.pre", "Command to run before a task, e.g. plan=tflint. Can set more than once.")
+	postHooks := fs.StringList(0, "hook.post", "Command to run after a task, e.g. apply=./notify.sh. Can set more than once.")
+
+	caps := fs.StringList(0, "concurrency-cap", "Cap on parallel tasks, e.g. backend:s3=3, path:prod/**=1, label:shared=2. Can set more than once.")
+	labels := fs.StringList(0, "module-label", "Label modules matching a path glob, e.g. shared=accounts/shared/**. Can set more than once.")
+
+	schedules := fs.StringList(0, "schedule", "Recurring plan of workspaces in modules matching a glob, e.g. plan:prod/*=30m. Can set more than once.")
+
+	policy := fs.StringList(0, "policy", "Rule limiting plan changes before apply, e.g. delete:aws_db_instance=0. Can set more than once.")
+
+	fs.BoolVar(&cfg.PlanRetention.Enabled, 0, "plans.retain", "Retain plan files and their JSON rendering in the data directory.")
+	fs.DurationVar(&cfg.PlanRetention.MaxAge, 0, "plans.max-age", 0, "Maximum age of a retained plan. Zero disables the limit.")
+	fs.IntVar(&cfg.PlanRetention.MaxCount, 0, "plans.max-count", 100, "Maximum number of retained plans. Zero disables the limit.")
+
+	varFiles := fs.StringList(0, "var-file", "Path template of workspace var files, e.g. env/{workspace}/*.tfvars. Can set more than once; later files take precedence.")
+
 	fs.BoolVar(&cfg.DisableReloadAfterApply, 0, "disable-reload-after-apply", "Disable automatic reload of state following an apply.")
 
 	{
@@ -93,6 +158,77 @@ func Parse(stderr io.Writer, args []string) (Config, error) {
 	if err != nil {
 		return Config{}, err
 	}
+	if cfg.MaxTaskMemory < minTaskMemory {
+		// Otherwise output would be written to a new file on almost every
+		// write.
+		return Config{}, fmt.Errorf("invalid maximum task memory: %d: must be at least %d bytes", cfg.MaxTaskMemory, minTaskMemory)
+	}
+	if cfg.Timeouts.Grace <= 0 {
+		// Otherwise a task that ignores an interrupt would never be killed.
+		return Config{}, fmt.Errorf("invalid grace period: %s: must be greater than zero", cfg.Timeouts.Grace)
+	}
+	if len(*retryPatterns) == 0 {
+		*retryPatterns = task.DefaultRetryPatterns
+	}
+	for _, pattern := range *retryPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid retry pattern: %w", err)
+		}
+		cfg.Retry.Patterns = append(cfg.Retry.Patterns, re)
+	}
+	for _, s := range *preHooks {
+		hook, err := task.ParseHook(s)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.Hooks.Pre = append(cfg.Hooks.Pre, hook)
+	}
+	for _, s := range *postHooks {
+		hook, err := task.ParseHook(s)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.Hooks.Post = append(cfg.Hooks.Post, hook)
+	}
+	for _, s := range *caps {
+		c, err := task.ParseConcurrencyCap(s)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.ConcurrencyCaps = append(cfg.ConcurrencyCaps, c)
+	}
+	for _, s := range *labels {
+		l, err := task.ParseModuleLabel(s)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.ModuleLabels = append(cfg.ModuleLabels, l)
+	}
+	for _, s := range *schedules {
+		sched, err := schedule.Parse(s)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.Schedules = append(cfg.Schedules, sched)
+	}
+	for _, s := range *policy {
+		rule, err := plan.ParsePolicyRule(s)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.Policy = append(cfg.Policy, rule)
+	}
+	if len(*varFiles) == 0 {
+		cfg.VarFiles = workspace.DefaultVarFileTemplates
+	}
+	for _, s := range *varFiles {
+		tmpl, err := workspace.ParseVarFileTemplate(s)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.VarFiles = append(cfg.VarFiles, tmpl)
+	}
 
 	return cfg, nil
 }
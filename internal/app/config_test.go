@@ -5,13 +5,19 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/schedule"
+	"github.com/leg100/pug/internal/task"
 	"github.com/leg100/pug/internal/testutils"
+	"github.com/leg100/pug/internal/workspace"
 	"github.com/peterbourgon/ff/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -44,15 +50,34 @@ func TestConfig(t *testing.T) {
 				require.NoError(t, err)
 
 				want := Config{
-					Program:   "terraform",
-					MaxTasks:  2 * runtime.NumCPU(),
-					FirstPage: "modules",
-					Workdir:   wd,
-					DataDir:   filepath.Join(os.Getenv("HOME"), ".pug"),
+					Program:       "terraform",
+					MaxTasks:      2 * runtime.NumCPU(),
+					FirstPage:     "modules",
+					Workdir:       wd,
+					DataDir:       filepath.Join(os.Getenv("HOME"), ".pug"),
+					MaxTaskMemory: 1 << 20,
+					TaskHistory: task.HistoryOptions{
+						MaxCount: 500,
+					},
+					Timeouts: Timeouts{
+						Grace: 10 * time.Second,
+					},
+					Retry: task.RetryPolicy{
+						MaxAttempts: 1,
+						Backoff:     10 * time.Second,
+						MaxBackoff:  5 * time.Minute,
+					},
+					PlanRetention: plan.ArchiveOptions{
+						MaxCount: 100,
+					},
+					VarFiles: workspace.DefaultVarFileTemplates,
 					Logging: logging.Options{
 						Level: "info",
 					},
 				}
+				for _, pattern := range task.DefaultRetryPatterns {
+					want.Retry.Patterns = append(want.Retry.Patterns, regexp.MustCompile(pattern))
+				}
 				assert.Equal(t, want, got)
 			},
 		},
@@ -74,6 +99,104 @@ func TestConfig(t *testing.T) {
 				assert.Equal(t, got.MaxTasks, 3)
 			},
 		},
+		{
+			"config file with timeouts",
+			"timeout:\n  plan: 30m\n  grace: 1m\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				assert.Equal(t, got.Timeouts.Plan, 30*time.Minute)
+				assert.Equal(t, got.Timeouts.Grace, time.Minute)
+			},
+		},
+		{
+			"config file with retry patterns",
+			"retry:\n  max-attempts: 3\n  pattern:\n    - foo\n    - bar\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				assert.Equal(t, 3, got.Retry.MaxAttempts)
+				if assert.Len(t, got.Retry.Patterns, 2) {
+					assert.Equal(t, "foo", got.Retry.Patterns[0].String())
+					assert.Equal(t, "bar", got.Retry.Patterns[1].String())
+				}
+			},
+		},
+		{
+			"config file with concurrency caps",
+			"concurrency-cap:\n  - backend:s3=3\n  - label:shared=1\nmodule-label:\n  - shared=accounts/shared/**\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				assert.Equal(t, []task.ConcurrencyCap{
+					{Kind: task.BackendCap, Value: "s3", Max: 3},
+					{Kind: task.LabelCap, Value: "shared", Max: 1},
+				}, got.ConcurrencyCaps)
+				assert.Equal(t, []task.ModuleLabel{
+					{Name: "shared", Glob: "accounts/shared/**"},
+				}, got.ModuleLabels)
+			},
+		},
+		{
+			"config file with schedules",
+			"schedule:\n  - plan:prod/*=30m\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				assert.Equal(t, []schedule.Schedule{
+					{Command: "plan", Glob: "prod/*", Interval: 30 * time.Minute},
+				}, got.Schedules)
+			},
+		},
+		{
+			"config file with plan retention",
+			"plans:\n  retain: true\n  max-age: 720h\n  max-count: 20\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				assert.Equal(t, plan.ArchiveOptions{
+					Enabled:  true,
+					MaxAge:   720 * time.Hour,
+					MaxCount: 20,
+				}, got.PlanRetention)
+			},
+		},
+		{
+			"config file with policy",
+			"policy:\n  - delete:aws_db_instance=0\n  - replace:#critical@prod*=0\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				assert.Equal(t, []plan.PolicyRule{
+					{Action: plan.DeleteAction, Type: "aws_db_instance", Max: 0},
+					{Action: plan.ReplaceAction, Tag: "critical", Workspace: "prod*", Max: 0},
+				}, got.Policy)
+			},
+		},
+		{
+			"config file with var files",
+			"var-file:\n  - common.tfvars\n  - env/{workspace}/*.tfvars\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				assert.Equal(t, []workspace.VarFileTemplate{
+					"common.tfvars",
+					"env/{workspace}/*.tfvars",
+				}, got.VarFiles)
+			},
+		},
+		{
+			"config file with hooks",
+			"hook:\n  pre:\n    - plan=tflint\n  post:\n    - apply=./notify.sh\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				assert.Equal(t, Hooks{
+					Pre:  []task.Hook{{Match: "plan", Command: "tflint"}},
+					Post: []task.Hook{{Match: "apply", Command: "./notify.sh"}},
+				}, got.Hooks)
+			},
+		},
 		{
 			"env var override default",
 			"",
@@ -186,6 +309,30 @@ func TestConfig(t *testing.T) {
 	}
 }
 
+func TestConfig_Invalid(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"zero grace period", []string{"--timeout.grace", "0s"}},
+		{"negative grace period", []string{"--timeout.grace", "-1s"}},
+		{"zero max task memory", []string{"--max-task-memory", "0"}},
+		{"negative max task memory", []string{"--max-task-memory", "-1"}},
+		{"tiny max task memory", []string{"--max-task-memory", "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// change into a temp dir in case the host computer has a pug.yaml file
+			testutils.ChTempDir(t, t.TempDir())
+
+			_, err := Parse(io.Discard, tt.args)
+			assert.Error(t, err)
+		})
+	}
+}
+
 func TestHelpFlag(t *testing.T) {
 	for _, flag := range []string{"--help", "-h"} {
 		got := new(bytes.Buffer)
@@ -0,0 +1,174 @@
+// Package attribute flattens and compares the attributes of terraform
+// resources, such as those found in state files and plan files.
+package attribute
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Rendered in place of values that are unknown or sensitive.
+const (
+	UnknownValue   = "(known after apply)"
+	SensitiveValue = "(sensitive value)"
+)
+
+// Diff is the difference between an attribute's value before and after a
+// change. Before is empty if the attribute has been added, and After is empty
+// if it has been removed.
+type Diff struct {
+	// Path to the attribute, e.g. tags.Name or ingress[0].port
+	Path   string
+	Before string
+	After  string
+}
+
+// Values are the leaf values of a resource's attributes, keyed by their path,
+// along with the paths of those values that are sensitive.
+type Values struct {
+	Leaves    map[string]any
+	Sensitive map[string]bool
+}
+
+// Compare returns the attributes that differ between two sets of values,
+// sorted by path. Unknown are the paths of values that are unknown after the
+// change, which are rendered as UnknownValue. Sensitive values are rendered as
+// SensitiveValue.
+func Compare(before, after Values, unknown map[string]bool) []Diff {
+	// Unknown values are absent from the values after the change, so their
+	// paths are taken from the unknown marks.
+	paths := make(map[string]struct{})
+	for _, m := range []map[string]any{before.Leaves, after.Leaves} {
+		for path := range m {
+			paths[path] = struct{}{}
+		}
+	}
+	for path := range unknown {
+		if path != "" {
+			paths[path] = struct{}{}
+		}
+	}
+
+	var diffs []Diff
+	for path := range paths {
+		beforeValue, inBefore := before.Leaves[path]
+		afterValue, inAfter := after.Leaves[path]
+		isUnknown := Marked(unknown, path)
+		if !isUnknown && inBefore == inAfter && reflect.DeepEqual(beforeValue, afterValue) {
+			// Unchanged
+			continue
+		}
+		diff := Diff{Path: path}
+		if inBefore {
+			diff.Before = Render(beforeValue, Marked(before.Sensitive, path))
+		}
+		if isUnknown {
+			diff.After = UnknownValue
+		} else if inAfter {
+			diff.After = Render(afterValue, Marked(after.Sensitive, path))
+		}
+		diffs = append(diffs, diff)
+	}
+	slices.SortFunc(diffs, func(a, b Diff) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+	return diffs
+}
+
+// Flatten returns the leaf values of v, keyed by their path. Null values are
+// omitted.
+func Flatten(v any) map[string]any {
+	out := make(map[string]any)
+	flatten("", v, out)
+	return out
+}
+
+func flatten(prefix string, v any, out map[string]any) {
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) == 0 && prefix != "" {
+			out[prefix] = v
+		}
+		for k, child := range v {
+			flatten(Join(prefix, k), child, out)
+		}
+	case []any:
+		if len(v) == 0 && prefix != "" {
+			out[prefix] = v
+		}
+		for i, child := range v {
+			flatten(Index(prefix, i), child, out)
+		}
+	case nil:
+	default:
+		if prefix != "" {
+			out[prefix] = v
+		}
+	}
+}
+
+// FlattenMarks returns the paths of values marked true in v, where v mirrors
+// the structure of a value, e.g. after_unknown in a plan file.
+func FlattenMarks(v any) map[string]bool {
+	out := make(map[string]bool)
+	flattenMarks("", v, out)
+	return out
+}
+
+func flattenMarks(prefix string, v any, out map[string]bool) {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			out[prefix] = true
+		}
+	case map[string]any:
+		for k, child := range v {
+			flattenMarks(Join(prefix, k), child, out)
+		}
+	case []any:
+		for i, child := range v {
+			flattenMarks(Index(prefix, i), child, out)
+		}
+	}
+}
+
+// Join returns the path of the attribute with the given name within the
+// attribute at the prefix path.
+func Join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// Index returns the path of the element with the given index within the list
+// at the prefix path.
+func Index(prefix string, i int) string {
+	return fmt.Sprintf("%s[%d]", prefix, i)
+}
+
+// Marked determines whether the path, or any of its parents, is marked.
+func Marked(marks map[string]bool, path string) bool {
+	for mark := range marks {
+		if mark == "" || mark == path || strings.HasPrefix(path, mark+".") || strings.HasPrefix(path, mark+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// Render renders a value as JSON, or as SensitiveValue if it is sensitive.
+func Render(v any, sensitive bool) string {
+	if sensitive {
+		return SensitiveValue
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,34 @@
+package attribute
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlatten(t *testing.T) {
+	got := Flatten(map[string]any{
+		"id":      "foo",
+		"tags":    map[string]any{"Name": "bar"},
+		"ingress": []any{map[string]any{"port": float64(80)}},
+		"empty":   []any{},
+		"null":    nil,
+	})
+	assert.Equal(t, map[string]any{
+		"id":              "foo",
+		"tags.Name":       "bar",
+		"ingress[0].port": float64(80),
+		"empty":           []any{},
+	}, got)
+}
+
+func TestMarked(t *testing.T) {
+	marks := map[string]bool{"tags": true, "ingress[0]": true}
+
+	assert.True(t, Marked(marks, "tags"))
+	assert.True(t, Marked(marks, "tags.Name"))
+	assert.True(t, Marked(marks, "ingress[0].port"))
+	assert.False(t, Marked(marks, "tags_all.Name"))
+	assert.False(t, Marked(marks, "ingress[1].port"))
+	assert.True(t, Marked(map[string]bool{"": true}, "id"))
+}
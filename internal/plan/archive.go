@@ -0,0 +1,303 @@
+package plan
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/leg100/pug/internal/resource"
+)
+
+const (
+	archiveDirName    = "plans"
+	archiveRecordFile = "record.json"
+	archivePlanFile   = "plan"
+	archiveShowFile   = "show.json"
+	// archiveTimeFormat is the format of the name of the directory in which
+	// a plan is archived.
+	archiveTimeFormat = "20060102T150405.000Z"
+)
+
+// Archived is a plan retained in the data directory, along with its JSON
+// rendering, so that it can be browsed in later pug sessions.
+type Archived struct {
+	resource.ID `json:"-"`
+
+	ModulePath    string    `json:"module_path"`
+	WorkspaceName string    `json:"workspace_name"`
+	Created       time.Time `json:"created"`
+	Destroy       bool      `json:"destroy,omitempty"`
+	Report        Report    `json:"report"`
+
+	// dir is the directory in which the plan is archived.
+	dir string
+	// changes are the resource changes in the plan, and are only set once
+	// they have been read from the archive.
+	changes []*ResourceChange
+}
+
+func (a *Archived) String() string {
+	return fmt.Sprintf("%s:%s", a.ModulePath, a.WorkspaceName)
+}
+
+// PlanPath is the path to the archived plan file.
+func (a *Archived) PlanPath() string {
+	return filepath.Join(a.dir, archivePlanFile)
+}
+
+// ArchiveOptions configure the retention of plans.
+type ArchiveOptions struct {
+	// Enabled retains plans in the data directory.
+	Enabled bool
+	// MaxAge is the maximum age of a retained plan. Zero means no limit.
+	MaxAge time.Duration
+	// MaxCount is the maximum number of retained plans. Zero means no limit.
+	MaxCount int
+}
+
+// archive retains plans in a directory, indexed by module, workspace and
+// time.
+type archive struct {
+	ArchiveOptions
+
+	// dir is the directory in which plans are archived. If empty then plans
+	// are not archived.
+	dir   string
+	table *resource.Table[*Archived]
+}
+
+func (a *archive) disabled() bool {
+	return a.dir == ""
+}
+
+// save archives a plan file along with its JSON rendering.
+func (a *archive) save(rec *Archived, planPath string, shown []byte) error {
+	if a.disabled() {
+		return nil
+	}
+	rec.ID = resource.NewID(resource.ArchivedPlan)
+	rec.dir = filepath.Join(a.dir, rec.ModulePath, rec.WorkspaceName, rec.Created.UTC().Format(archiveTimeFormat))
+	if err := os.MkdirAll(rec.dir, 0o700); err != nil {
+		return fmt.Errorf("creating plan archive directory: %w", err)
+	}
+	if err := copyFile(planPath, rec.PlanPath()); err != nil {
+		return fmt.Errorf("archiving plan file: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(rec.dir, archiveShowFile), shown, 0o600); err != nil {
+		return fmt.Errorf("archiving plan rendering: %w", err)
+	}
+	// Write record last, so that a record is never found without its plan.
+	body, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(rec.dir, archiveRecordFile), body, 0o600); err != nil {
+		return err
+	}
+	a.table.Add(rec.ID, rec)
+	return nil
+}
+
+// load reads archived plans from disk.
+func (a *archive) load() error {
+	if a.disabled() {
+		return nil
+	}
+	err := filepath.WalkDir(a.dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == a.dir {
+			// Nothing archived yet.
+			return filepath.SkipDir
+		} else if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != archiveRecordFile {
+			return nil
+		}
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var rec Archived
+		if err := json.Unmarshal(body, &rec); err != nil {
+			return fmt.Errorf("loading archived plan %s: %w", path, err)
+		}
+		rec.ID = resource.NewID(resource.ArchivedPlan)
+		rec.dir = filepath.Dir(path)
+		a.table.Add(rec.ID, &rec)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("loading archived plans: %w", err)
+	}
+	return nil
+}
+
+// gc removes archived plans exceeding the maximum age or count, oldest
+// first, returning the number removed.
+func (a *archive) gc(now time.Time) (int, error) {
+	if a.disabled() {
+		return 0, nil
+	}
+	plans := a.table.List()
+	slices.SortFunc(plans, SortArchived)
+	var removed int
+	for i, rec := range plans {
+		tooMany := a.MaxCount > 0 && i >= a.MaxCount
+		tooOld := a.MaxAge > 0 && now.Sub(rec.Created) > a.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := os.RemoveAll(rec.dir); err != nil {
+			return removed, fmt.Errorf("removing archived plan: %w", err)
+		}
+		a.table.Delete(rec.ID)
+		removed++
+	}
+	return removed, nil
+}
+
+// changes retrieves the resource changes of an archived plan, reading them
+// from its JSON rendering if they have not already been read.
+func (a *archive) changes(id resource.ID) ([]*ResourceChange, error) {
+	rec, err := a.table.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if rec.changes != nil {
+		return rec.changes, nil
+	}
+	shown, err := os.ReadFile(filepath.Join(rec.dir, archiveShowFile))
+	if err != nil {
+		return nil, fmt.Errorf("reading archived plan: %w", err)
+	}
+	changes, err := parsePlanFile(bytes.NewReader(shown))
+	if err != nil {
+		return nil, err
+	}
+	_, err = a.table.Update(id, func(existing *Archived) error {
+		existing.changes = changes
+		return nil
+	})
+	return changes, err
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// SortArchived sorts archived plans, newest first.
+func SortArchived(i, j *Archived) int {
+	return cmp.Compare(j.Created.UnixNano(), i.Created.UnixNano())
+}
+
+// archiveGCInterval is how often archived plans exceeding the retention
+// limits are removed.
+const archiveGCInterval = time.Hour
+
+// archivePlan archives a plan along with its JSON rendering, if retention is
+// enabled and the plan was not created by a schedule.
+func (s *Service) archivePlan(planID resource.ID, shown []byte) error {
+	if s.archive.disabled() {
+		return nil
+	}
+	p, err := s.table.Get(planID)
+	if err != nil {
+		return err
+	}
+	if p.Scheduled {
+		return nil
+	}
+	ws, err := s.workspaces.Get(p.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	rec := &Archived{
+		ModulePath:    p.ModulePath,
+		WorkspaceName: ws.Name,
+		Created:       time.Now(),
+		Destroy:       p.Destroy,
+		Report:        p.report,
+	}
+	if err := s.archive.save(rec, p.planPath(), shown); err != nil {
+		return err
+	}
+	s.logger.Debug("archived plan", "plan", p, "path", rec.dir)
+	return nil
+}
+
+// LoadArchive loads plans archived by previous pug sessions.
+func (s *Service) LoadArchive() error {
+	if err := s.archive.load(); err != nil {
+		return err
+	}
+	s.logger.Info("loaded archived plans", "plans", len(s.archive.table.List()))
+	return nil
+}
+
+// StartArchiveGC periodically removes archived plans exceeding the retention
+// limits, until the context is canceled.
+func (s *Service) StartArchiveGC(ctx context.Context) {
+	if s.archive.disabled() {
+		return
+	}
+	gc := func() {
+		removed, err := s.archive.gc(time.Now())
+		if err != nil {
+			s.logger.Error("removing archived plans", "error", err)
+		}
+		if removed > 0 {
+			s.logger.Info("removed archived plans", "plans", removed)
+		}
+	}
+	gc()
+	go func() {
+		ticker := time.NewTicker(archiveGCInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				gc()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// ListArchived lists archived plans.
+func (s *Service) ListArchived() []*Archived {
+	return s.archive.table.List()
+}
+
+// GetArchived retrieves an archived plan.
+func (s *Service) GetArchived(id resource.ID) (*Archived, error) {
+	return s.archive.table.Get(id)
+}
+
+// ArchivedChanges retrieves the resource changes of an archived plan.
+func (s *Service) ArchivedChanges(id resource.ID) ([]*ResourceChange, error) {
+	return s.archive.changes(id)
+}
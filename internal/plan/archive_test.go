@@ -0,0 +1,152 @@
+package plan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchive(t *testing.T) {
+	newArchive := func(dir string, opts ArchiveOptions) *archive {
+		return &archive{
+			ArchiveOptions: opts,
+			dir:            dir,
+			table:          resource.NewTable(&fakePublisher[*Archived]{}),
+		}
+	}
+	dir := t.TempDir()
+	a := newArchive(dir, ArchiveOptions{})
+
+	// Create plan file to archive
+	planPath := filepath.Join(t.TempDir(), "plan")
+	require.NoError(t, os.WriteFile(planPath, []byte("plan file"), 0o600))
+	shown, err := os.ReadFile("testdata/show.json")
+	require.NoError(t, err)
+
+	now := time.Now()
+	for i, ws := range []string{"dev", "staging", "prod"} {
+		err := a.save(&Archived{
+			ModulePath:    "a/b",
+			WorkspaceName: ws,
+			Created:       now.Add(-time.Duration(i) * time.Hour),
+			Report:        Report{Additions: 1, Changes: 1, Destructions: 2},
+		}, planPath, shown)
+		require.NoError(t, err)
+	}
+	assert.FileExists(t, filepath.Join(dir, "a", "b", "dev", now.UTC().Format(archiveTimeFormat), archivePlanFile))
+
+	t.Run("load", func(t *testing.T) {
+		loaded := newArchive(dir, ArchiveOptions{})
+		require.NoError(t, loaded.load())
+
+		got := loaded.table.List()
+		require.Len(t, got, 3)
+		for _, rec := range got {
+			assert.Equal(t, "a/b", rec.ModulePath)
+			assert.Equal(t, Report{Additions: 1, Changes: 1, Destructions: 2}, rec.Report)
+		}
+	})
+
+	t.Run("load nothing archived", func(t *testing.T) {
+		loaded := newArchive(filepath.Join(t.TempDir(), "plans"), ArchiveOptions{})
+		require.NoError(t, loaded.load())
+		assert.Empty(t, loaded.table.List())
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		rec := a.table.List()[0]
+		changes, err := a.changes(rec.ID)
+		require.NoError(t, err)
+		assert.Len(t, changes, 4)
+	})
+
+	tests := []struct {
+		name string
+		opts ArchiveOptions
+		// workspaces of the plans expected to remain after gc
+		want []string
+	}{
+		{"no limits", ArchiveOptions{}, []string{"dev", "staging", "prod"}},
+		{"max count", ArchiveOptions{MaxCount: 2}, []string{"dev", "staging"}},
+		{"max age", ArchiveOptions{MaxAge: 90 * time.Minute}, []string{"dev", "staging"}},
+		{"max count and age", ArchiveOptions{MaxCount: 2, MaxAge: 30 * time.Minute}, []string{"dev"}},
+	}
+	for _, tt := range tests {
+		t.Run("gc "+tt.name, func(t *testing.T) {
+			// Archive to a copy, so that each test starts afresh.
+			gcDir := t.TempDir()
+			gcArchive := newArchive(gcDir, tt.opts)
+			for _, rec := range a.table.List() {
+				err := gcArchive.save(&Archived{
+					ModulePath:    rec.ModulePath,
+					WorkspaceName: rec.WorkspaceName,
+					Created:       rec.Created,
+				}, planPath, shown)
+				require.NoError(t, err)
+			}
+
+			removed, err := gcArchive.gc(now)
+			require.NoError(t, err)
+			assert.Equal(t, 3-len(tt.want), removed)
+
+			var got []string
+			for _, rec := range gcArchive.table.List() {
+				got = append(got, rec.WorkspaceName)
+				assert.DirExists(t, rec.dir)
+			}
+			assert.ElementsMatch(t, tt.want, got)
+
+			// Removed plans should not be reloaded.
+			reloaded := newArchive(gcDir, ArchiveOptions{})
+			require.NoError(t, reloaded.load())
+			assert.Len(t, reloaded.table.List(), len(tt.want))
+		})
+	}
+}
+
+func TestService_archivePlan(t *testing.T) {
+	shown, err := os.ReadFile("testdata/show.json")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		scheduled bool
+		want      int
+	}{
+		{"plan", false, 1},
+		{"scheduled plan", true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _, ws := setupTest(t)
+			svc := &Service{
+				table:      resource.NewTable(&fakePublisher[*plan]{}),
+				factory:    f,
+				workspaces: f.workspaces,
+				logger:     logging.Discard,
+				archive: &archive{
+					ArchiveOptions: ArchiveOptions{Enabled: true},
+					dir:            t.TempDir(),
+					table:          resource.NewTable(&fakePublisher[*Archived]{}),
+				},
+			}
+			p, err := svc.newPlan(ws.ID, CreateOptions{Scheduled: tt.scheduled, planFile: true})
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(p.planPath(), []byte("plan file"), 0o600))
+			svc.table.Add(p.ID, p)
+
+			require.NoError(t, svc.archivePlan(p.ID, shown))
+			assert.Len(t, svc.ListArchived(), tt.want)
+		})
+	}
+}
+
+type fakePublisher[T any] struct{}
+
+func (f *fakePublisher[T]) Publish(resource.EventType, T) {}
@@ -0,0 +1,235 @@
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/leg100/pug/internal/task"
+)
+
+// message is a message from terraform's machine-readable UI, which is
+// streamed one message per line when the -json flag is passed to plan and
+// apply.
+type message struct {
+	Level   string `json:"@level"`
+	Message string `json:"@message"`
+	Type    string `json:"type"`
+	// Changes is populated for change_summary messages.
+	Changes *changeSummary `json:"changes"`
+	// Diagnostic is populated for diagnostic messages.
+	Diagnostic *diagnostic `json:"diagnostic"`
+	// Outputs is populated for outputs messages.
+	Outputs map[string]output `json:"outputs"`
+	// Change is populated for planned_change messages.
+	Change *plannedChange `json:"change"`
+	// Hook is populated for messages reporting the progress of an operation
+	// on a resource, e.g. apply_start and apply_complete.
+	Hook *hook `json:"hook"`
+}
+
+type resourceAddr struct {
+	Addr string `json:"addr"`
+}
+
+type plannedChange struct {
+	Resource resourceAddr `json:"resource"`
+	Action   string       `json:"action"`
+}
+
+type hook struct {
+	Resource       resourceAddr `json:"resource"`
+	Action         string       `json:"action"`
+	ElapsedSeconds int          `json:"elapsed_seconds"`
+}
+
+type changeSummary struct {
+	Add       int    `json:"add"`
+	Change    int    `json:"change"`
+	Import    int    `json:"import"`
+	Remove    int    `json:"remove"`
+	Operation string `json:"operation"`
+}
+
+type diagnostic struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail"`
+}
+
+type output struct {
+	Sensitive bool `json:"sensitive"`
+	// Action is the planned action for the output, and is only populated
+	// during a plan.
+	Action string `json:"action"`
+	// Value is the value of the output, and is only populated after an
+	// apply.
+	Value json.RawMessage `json:"value"`
+}
+
+// decodeMessage decodes a line of terraform's machine-readable UI. False is
+// returned if the line is not a message.
+func decodeMessage(line []byte) (message, bool) {
+	var msg message
+	if err := json.Unmarshal(line, &msg); err != nil || msg.Type == "" {
+		return message{}, false
+	}
+	return msg, true
+}
+
+// decodeMessages decodes the messages in the output from terraform's
+// machine-readable UI, skipping any lines that are not messages.
+func decodeMessages(out []byte) []message {
+	var msgs []message
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		if msg, ok := decodeMessage(line); ok {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+// render renders the message in a human-readable form.
+func (m message) render() string {
+	switch m.Type {
+	case "version":
+		return ""
+	case "diagnostic":
+		if m.Diagnostic == nil || m.Diagnostic.Detail == "" {
+			return fmt.Sprintf("\n%s\n\n", m.Message)
+		}
+		return fmt.Sprintf("\n%s\n\n%s\n\n", m.Message, m.Diagnostic.Detail)
+	case "change_summary":
+		return fmt.Sprintf("\n%s\n", m.Message)
+	case "outputs":
+		return m.renderOutputs()
+	default:
+		return m.Message + "\n"
+	}
+}
+
+var outputActionSymbols = map[string]string{
+	"create": "+",
+	"update": "~",
+	"delete": "-",
+}
+
+func (m message) renderOutputs() string {
+	names := make([]string, 0, len(m.Outputs))
+	for name := range m.Outputs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		out := m.Outputs[name]
+		switch {
+		case out.Action != "":
+			// Planned output change
+			symbol, ok := outputActionSymbols[out.Action]
+			if !ok {
+				continue
+			}
+			if b.Len() == 0 {
+				b.WriteString("\nChanges to Outputs:\n")
+			}
+			fmt.Fprintf(&b, "  %s %s\n", symbol, name)
+		default:
+			// Applied output value
+			if b.Len() == 0 {
+				b.WriteString("\nOutputs:\n\n")
+			}
+			value := string(out.Value)
+			if out.Sensitive {
+				value = "<sensitive>"
+			}
+			fmt.Fprintf(&b, "%s = %s\n", name, value)
+		}
+	}
+	return b.String()
+}
+
+// shownMessageTypes are the types of message summarising the changes in a
+// plan, which are instead rendered in full by showing the plan file.
+var shownMessageTypes = []string{
+	"planned_change",
+	"resource_drift",
+	"change_summary",
+	"outputs",
+}
+
+// renderer renders terraform's machine-readable UI in a human-readable form,
+// writing the result to the underlying writer. Lines that are not messages
+// are written unaltered.
+type renderer struct {
+	w io.Writer
+	// buf holds an incomplete line.
+	buf []byte
+	// omit is the types of message that are not rendered.
+	omit []string
+	// task, if non-nil, is the task to which the progress of the apply is
+	// reported.
+	task     *task.Task
+	progress *ApplyProgress
+}
+
+// newPlanRenderer constructs a renderer for a plan that is followed by
+// showing the plan file, omitting the messages that summarise the changes
+// in favour of the full diff that is shown.
+func newPlanRenderer(_ *task.Task, w io.Writer) io.WriteCloser {
+	return &renderer{w: w, omit: shownMessageTypes}
+}
+
+// newApplyRenderer constructs a renderer that additionally reports the
+// progress of an apply to its task.
+func newApplyRenderer(t *task.Task, w io.Writer) io.WriteCloser {
+	return &renderer{w: w, task: t, progress: &ApplyProgress{}}
+}
+
+func (r *renderer) Write(p []byte) (int, error) {
+	r.buf = append(r.buf, p...)
+	for {
+		i := bytes.IndexByte(r.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := r.renderLine(r.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		r.buf = r.buf[i+1:]
+	}
+	// Copy the incomplete line so that rendered lines can be garbage
+	// collected.
+	r.buf = bytes.Clone(r.buf)
+	return len(p), nil
+}
+
+// Close renders any remaining incomplete line.
+func (r *renderer) Close() error {
+	if len(r.buf) == 0 {
+		return nil
+	}
+	err := r.renderLine(r.buf)
+	r.buf = nil
+	return err
+}
+
+func (r *renderer) renderLine(line []byte) error {
+	msg, ok := decodeMessage(line)
+	if !ok {
+		_, err := r.w.Write(line)
+		return err
+	}
+	if r.progress != nil && r.progress.handle(msg) {
+		r.task.ReportProgress(r.progress)
+	}
+	if slices.Contains(r.omit, msg.Type) {
+		return nil
+	}
+	_, err := io.WriteString(r.w, msg.render())
+	return err
+}
@@ -0,0 +1,109 @@
+package plan
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderer(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		// plan is true if the output is from a plan that is followed by
+		// showing the plan file.
+		plan bool
+		want string
+	}{
+		{
+			name: "plan",
+			file: "testdata/plan_with_changes.jsonl",
+			want: `null_resource.demo2: Refreshing state... [id=5775967549090285526]
+null_resource.demo2: Refresh complete [id=5775967549090285526]
+null_resource.demo2: Plan to delete
+null_resource.demo5: Plan to create
+
+Plan: 1 to add, 0 to change, 1 to destroy.
+`,
+		},
+		{
+			name: "plan followed by show",
+			file: "testdata/plan_with_changes.jsonl",
+			plan: true,
+			want: `null_resource.demo2: Refreshing state... [id=5775967549090285526]
+null_resource.demo2: Refresh complete [id=5775967549090285526]
+`,
+		},
+		{
+			name: "plan output changes",
+			file: "testdata/plan_output_changes.jsonl",
+			want: `
+Plan: 0 to add, 0 to change, 0 to destroy.
+
+Changes to Outputs:
+  + foo
+`,
+		},
+		{
+			name: "plan error",
+			file: "testdata/plan_error.jsonl",
+			plan: true,
+			want: `
+Error: Unsupported argument
+
+An argument named "foo" is not expected here.
+
+`,
+		},
+		{
+			name: "apply",
+			file: "testdata/apply.jsonl",
+			want: `random_pet.pet: Plan to create
+random_pet.pet: Creating...
+random_pet.pet: Creation complete after 0s [id=novel-monkey]
+
+Apply complete! Resources: 1 added, 0 changed, 0 destroyed.
+
+Outputs:
+
+pet = "novel-monkey"
+secret = <sensitive>
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := os.ReadFile(tt.file)
+			require.NoError(t, err)
+
+			var got bytes.Buffer
+			var r io.WriteCloser = &renderer{w: &got}
+			if tt.plan {
+				r = newPlanRenderer(nil, &got)
+			}
+			// Write output in small chunks to exercise the buffering of
+			// incomplete lines.
+			for i := 0; i < len(out); i += 7 {
+				_, err := r.Write(out[i:min(i+7, len(out))])
+				require.NoError(t, err)
+			}
+			require.NoError(t, r.Close())
+
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestRenderer_NonJSON(t *testing.T) {
+	var got bytes.Buffer
+	r := newPlanRenderer(nil, &got)
+	_, err := r.Write([]byte("Initializing the backend...\nno trailing newline"))
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	assert.Equal(t, "Initializing the backend...\nno trailing newline", got.String())
+}
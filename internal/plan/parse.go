@@ -1,104 +1,100 @@
 package plan
 
 import (
-	"errors"
 	"fmt"
-	"regexp"
-	"strconv"
-
-	"github.com/leg100/pug/internal"
-)
-
-var (
-	planChangesRegex       = regexp.MustCompile(`Plan: (\d+) to add, (\d+) to change, (\d+) to destroy.`)
-	planOutputChangesRegex = regexp.MustCompile(`Changes to Outputs:`)
-	noChangesRegex         = regexp.MustCompile(`No changes. Your infrastructure matches the configuration.`)
-	applyChangesRegex      = regexp.MustCompile(`(?m)^Apply complete! Resources: (\d+) added, (\d+) changed, (\d+) destroyed.`)
-	destroyChangesRegex    = regexp.MustCompile(`Destroy complete! Resources: (\d+) destroyed.`)
+	"strings"
 )
 
-// parsePlanReport reads the logs from `terraform plan` and detects whether
-// there were any changes and produces a report of the number of resource
-// changes.
+// parsePlanReport reads the machine-readable output from `terraform plan
+// -json` and detects whether there were any changes and produces a report of
+// the number of resource changes.
 //
-// TODO: parse bytes intead, to skip re-allocation
-func parsePlanReport(logs string) (bool, Report, error) {
-	raw := internal.StripAnsi(logs)
-
-	// No changes
-	if noChangesRegex.MatchString(raw) {
-		return false, Report{}, nil
-	}
-
-	// Resource changes
-	matches := planChangesRegex.FindStringSubmatch(raw)
-	if matches != nil {
-		adds, err := strconv.ParseInt(matches[1], 10, 0)
-		if err != nil {
-			return false, Report{}, err
+// If refreshOnly is true then the report instead counts the resources that
+// have drifted, which terraform reports in resource_drift messages.
+func parsePlanReport(out []byte, refreshOnly bool) (bool, Report, error) {
+	var (
+		summary        *changeSummary
+		drift          = Report{RefreshOnly: true}
+		outputsChanged bool
+		diags          []string
+	)
+	for _, msg := range decodeMessages(out) {
+		switch msg.Type {
+		case "change_summary":
+			summary = msg.Changes
+		case "resource_drift":
+			if msg.Change != nil {
+				drift.addDrift(msg.Change.Action)
+			}
+		case "outputs":
+			for _, o := range msg.Outputs {
+				if o.Action != "" && o.Action != "noop" {
+					outputsChanged = true
+				}
+			}
+		case "diagnostic":
+			if msg.Diagnostic != nil && msg.Diagnostic.Severity == "error" {
+				diags = append(diags, msg.Diagnostic.Summary)
+			}
 		}
-		changes, err := strconv.ParseInt(matches[2], 10, 0)
-		if err != nil {
-			return false, Report{}, err
-		}
-		deletions, err := strconv.ParseInt(matches[3], 10, 0)
-		if err != nil {
-			return false, Report{}, err
-		}
-		return true, Report{
-			Additions:    int(adds),
-			Changes:      int(changes),
-			Destructions: int(deletions),
-		}, nil
 	}
-
-	// Output changes
-	if planOutputChangesRegex.MatchString(raw) {
-		return true, Report{}, nil
+	if summary == nil {
+		// Something went wrong
+		return false, Report{}, unexpectedOutputError("plan", diags)
 	}
-
-	// Something went wrong
-	return false, Report{}, errors.New("unexpected plan output: failed to detect changes")
+	if refreshOnly {
+		return drift.HasChanges() || outputsChanged, drift, nil
+	}
+	report := Report{
+		Additions:    summary.Add,
+		Changes:      summary.Change,
+		Destructions: summary.Remove,
+	}
+	changes := report.HasChanges() || outputsChanged
+	return changes, report, nil
 }
 
-// parseApplyReport reads the logs from `terraform apply` and produces a report
-// of the changes made.
+// parseApplyReport reads the machine-readable output from `terraform apply
+// -json` and produces a report of the changes made.
 //
-// TODO: parse bytes intead, to skip re-allocation
-func parseApplyReport(logs string) (Report, error) {
-	raw := internal.StripAnsi(logs)
-
-	if noChangesRegex.MatchString(raw) {
-		// No changes
-		return Report{}, nil
-	}
-	if matches := destroyChangesRegex.FindStringSubmatch(raw); len(matches) > 1 {
-		// Destroy op
-		deletions, err := strconv.Atoi(matches[1])
-		if err != nil {
-			return Report{}, err
+// If refreshOnly is true then the report instead counts the resources that
+// have drifted and have been updated in state.
+func parseApplyReport(out []byte, refreshOnly bool) (Report, error) {
+	var (
+		summary *changeSummary
+		drift   = Report{RefreshOnly: true}
+		diags   []string
+	)
+	for _, msg := range decodeMessages(out) {
+		switch msg.Type {
+		case "change_summary":
+			summary = msg.Changes
+		case "resource_drift":
+			if msg.Change != nil {
+				drift.addDrift(msg.Change.Action)
+			}
+		case "diagnostic":
+			if msg.Diagnostic != nil && msg.Diagnostic.Severity == "error" {
+				diags = append(diags, msg.Diagnostic.Summary)
+			}
 		}
-		return Report{Destructions: deletions}, nil
 	}
-	if matches := applyChangesRegex.FindStringSubmatch(raw); len(matches) > 3 {
-		// Apply op
-		adds, err := strconv.Atoi(matches[1])
-		if err != nil {
-			return Report{}, err
-		}
-		changes, err := strconv.Atoi(matches[2])
-		if err != nil {
-			return Report{}, err
-		}
-		deletions, err := strconv.Atoi(matches[3])
-		if err != nil {
-			return Report{}, err
-		}
-		return Report{
-			Additions:    adds,
-			Changes:      changes,
-			Destructions: deletions,
-		}, nil
+	if summary == nil {
+		return Report{}, unexpectedOutputError("apply", diags)
+	}
+	if refreshOnly {
+		return drift, nil
+	}
+	return Report{
+		Additions:    summary.Add,
+		Changes:      summary.Change,
+		Destructions: summary.Remove,
+	}, nil
+}
+
+func unexpectedOutputError(cmd string, diags []string) error {
+	if len(diags) > 0 {
+		return fmt.Errorf("%s failed: %s", cmd, strings.Join(diags, "; "))
 	}
-	return Report{}, fmt.Errorf("regexes unexpectedly did not match apply output")
+	return fmt.Errorf("unexpected %s output: failed to detect changes", cmd)
 }
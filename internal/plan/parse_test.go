@@ -9,10 +9,10 @@ import (
 )
 
 func Test_ParsePlanReport(t *testing.T) {
-	logs, err := os.ReadFile("testdata/plan_with_changes.txt")
+	logs, err := os.ReadFile("testdata/plan_with_changes.jsonl")
 	require.NoError(t, err)
 
-	changed, got, err := parsePlanReport(string(logs))
+	changed, got, err := parsePlanReport(logs, false)
 	require.NoError(t, err)
 
 	assert.True(t, changed)
@@ -25,10 +25,10 @@ func Test_ParsePlanReport(t *testing.T) {
 }
 
 func Test_ParsePlanReport_OutputChanges(t *testing.T) {
-	logs, err := os.ReadFile("./testdata/plan_output_changes.txt")
+	logs, err := os.ReadFile("./testdata/plan_output_changes.jsonl")
 	require.NoError(t, err)
 
-	changed, got, err := parsePlanReport(string(logs))
+	changed, got, err := parsePlanReport(logs, false)
 	require.NoError(t, err)
 
 	// no resource changes, but the outputs did change, so should be true.
@@ -42,10 +42,10 @@ func Test_ParsePlanReport_OutputChanges(t *testing.T) {
 }
 
 func Test_ParsePlanReport_NoChanges(t *testing.T) {
-	logs, err := os.ReadFile("./testdata/plan_no_changes.txt")
+	logs, err := os.ReadFile("./testdata/plan_no_changes.jsonl")
 	require.NoError(t, err)
 
-	changed, got, err := parsePlanReport(string(logs))
+	changed, got, err := parsePlanReport(logs, false)
 	require.NoError(t, err)
 
 	assert.False(t, changed)
@@ -58,11 +58,30 @@ func Test_ParsePlanReport_NoChanges(t *testing.T) {
 	assert.Equal(t, want, got)
 }
 
+func Test_ParsePlanReport_RefreshOnly(t *testing.T) {
+	logs, err := os.ReadFile("./testdata/plan_refresh_only.jsonl")
+	require.NoError(t, err)
+
+	changed, got, err := parsePlanReport(logs, true)
+	require.NoError(t, err)
+
+	// no resource changes, but resources have drifted, so should be true.
+	assert.True(t, changed)
+	want := Report{
+		Additions:    0,
+		Changes:      1,
+		Destructions: 1,
+		RefreshOnly:  true,
+	}
+	assert.Equal(t, want, got)
+	assert.Equal(t, "drift: ~1/\u22121", got.String())
+}
+
 func Test_ParseApplyReport(t *testing.T) {
-	logs, err := os.ReadFile("testdata/apply.txt")
+	logs, err := os.ReadFile("testdata/apply.jsonl")
 	require.NoError(t, err)
 
-	got, err := parseApplyReport(string(logs))
+	got, err := parseApplyReport(logs, false)
 
 	require.NoError(t, err)
 	want := Report{
@@ -74,10 +93,10 @@ func Test_ParseApplyReport(t *testing.T) {
 }
 
 func Test_ParseApplyReport_NoChanges(t *testing.T) {
-	logs, err := os.ReadFile("testdata/apply_no_changes.txt")
+	logs, err := os.ReadFile("testdata/apply_no_changes.jsonl")
 	require.NoError(t, err)
 
-	got, err := parseApplyReport(string(logs))
+	got, err := parseApplyReport(logs, false)
 	require.NoError(t, err)
 
 	want := Report{
@@ -89,10 +108,10 @@ func Test_ParseApplyReport_NoChanges(t *testing.T) {
 }
 
 func Test_ParseDestroyReport(t *testing.T) {
-	logs, err := os.ReadFile("testdata/destroy.txt")
+	logs, err := os.ReadFile("testdata/destroy.jsonl")
 	require.NoError(t, err)
 
-	got, err := parseApplyReport(string(logs))
+	got, err := parseApplyReport(logs, false)
 
 	require.NoError(t, err)
 	want := Report{
@@ -102,3 +121,11 @@ func Test_ParseDestroyReport(t *testing.T) {
 	}
 	assert.Equal(t, want, got)
 }
+
+func Test_ParsePlanReport_Error(t *testing.T) {
+	logs, err := os.ReadFile("./testdata/plan_error.jsonl")
+	require.NoError(t, err)
+
+	_, _, err = parsePlanReport(logs, false)
+	assert.EqualError(t, err, "plan failed: Unsupported argument")
+}
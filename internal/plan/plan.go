@@ -1,6 +1,7 @@
 package plan
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -23,25 +24,63 @@ type plan struct {
 	HasChanges    bool
 	ArtefactsPath string
 	Destroy       bool
+	RefreshOnly   bool
 	TargetAddrs   []state.ResourceAddress
+	ReplaceAddrs  []state.ResourceAddress
+	Variables     Variables
+	// Scheduled is true if the plan was created by a schedule.
+	Scheduled bool
 
-	targetArgs         []string
-	terragrunt         bool
-	planFile           bool
-	varsFileArg        *string
+	targetArgs  []string
+	replaceArgs []string
+	terragrunt  bool
+	planFile    bool
+	varFileArgs []string
+	// varFiles are the paths of the plan's variables files, relative to the
+	// module directory, including any variable overrides files.
+	varFiles           []string
 	envs               []string
 	moduleDependencies []resource.ID
 
+	// report summarises the changes in the plan, and is only set once the
+	// plan task has finished.
+	report Report
 	// taskID is the ID of the plan task, and is only set once the task is
 	// created.
 	taskID *resource.ID
+	// changes are the resource changes in the plan file, and are only set
+	// once the plan file has been shown.
+	changes []*ResourceChange
+	// violations are breaches of policy by the changes.
+	violations []Violation
+	// showTaskID is the ID of the most recent task to show the plan file, and
+	// is only set once the task is created.
+	showTaskID *resource.ID
+	// inputs are the inputs to the plan, recorded when the plan task starts.
+	inputs *inputs
+	// stale is the reason the plan has become stale, or empty if it is not
+	// stale.
+	stale string
+	// applied is true once the plan is being applied.
+	applied bool
 }
 
 type CreateOptions struct {
 	// TargetAddrs creates a plan targeting specific resources.
 	TargetAddrs []state.ResourceAddress
+	// ReplaceAddrs creates a plan replacing specific resources.
+	ReplaceAddrs []state.ResourceAddress
+	// Variables are variable overrides passed to both the plan and the
+	// apply.
+	Variables Variables
 	// Destroy creates a plan to destroy all resources.
 	Destroy bool
+	// RefreshOnly creates a plan that only updates state to match any
+	// changes made outside of terraform, without changing any resources.
+	RefreshOnly bool
+	// Scheduled marks a plan created by a schedule. Scheduled plans are not
+	// retained in the archive, so that they don't evict the user's own plans.
+	Scheduled bool
 	// planFile is true if a plan file is first created with `terraform plan
 	// -out plan.file`.
 	planFile bool
@@ -61,6 +100,9 @@ func (f *factory) newPlan(workspaceID resource.ID, opts CreateOptions) (*plan, e
 	if err != nil {
 		return nil, fmt.Errorf("retrieving workspace: %w", err)
 	}
+	if opts.Destroy && opts.RefreshOnly {
+		return nil, errors.New("a plan cannot be both a destroy and a refresh-only plan")
+	}
 	mod, err := f.modules.Get(ws.ModuleID)
 	if err != nil {
 		return nil, fmt.Errorf("retrieving module: %w", err)
@@ -71,7 +113,11 @@ func (f *factory) newPlan(workspaceID resource.ID, opts CreateOptions) (*plan, e
 		WorkspaceID:        ws.ID,
 		ModulePath:         mod.Path,
 		Destroy:            opts.Destroy,
+		RefreshOnly:        opts.RefreshOnly,
 		TargetAddrs:        opts.TargetAddrs,
+		ReplaceAddrs:       opts.ReplaceAddrs,
+		Variables:          opts.Variables,
+		Scheduled:          opts.Scheduled,
 		planFile:           opts.planFile,
 		terragrunt:         f.terragrunt,
 		envs:               []string{ws.TerraformEnv()},
@@ -86,10 +132,14 @@ func (f *factory) newPlan(workspaceID resource.ID, opts CreateOptions) (*plan, e
 	for _, addr := range plan.TargetAddrs {
 		plan.targetArgs = append(plan.targetArgs, fmt.Sprintf("-target=%s", addr))
 	}
-	if fname, ok := ws.VarsFile(f.workdir); ok {
-		flag := fmt.Sprintf("-var-file=%s", fname)
-		plan.varsFileArg = &flag
+	for _, addr := range plan.ReplaceAddrs {
+		plan.replaceArgs = append(plan.replaceArgs, fmt.Sprintf("-replace=%s", addr))
+	}
+	for _, fname := range f.workspaces.VarFiles(ws) {
+		plan.varFileArgs = append(plan.varFileArgs, fmt.Sprintf("-var-file=%s", fname))
+		plan.varFiles = append(plan.varFiles, fname)
 	}
+	plan.varFiles = append(plan.varFiles, opts.Variables.Files...)
 	return plan, nil
 }
 
@@ -101,17 +151,28 @@ func (r *plan) args() []string {
 	return append([]string{"-input"}, r.targetArgs...)
 }
 
+const PlanTask task.Identifier = "plan"
+
 func (r *plan) planTaskSpec() task.Spec {
 	// TODO: assert planFile is true first
 	spec := task.Spec{
+		Identifier:  PlanTask,
 		ModuleID:    &r.ModuleID,
 		WorkspaceID: &r.WorkspaceID,
 		Path:        r.ModulePath,
 		Env:         r.envs,
 		Execution: task.Execution{
 			TerraformCommand: []string{"plan"},
-			Args:             append(r.args(), "-out", r.planPath()),
+			Args:             append(append(r.args(), r.replaceArgs...), "-json", "-out", r.planPath()),
+		},
+		// Show the plan file once created in order to render the full diff
+		// of the changes in a human-readable form.
+		AdditionalExecution: &task.Execution{
+			TerraformCommand: []string{"show"},
+			Args:             []string{r.planPath()},
 		},
+		RenderStdout: newPlanRenderer,
+		WrapError:    state.WrapLockError,
 		// TODO: explain why plan is blocking (?)
 		Blocking:    true,
 		Description: "plan",
@@ -123,24 +184,69 @@ func (r *plan) planTaskSpec() task.Spec {
 			if err != nil {
 				return nil, err
 			}
-			changes, report, err := parsePlanReport(string(out))
+			changes, report, err := parsePlanReport(out, r.RefreshOnly)
 			if err != nil {
 				return nil, err
 			}
 			r.HasChanges = changes
+			r.report = report
 			return report, nil
 		},
 	}
-	if r.varsFileArg != nil {
-		spec.Execution.Args = append(spec.Execution.Args, *r.varsFileArg)
-	}
+	spec.Execution.Args = append(spec.Execution.Args, r.varFileArgs...)
+	// Overrides come after the workspace's var files so that they take
+	// precedence.
+	spec.Execution.Args = append(spec.Execution.Args, r.Variables.args()...)
+	spec.Variables = r.Variables.args()
 	if r.Destroy {
 		spec.Execution.Args = append(spec.Execution.Args, "-destroy")
 		spec.Description += " (destroy)"
 	}
+	if r.RefreshOnly {
+		spec.Execution.Args = append(spec.Execution.Args, "-refresh-only")
+		spec.Description += " (refresh-only)"
+	}
+	if len(r.ReplaceAddrs) > 0 {
+		spec.Description += " (replace)"
+	}
 	return spec
 }
 
+const ShowTask task.Identifier = "show"
+
+// showTaskSpec specifies a task to show the plan file in JSON, i.e.
+// `terraform show -json <plan>`, from which the resource changes are parsed.
+// The changes are passed to afterShow, along with the JSON itself.
+func (r *plan) showTaskSpec(afterShow func(changes []*ResourceChange, shown []byte)) task.Spec {
+	return task.Spec{
+		Identifier:  ShowTask,
+		ModuleID:    &r.ModuleID,
+		WorkspaceID: &r.WorkspaceID,
+		Path:        r.ModulePath,
+		Env:         r.envs,
+		Execution: task.Execution{
+			TerraformCommand: []string{"show"},
+			Args:             []string{"-json", r.planPath()},
+		},
+		JSON: true,
+		// The user is likely waiting to view the plan.
+		Priority:    task.HighPriority,
+		Description: "show plan",
+		BeforeExited: func(t *task.Task) (task.Summary, error) {
+			shown, err := io.ReadAll(t.NewReader(false))
+			if err != nil {
+				return nil, err
+			}
+			changes, err := parsePlanFile(bytes.NewReader(shown))
+			if err != nil {
+				return nil, err
+			}
+			afterShow(changes, shown)
+			return nil, nil
+		},
+	}
+}
+
 const ApplyTask task.Identifier = "apply"
 
 func (r *plan) applyTaskSpec() (task.Spec, error) {
@@ -154,11 +260,13 @@ func (r *plan) applyTaskSpec() (task.Spec, error) {
 		Path:        r.ModulePath,
 		Execution: task.Execution{
 			TerraformCommand: []string{"apply"},
-			Args:             r.args(),
+			Args:             append(r.args(), "-json"),
 		},
-		Env:         r.envs,
-		Blocking:    true,
-		Description: "apply",
+		RenderStdout: newApplyRenderer,
+		WrapError:    state.WrapLockError,
+		Env:          r.envs,
+		Blocking:     true,
+		Description:  "apply",
 		BeforeExited: func(t *task.Task) (task.Summary, error) {
 			out, err := io.ReadAll(t.NewReader(false))
 			if err != nil {
@@ -168,10 +276,15 @@ func (r *plan) applyTaskSpec() (task.Spec, error) {
 				// Plan file can now be safely removed
 				_ = os.RemoveAll(r.ArtefactsPath)
 			}
-			report, err := parseApplyReport(string(out))
+			report, err := parseApplyReport(out, r.RefreshOnly)
 			if err != nil {
 				return nil, err
 			}
+			if r.RefreshOnly && r.planFile {
+				// Applying a saved refresh-only plan doesn't report drift
+				// again, so report the drift that was planned instead.
+				return r.report, nil
+			}
 			return report, nil
 		},
 	}
@@ -186,16 +299,27 @@ func (r *plan) applyTaskSpec() (task.Spec, error) {
 	if r.planFile {
 		spec.Execution.Args = append(spec.Execution.Args, r.planPath())
 	} else {
-		if r.varsFileArg != nil {
-			spec.Execution.Args = append(spec.Execution.Args, *r.varsFileArg)
-		}
+		spec.Execution.Args = append(spec.Execution.Args, r.varFileArgs...)
+		// Replacements and variable overrides are planned as part of the
+		// apply; a saved plan already includes them.
+		spec.Execution.Args = append(spec.Execution.Args, r.Variables.args()...)
+		spec.Execution.Args = append(spec.Execution.Args, r.replaceArgs...)
 		spec.Execution.Args = append(spec.Execution.Args, "-auto-approve")
 	}
+	// Show the overrides with which the plan was created, whether or not
+	// they are passed to the apply.
+	spec.Variables = r.Variables.args()
 	if r.Destroy {
 		if !r.planFile {
 			spec.Execution.Args = append(spec.Execution.Args, "-destroy")
 		}
 		spec.Description += " (destroy)"
 	}
+	if r.RefreshOnly {
+		if !r.planFile {
+			spec.Execution.Args = append(spec.Execution.Args, "-refresh-only")
+		}
+		spec.Description += " (refresh-only)"
+	}
 	return spec, nil
 }
@@ -1,61 +1,145 @@
 package plan
 
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/leg100/pug/internal/attribute"
+	"github.com/leg100/pug/internal/resource"
+)
+
 const (
-	CreateAction ChangeAction = "create"
-	UpdateAction ChangeAction = "update"
-	DeleteAction ChangeAction = "delete"
+	CreateAction  ChangeAction = "create"
+	UpdateAction  ChangeAction = "update"
+	DeleteAction  ChangeAction = "delete"
+	ReplaceAction ChangeAction = "replace"
+	ReadAction    ChangeAction = "read"
+	NoopAction    ChangeAction = "no-op"
 )
 
 type (
-	// planFile represents the schema of a plan file
+	// planFile represents the schema of a plan file, as output by `terraform
+	// show -json <plan>`.
 	planFile struct {
-		ResourceChanges []ResourceChange  `json:"resource_changes"`
+		ResourceChanges []*ResourceChange `json:"resource_changes"`
 		OutputChanges   map[string]Change `json:"output_changes"`
 	}
 
 	// ResourceChange represents a proposed change to a resource in a plan file
 	ResourceChange struct {
-		Change Change
+		resource.ID `json:"-"`
+
+		Address string `json:"address"`
+		// Type is the resource type, e.g. aws_instance.
+		Type string `json:"type"`
+		// ActionReason optionally explains why the action was chosen, e.g.
+		// replace_because_tainted.
+		ActionReason string `json:"action_reason"`
+		Change       Change `json:"change"`
 	}
 
 	// Change represents the type of change being made
 	Change struct {
-		Actions []ChangeAction
+		Actions []ChangeAction `json:"actions"`
+		// Before and After are the values of the resource's attributes
+		// before and after the change.
+		Before any `json:"before"`
+		After  any `json:"after"`
+		// AfterUnknown mirrors the structure of After, with true marking
+		// values that are unknown until the change is applied.
+		AfterUnknown any `json:"after_unknown"`
+		// BeforeSensitive and AfterSensitive mirror the structure of Before
+		// and After, with true marking sensitive values.
+		BeforeSensitive any `json:"before_sensitive"`
+		AfterSensitive  any `json:"after_sensitive"`
 	}
 
 	ChangeAction string
 )
 
-//lint:ignore U1000 intend to use shortly
-func (pf *planFile) resourceChanges() (resource Report) {
+// parsePlanFile parses the output of `terraform show -json <plan>`, returning
+// the resource changes that are to be made.
+func parsePlanFile(r io.Reader) ([]*ResourceChange, error) {
+	var pf planFile
+	if err := json.NewDecoder(r).Decode(&pf); err != nil {
+		return nil, fmt.Errorf("decoding plan file: %w", err)
+	}
+	changes := make([]*ResourceChange, 0, len(pf.ResourceChanges))
 	for _, rc := range pf.ResourceChanges {
-		for _, action := range rc.Change.Actions {
-			switch action {
-			case CreateAction:
-				resource.Additions++
-			case UpdateAction:
-				resource.Changes++
-			case DeleteAction:
-				resource.Destructions++
-			}
+		switch rc.Action() {
+		case NoopAction, ReadAction:
+			continue
 		}
+		rc.ID = resource.NewID(resource.ResourceChange)
+		changes = append(changes, rc)
+	}
+	return changes, nil
+}
+
+func (rc *ResourceChange) String() string {
+	return rc.Address
+}
+
+// Action returns the action to be taken on the resource.
+func (rc *ResourceChange) Action() ChangeAction {
+	actions := rc.Change.Actions
+	switch {
+	case slices.Contains(actions, DeleteAction) && slices.Contains(actions, CreateAction):
+		return ReplaceAction
+	case len(actions) == 1:
+		return actions[0]
+	default:
+		return NoopAction
 	}
-	return
 }
 
-//lint:ignore U1000 intend to use shortly
-func (pf *planFile) outputChanges() (output Report) {
-	for _, rc := range pf.OutputChanges {
-		for _, action := range rc.Actions {
-			switch action {
-			case CreateAction:
-				output.Additions++
-			case UpdateAction:
-				output.Changes++
-			case DeleteAction:
-				output.Destructions++
-			}
+// tags returns the resource's tags, taken from its attributes after the
+// change, or before the change if it is being deleted.
+func (rc *ResourceChange) tags() map[string]string {
+	attrs, ok := rc.Change.After.(map[string]any)
+	if !ok {
+		attrs, _ = rc.Change.Before.(map[string]any)
+	}
+	tags := make(map[string]string)
+	for _, key := range []string{"tags_all", "tags"} {
+		m, _ := attrs[key].(map[string]any)
+		for k, v := range m {
+			tags[k] = fmt.Sprint(v)
 		}
 	}
-	return
+	return tags
+}
+
+// Diff returns the attributes that differ before and after the change,
+// sorted by path. Unknown and sensitive values are rendered as
+// attribute.UnknownValue and attribute.SensitiveValue respectively.
+func (rc *ResourceChange) Diff() []attribute.Diff {
+	before := attribute.Values{
+		Leaves:    attribute.Flatten(rc.Change.Before),
+		Sensitive: attribute.FlattenMarks(rc.Change.BeforeSensitive),
+	}
+	after := attribute.Values{
+		Leaves:    attribute.Flatten(rc.Change.After),
+		Sensitive: attribute.FlattenMarks(rc.Change.AfterSensitive),
+	}
+	return attribute.Compare(before, after, attribute.FlattenMarks(rc.Change.AfterUnknown))
+}
+
+// actionOrder is the order in which changes are grouped by action.
+var actionOrder = map[ChangeAction]int{
+	CreateAction:  0,
+	UpdateAction:  1,
+	ReplaceAction: 2,
+	DeleteAction:  3,
+}
+
+// SortChanges sorts resource changes by action, and then by address.
+func SortChanges(a, b *ResourceChange) int {
+	if n := cmp.Compare(actionOrder[a.Action()], actionOrder[b.Action()]); n != 0 {
+		return n
+	}
+	return cmp.Compare(a.Address, b.Address)
 }
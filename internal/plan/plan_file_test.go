@@ -0,0 +1,62 @@
+package plan
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/leg100/pug/internal/attribute"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePlanFile(t *testing.T) {
+	f, err := os.Open("testdata/show.json")
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+
+	changes, err := parsePlanFile(f)
+	require.NoError(t, err)
+
+	// No-op and read changes are skipped.
+	slices.SortFunc(changes, SortChanges)
+	var got []string
+	for _, rc := range changes {
+		got = append(got, string(rc.Action())+" "+rc.Address)
+	}
+	assert.Equal(t, []string{
+		"create random_pet.new",
+		"update aws_instance.web",
+		"replace random_pet.tainted",
+		"delete random_pet.old",
+	}, got)
+
+	t.Run("create", func(t *testing.T) {
+		assert.Equal(t, []attribute.Diff{
+			{Path: "id", After: attribute.UnknownValue},
+			{Path: "length", After: "2"},
+			{Path: "separator", After: `"-"`},
+		}, changes[0].Diff())
+	})
+
+	t.Run("update", func(t *testing.T) {
+		assert.Equal(t, []attribute.Diff{
+			{Path: "ami", Before: `"ami-1"`, After: `"ami-2"`},
+			{Path: "tags.Env", Before: `"dev"`},
+			{Path: "user_data", Before: attribute.SensitiveValue, After: attribute.SensitiveValue},
+		}, changes[1].Diff())
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		assert.Equal(t, "replace_because_tainted", changes[2].ActionReason)
+		assert.Equal(t, []attribute.Diff{
+			{Path: "id", Before: `"novel-monkey"`, After: attribute.UnknownValue},
+		}, changes[2].Diff())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.Equal(t, []attribute.Diff{
+			{Path: "id", Before: `"happy-dog"`},
+		}, changes[3].Diff())
+	})
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/module"
 	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/task"
 	"github.com/leg100/pug/internal/testutils"
 	"github.com/leg100/pug/internal/workspace"
 	"github.com/stretchr/testify/assert"
@@ -27,9 +29,7 @@ func TestPlan_VarsFile(t *testing.T) {
 	run, err := f.newPlan(ws.ID, CreateOptions{})
 	require.NoError(t, err)
 
-	if assert.NotNil(t, run.varsFileArg) {
-		assert.Equal(t, *run.varsFileArg, "-var-file=dev.tfvars")
-	}
+	assert.Equal(t, []string{"-var-file=dev.tfvars"}, run.varFileArgs)
 }
 
 func TestPlan_MakeArtefactsPath(t *testing.T) {
@@ -41,6 +41,70 @@ func TestPlan_MakeArtefactsPath(t *testing.T) {
 	assert.DirExists(t, run.ArtefactsPath)
 }
 
+func TestPlan_Replace(t *testing.T) {
+	f, _, ws := setupTest(t)
+
+	run, err := f.newPlan(ws.ID, CreateOptions{
+		ReplaceAddrs: []state.ResourceAddress{"random_pet.a", "random_pet.b"},
+		planFile:     true,
+	})
+	require.NoError(t, err)
+
+	spec := run.planTaskSpec()
+	assert.Subset(t, spec.Execution.Args, []string{"-replace=random_pet.a", "-replace=random_pet.b"})
+	assert.Equal(t, "plan (replace)", spec.Description)
+
+	// Applying the saved plan must not re-specify the replacements.
+	run.HasChanges = true
+	applySpec, err := run.applyTaskSpec()
+	require.NoError(t, err)
+	assert.NotContains(t, applySpec.Execution.Args, "-replace=random_pet.a")
+}
+
+func TestPlan_RefreshOnly(t *testing.T) {
+	f, _, ws := setupTest(t)
+
+	run, err := f.newPlan(ws.ID, CreateOptions{RefreshOnly: true})
+	require.NoError(t, err)
+
+	spec := run.planTaskSpec()
+	assert.Contains(t, spec.Execution.Args, "-refresh-only")
+	assert.Equal(t, "plan (refresh-only)", spec.Description)
+
+	// Auto-applying must also only refresh.
+	applySpec, err := run.applyTaskSpec()
+	require.NoError(t, err)
+	assert.Contains(t, applySpec.Execution.Args, "-refresh-only")
+	assert.Equal(t, "apply (refresh-only)", applySpec.Description)
+
+	_, err = f.newPlan(ws.ID, CreateOptions{RefreshOnly: true, Destroy: true})
+	assert.Error(t, err)
+}
+
+func TestPlan_Variables(t *testing.T) {
+	f, _, ws := setupTest(t)
+
+	run, err := f.newPlan(ws.ID, CreateOptions{
+		Variables: Variables{
+			Vars:  []string{"image_tag=v1.2.3"},
+			Files: []string{"extra.tfvars"},
+		},
+	})
+	require.NoError(t, err)
+
+	want := []string{"-var-file=extra.tfvars", "-var=image_tag=v1.2.3"}
+
+	spec := run.planTaskSpec()
+	assert.Subset(t, spec.Execution.Args, want)
+	assert.Equal(t, want, spec.Variables)
+
+	// Auto-applying must pass the overrides too.
+	applySpec, err := run.applyTaskSpec()
+	require.NoError(t, err)
+	assert.Subset(t, applySpec.Execution.Args, want)
+	assert.Equal(t, want, applySpec.Variables)
+}
+
 func setupTest(t *testing.T) (*factory, *module.Module, *workspace.Workspace) {
 	workdir := internal.NewTestWorkdir(t)
 	testutils.ChTempDir(t, workdir.String())
@@ -50,7 +114,7 @@ func setupTest(t *testing.T) (*factory, *module.Module, *workspace.Workspace) {
 	require.NoError(t, err)
 	factory := factory{
 		modules:    &fakeModuleGetter{mod: mod},
-		workspaces: &fakeWorkspaceGetter{ws: ws},
+		workspaces: &fakeWorkspaceGetter{ws: ws, workdir: workdir},
 		dataDir:    t.TempDir(),
 		workdir:    workdir,
 	}
@@ -65,10 +129,57 @@ func (f *fakeModuleGetter) Get(resource.ID) (*module.Module, error) {
 	return f.mod, nil
 }
 
+func (f *fakeModuleGetter) List() []*module.Module {
+	return []*module.Module{f.mod}
+}
+
 type fakeWorkspaceGetter struct {
-	ws *workspace.Workspace
+	ws      *workspace.Workspace
+	workdir internal.Workdir
 }
 
 func (f *fakeWorkspaceGetter) Get(resource.ID) (*workspace.Workspace, error) {
 	return f.ws, nil
 }
+
+func (f *fakeWorkspaceGetter) VarFiles(ws *workspace.Workspace) []string {
+	return ws.VarFiles(f.workdir, workspace.DefaultVarFileTemplates)
+}
+
+type fakeTaskService struct {
+	tasks map[resource.ID]*task.Task
+}
+
+func newFakeTaskService(tasks ...*task.Task) *fakeTaskService {
+	f := &fakeTaskService{tasks: make(map[resource.ID]*task.Task)}
+	for _, t := range tasks {
+		f.tasks[t.ID] = t
+	}
+	return f
+}
+
+func (f *fakeTaskService) Create(task.Spec) (*task.Task, error) {
+	t := &task.Task{ID: resource.NewID(resource.Task), State: task.Pending}
+	f.tasks[t.ID] = t
+	return t, nil
+}
+
+func (f *fakeTaskService) Get(id resource.ID) (*task.Task, error) {
+	t, ok := f.tasks[id]
+	if !ok {
+		return nil, resource.ErrNotFound
+	}
+	return t, nil
+}
+
+type fakeStateService struct {
+	state *state.State
+}
+
+func (f *fakeStateService) Get(resource.ID) (*state.State, error) {
+	return f.state, nil
+}
+
+func (f *fakeStateService) CreateReloadTask(resource.ID) (*task.Task, error) {
+	return nil, nil
+}
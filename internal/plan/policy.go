@@ -0,0 +1,159 @@
+package plan
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/leg100/pug/internal"
+)
+
+// PolicyRule limits the number of changes of a particular kind that a plan
+// may make before it can be applied.
+type PolicyRule struct {
+	// Action is the action the rule applies to. A rule for deletions also
+	// applies to replacements, because a replacement deletes the resource.
+	Action ChangeAction
+	// Type is a glob matching the types of resources the rule applies to.
+	Type string
+	// Tag, if non-empty, restricts the rule to resources with the tag.
+	Tag string
+	// TagValue, if non-empty, restricts the rule to resources with the tag
+	// set to the value.
+	TagValue string
+	// Workspace, if non-empty, is a glob restricting the rule to workspaces
+	// with a matching name.
+	Workspace string
+	// Max is the maximum number of matching changes permitted.
+	Max int
+}
+
+// ParsePolicyRule parses a policy rule of the form
+// <action>:<selector>[@<workspace>]=<max>, where the selector is either a
+// glob matching resource types, or #<tag>[:<value>] matching tagged
+// resources. For example:
+//
+//	delete:aws_db_instance=0
+//	delete:*@prod*=5
+//	replace:#critical=0
+func ParsePolicyRule(s string) (PolicyRule, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("invalid policy rule: %s: %s", s, reason)
+	}
+	action, rest, ok := strings.Cut(s, ":")
+	if !ok {
+		return PolicyRule{}, invalid("missing <action>:")
+	}
+	rule := PolicyRule{Action: ChangeAction(action)}
+	switch rule.Action {
+	case CreateAction, UpdateAction, ReplaceAction, DeleteAction:
+	default:
+		return PolicyRule{}, invalid("action must be one of create, update, replace, or delete")
+	}
+	selector, maxStr, ok := internal.CutLast(rest, "=")
+	if !ok {
+		return PolicyRule{}, invalid("missing =<max>")
+	}
+	var err error
+	rule.Max, err = strconv.Atoi(maxStr)
+	if err != nil || rule.Max < 0 {
+		return PolicyRule{}, invalid("max must be a non-negative integer")
+	}
+	selector, rule.Workspace, _ = strings.Cut(selector, "@")
+	if tag, ok := strings.CutPrefix(selector, "#"); ok {
+		rule.Tag, rule.TagValue, _ = strings.Cut(tag, ":")
+		if rule.Tag == "" {
+			return PolicyRule{}, invalid("missing tag")
+		}
+	} else {
+		rule.Type = selector
+	}
+	if rule.Type == "" && rule.Tag == "" {
+		return PolicyRule{}, invalid("missing selector")
+	}
+	return rule, nil
+}
+
+func (r PolicyRule) String() string {
+	selector := r.Type
+	if r.Tag != "" {
+		selector = "#" + r.Tag
+		if r.TagValue != "" {
+			selector += ":" + r.TagValue
+		}
+	}
+	if r.Workspace != "" {
+		selector += "@" + r.Workspace
+	}
+	return fmt.Sprintf("%s:%s=%d", r.Action, selector, r.Max)
+}
+
+// matches determines whether the rule applies to the change.
+func (r PolicyRule) matches(rc *ResourceChange) bool {
+	switch action := rc.Action(); r.Action {
+	case DeleteAction:
+		if action != DeleteAction && action != ReplaceAction {
+			return false
+		}
+	default:
+		if action != r.Action {
+			return false
+		}
+	}
+	if r.Type != "" && !internal.MatchGlob(r.Type, rc.Type) {
+		return false
+	}
+	if r.Tag != "" {
+		value, ok := rc.tags()[r.Tag]
+		if !ok || (r.TagValue != "" && value != r.TagValue) {
+			return false
+		}
+	}
+	return true
+}
+
+// Violation is a breach of a policy rule by a plan.
+type Violation struct {
+	Rule PolicyRule
+	// Addresses of the resources whose changes breach the rule.
+	Addresses []string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %d changes exceed the maximum of %d: %s",
+		v.Rule, len(v.Addresses), v.Rule.Max, strings.Join(v.Addresses, ", "))
+}
+
+// PolicyViolationError is returned when applying a plan that violates policy.
+type PolicyViolationError struct {
+	Violations []Violation
+}
+
+func (e *PolicyViolationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+	return "plan violates policy: " + strings.Join(msgs, "; ")
+}
+
+// evaluatePolicy evaluates the policy rules against the changes of a plan
+// for the named workspace, returning any violations.
+func evaluatePolicy(rules []PolicyRule, workspace string, changes []*ResourceChange) []Violation {
+	var violations []Violation
+	for _, rule := range rules {
+		if rule.Workspace != "" && !internal.MatchGlob(rule.Workspace, workspace) {
+			continue
+		}
+		var addrs []string
+		for _, rc := range changes {
+			if rule.matches(rc) {
+				addrs = append(addrs, rc.Address)
+			}
+		}
+		if len(addrs) > rule.Max {
+			violations = append(violations, Violation{Rule: rule, Addresses: addrs})
+		}
+	}
+	return violations
+}
@@ -0,0 +1,224 @@
+package plan
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicyRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    PolicyRule
+		wantErr bool
+	}{
+		{
+			name: "type",
+			s:    "delete:aws_db_instance=0",
+			want: PolicyRule{Action: DeleteAction, Type: "aws_db_instance"},
+		},
+		{
+			name: "type glob with workspace",
+			s:    "delete:*@prod*=5",
+			want: PolicyRule{Action: DeleteAction, Type: "*", Workspace: "prod*", Max: 5},
+		},
+		{
+			name: "tag",
+			s:    "replace:#critical=0",
+			want: PolicyRule{Action: ReplaceAction, Tag: "critical"},
+		},
+		{
+			name: "tag with value",
+			s:    "update:#env:prod=2",
+			want: PolicyRule{Action: UpdateAction, Tag: "env", TagValue: "prod", Max: 2},
+		},
+		{
+			name:    "missing action",
+			s:       "aws_db_instance=0",
+			wantErr: true,
+		},
+		{
+			name:    "invalid action",
+			s:       "read:aws_db_instance=0",
+			wantErr: true,
+		},
+		{
+			name:    "missing max",
+			s:       "delete:aws_db_instance",
+			wantErr: true,
+		},
+		{
+			name:    "negative max",
+			s:       "delete:aws_db_instance=-1",
+			wantErr: true,
+		},
+		{
+			name:    "missing selector",
+			s:       "delete:=0",
+			wantErr: true,
+		},
+		{
+			name:    "missing tag",
+			s:       "delete:#=0",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePolicyRule(tt.s)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			// Check rule round-trips.
+			assert.Equal(t, tt.s, got.String())
+		})
+	}
+}
+
+func TestEvaluatePolicy(t *testing.T) {
+	f, err := os.Open("testdata/show.json")
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+
+	changes, err := parsePlanFile(f)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		rule      string
+		workspace string
+		want      []string
+	}{
+		{
+			name: "deletes include replacements",
+			rule: "delete:random_pet=0",
+			want: []string{"random_pet.tainted", "random_pet.old"},
+		},
+		{
+			name: "within maximum",
+			rule: "delete:random_pet=2",
+		},
+		{
+			name: "replacements",
+			rule: "replace:random_*=0",
+			want: []string{"random_pet.tainted"},
+		},
+		{
+			name: "tag",
+			rule: "update:#Name=0",
+			want: []string{"aws_instance.web"},
+		},
+		{
+			name: "tag value",
+			rule: "update:#Name:db=0",
+		},
+		{
+			name:      "matching workspace",
+			rule:      "create:*@prod*=0",
+			workspace: "prod-eu",
+			want:      []string{"random_pet.new"},
+		},
+		{
+			name:      "non-matching workspace",
+			rule:      "create:*@prod*=0",
+			workspace: "dev",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParsePolicyRule(tt.rule)
+			require.NoError(t, err)
+
+			got := evaluatePolicy([]PolicyRule{rule}, tt.workspace, changes)
+			if tt.want == nil {
+				assert.Empty(t, got)
+				return
+			}
+			if assert.Len(t, got, 1) {
+				assert.Equal(t, tt.want, got[0].Addresses)
+			}
+		})
+	}
+}
+
+func TestService_Apply_Policy(t *testing.T) {
+	f, _, ws := setupTest(t)
+
+	rule, err := ParsePolicyRule("delete:*=0")
+	require.NoError(t, err)
+	svc := &Service{factory: f, policy: []PolicyRule{rule}}
+
+	// Auto-applying changes is blocked because they cannot be checked
+	// against policy.
+	_, err = svc.Apply(ws.ID, CreateOptions{})
+	assert.ErrorIs(t, err, ErrAutoApplyPolicy)
+
+	_, err = svc.Apply(ws.ID, CreateOptions{Destroy: true})
+	assert.ErrorIs(t, err, ErrAutoApplyPolicy)
+
+	// A refresh-only apply doesn't change resources.
+	_, err = svc.Apply(ws.ID, CreateOptions{RefreshOnly: true})
+	assert.NoError(t, err)
+}
+
+func TestService_ShowError(t *testing.T) {
+	f, _, ws := setupTest(t)
+
+	rule, err := ParsePolicyRule("delete:*=0")
+	require.NoError(t, err)
+	planTask := &task.Task{ID: resource.NewID(resource.Task), State: task.Exited}
+	tasks := newFakeTaskService(planTask)
+	svc := &Service{
+		table:      resource.NewTable(&fakePublisher[*plan]{}),
+		factory:    f,
+		modules:    f.modules,
+		workspaces: f.workspaces,
+		tasks:      tasks,
+		states:     &fakeStateService{},
+		logger:     logging.Discard,
+		policy:     []PolicyRule{rule},
+	}
+	p, err := svc.newPlan(ws.ID, CreateOptions{planFile: true})
+	require.NoError(t, err)
+	p.taskID = &planTask.ID
+	p.HasChanges = true
+	svc.table.Add(p.ID, p)
+
+	require.NoError(t, svc.showPlan(p))
+	showTask, err := tasks.Get(*p.showTaskID)
+	require.NoError(t, err)
+
+	// Plan cannot be shown again until it has failed to be shown.
+	assert.NoError(t, svc.ShowError(planTask.ID))
+	assert.Error(t, svc.RetryShow(planTask.ID))
+
+	showTask.State = task.Errored
+	showTask.Err = errors.New("bad plan file")
+
+	assert.EqualError(t, svc.ShowError(planTask.ID), "bad plan file")
+	_, err = svc.ApplyPlan(planTask.ID, ApplyPlanOptions{})
+	assert.ErrorContains(t, err, "bad plan file")
+	_, err = svc.ResourceChanges(planTask.ID)
+	assert.ErrorContains(t, err, "bad plan file")
+
+	// Retrying creates a new show task.
+	require.NoError(t, svc.RetryShow(planTask.ID))
+	assert.NotEqual(t, showTask.ID, *p.showTaskID)
+	assert.NoError(t, svc.ShowError(planTask.ID))
+
+	// Once shown, the plan can be applied.
+	svc.setChanges(p.ID, []*ResourceChange{})
+	assert.NoError(t, svc.ShowError(planTask.ID))
+	_, err = svc.ApplyPlan(planTask.ID, ApplyPlanOptions{})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,126 @@
+package plan
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ResourceStatus is the status of a resource being changed by an apply.
+type ResourceStatus string
+
+const (
+	ResourcePending  ResourceStatus = "pending"
+	ResourceApplying ResourceStatus = "applying"
+	ResourceComplete ResourceStatus = "complete"
+	ResourceErrored  ResourceStatus = "errored"
+)
+
+// ResourceProgress is the progress of a resource being changed by an apply.
+type ResourceProgress struct {
+	Addr   string
+	Action string
+	Status ResourceStatus
+	// Started is when the change to the resource started.
+	Started time.Time
+	// Elapsed is the time taken to change the resource, as reported by
+	// terraform. Whilst the resource is still being changed it is only
+	// updated periodically, so it is better to compute the elapsed time from
+	// Started.
+	Elapsed time.Duration
+}
+
+// ApplyProgress tracks the progress of the resources being changed by an
+// apply, built from the apply's stream of JSON messages.
+type ApplyProgress struct {
+	mu sync.Mutex
+	// resources in the order in which they first appeared in the stream.
+	resources []*ResourceProgress
+}
+
+// Resources returns a copy of the progress of each resource.
+func (p *ApplyProgress) Resources() []ResourceProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	resources := make([]ResourceProgress, len(p.resources))
+	for i, r := range p.resources {
+		resources[i] = *r
+	}
+	return resources
+}
+
+// String summarises the progress, e.g. "12/40 resources".
+func (p *ApplyProgress) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var finished int
+	for _, r := range p.resources {
+		if r.Status == ResourceComplete || r.Status == ResourceErrored {
+			finished++
+		}
+	}
+	return fmt.Sprintf("%d/%d resources", finished, len(p.resources))
+}
+
+// handle updates progress according to the message, returning true if
+// progress was updated.
+func (p *ApplyProgress) handle(msg message) bool {
+	var (
+		addr, action string
+		status       ResourceStatus
+		elapsed      int
+	)
+	switch msg.Type {
+	case "planned_change":
+		if msg.Change == nil {
+			return false
+		}
+		addr, action, status = msg.Change.Resource.Addr, msg.Change.Action, ResourcePending
+	case "apply_start", "apply_progress", "apply_complete", "apply_errored":
+		if msg.Hook == nil {
+			return false
+		}
+		addr, action, elapsed = msg.Hook.Resource.Addr, msg.Hook.Action, msg.Hook.ElapsedSeconds
+		switch msg.Type {
+		case "apply_complete":
+			status = ResourceComplete
+		case "apply_errored":
+			status = ResourceErrored
+		default:
+			status = ResourceApplying
+		}
+	default:
+		return false
+	}
+	if action == "read" {
+		// Ignore data sources
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := slices.IndexFunc(p.resources, func(r *ResourceProgress) bool {
+		return r.Addr == addr
+	})
+	if i < 0 {
+		p.resources = append(p.resources, &ResourceProgress{Addr: addr})
+		i = len(p.resources) - 1
+	} else if status == ResourcePending {
+		// Resource is already known
+		return false
+	}
+	r := p.resources[i]
+	if r.Status != ResourceApplying && status == ResourceApplying {
+		r.Started = time.Now()
+	}
+	r.Status = status
+	r.Elapsed = time.Duration(elapsed) * time.Second
+	if action != "" {
+		r.Action = action
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package plan
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyProgress(t *testing.T) {
+	out, err := os.ReadFile("testdata/apply.jsonl")
+	require.NoError(t, err)
+
+	var progress ApplyProgress
+	for _, msg := range decodeMessages(out) {
+		progress.handle(msg)
+	}
+
+	assert.Equal(t, "1/1 resources", progress.String())
+	if assert.Len(t, progress.Resources(), 1) {
+		got := progress.Resources()[0]
+		assert.Equal(t, "random_pet.pet", got.Addr)
+		assert.Equal(t, "create", got.Action)
+		assert.Equal(t, ResourceComplete, got.Status)
+	}
+}
+
+func TestApplyProgress_Running(t *testing.T) {
+	out := strings.Join([]string{
+		`{"@message":"aws_db_instance.main: Plan to create","change":{"resource":{"addr":"aws_db_instance.main"},"action":"create"},"type":"planned_change"}`,
+		`{"@message":"aws_s3_bucket.logs: Plan to update","change":{"resource":{"addr":"aws_s3_bucket.logs"},"action":"update"},"type":"planned_change"}`,
+		`{"@message":"aws_iam_role.app: Plan to delete","change":{"resource":{"addr":"aws_iam_role.app"},"action":"delete"},"type":"planned_change"}`,
+		`{"@message":"data.aws_region.current: Reading...","hook":{"resource":{"addr":"data.aws_region.current"},"action":"read"},"type":"apply_start"}`,
+		`{"@message":"aws_db_instance.main: Creating...","hook":{"resource":{"addr":"aws_db_instance.main"},"action":"create"},"type":"apply_start"}`,
+		`{"@message":"aws_db_instance.main: Still creating... [10s elapsed]","hook":{"resource":{"addr":"aws_db_instance.main"},"action":"create","elapsed_seconds":10},"type":"apply_progress"}`,
+		`{"@message":"aws_s3_bucket.logs: Modifying...","hook":{"resource":{"addr":"aws_s3_bucket.logs"},"action":"update"},"type":"apply_start"}`,
+		`{"@message":"aws_s3_bucket.logs: Modifying failed after 2s","hook":{"resource":{"addr":"aws_s3_bucket.logs"},"action":"update","elapsed_seconds":2},"type":"apply_errored"}`,
+	}, "\n")
+
+	var progress ApplyProgress
+	for _, msg := range decodeMessages([]byte(out)) {
+		progress.handle(msg)
+	}
+
+	assert.Equal(t, "1/3 resources", progress.String())
+
+	got := progress.Resources()
+	require.Len(t, got, 3)
+
+	assert.Equal(t, "aws_db_instance.main", got[0].Addr)
+	assert.Equal(t, ResourceApplying, got[0].Status)
+	assert.Equal(t, 10*time.Second, got[0].Elapsed)
+	assert.False(t, got[0].Started.IsZero())
+
+	assert.Equal(t, "aws_s3_bucket.logs", got[1].Addr)
+	assert.Equal(t, ResourceErrored, got[1].Status)
+	assert.Equal(t, 2*time.Second, got[1].Elapsed)
+
+	assert.Equal(t, "aws_iam_role.app", got[2].Addr)
+	assert.Equal(t, "delete", got[2].Action)
+	assert.Equal(t, ResourcePending, got[2].Status)
+}
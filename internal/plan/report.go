@@ -10,15 +10,35 @@ type Report struct {
 	Additions    int `json:"additions"`
 	Changes      int `json:"changes"`
 	Destructions int `json:"destructions"`
+	// RefreshOnly is true if the report is of a refresh-only plan or apply,
+	// in which case the counts are of resources that have drifted, i.e.
+	// changed outside of terraform, rather than of changes to resources.
+	RefreshOnly bool `json:"refresh_only,omitempty"`
 }
 
 func (r Report) HasChanges() bool {
-	return r != Report{}
+	return r.Additions > 0 || r.Changes > 0 || r.Destructions > 0
 }
 
 func (r Report) String() string {
 	// \u2212 is a proper minus sign; an ascii hyphen is too narrow (in the
 	// default github font at least) and looks incongruous alongside
 	// the wider '+' and '~' characters.
+	if r.RefreshOnly {
+		return fmt.Sprintf("drift: ~%d/\u2212%d", r.Changes, r.Destructions)
+	}
 	return fmt.Sprintf("+%d/~%d/\u2212%d", r.Additions, r.Changes, r.Destructions)
 }
+
+// addDrift adds a resource that has drifted to the report, according to the
+// action terraform reports for the drift.
+func (r *Report) addDrift(action string) {
+	switch action {
+	case "create":
+		r.Additions++
+	case "update":
+		r.Changes++
+	case "delete":
+		r.Destructions++
+	}
+}
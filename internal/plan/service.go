@@ -1,7 +1,9 @@
 package plan
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 
 	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/logging"
@@ -17,13 +19,20 @@ type Service struct {
 	table  *resource.Table[*plan]
 	logger logging.Interface
 
-	tasks      *task.Service
+	tasks      taskService
 	modules    moduleGetter
 	workspaces workspaceGetter
-	states     *state.Service
+	states     stateService
+	policy     []PolicyRule
+	archive    *archive
+	// recentVariables are the variable overrides most recently used to plan
+	// each workspace.
+	recentVariables recentVariables
 
 	*factory
 	*pubsub.Broker[*plan]
+	// ArchiveBroker publishes events for archived plans.
+	ArchiveBroker *pubsub.Broker[*Archived]
 }
 
 type ServiceOptions struct {
@@ -35,26 +44,53 @@ type ServiceOptions struct {
 	Workdir    internal.Workdir
 	Logger     logging.Interface
 	Terragrunt bool
+	// Policy rules are evaluated against plans before they can be applied.
+	Policy []PolicyRule
+	// Archive configures the retention of plans in the data directory.
+	Archive ArchiveOptions
 }
 
 type moduleGetter interface {
 	Get(moduleID resource.ID) (*module.Module, error)
+	List() []*module.Module
+}
+
+type taskService interface {
+	Create(spec task.Spec) (*task.Task, error)
+	Get(taskID resource.ID) (*task.Task, error)
+}
+
+type stateService interface {
+	Get(workspaceID resource.ID) (*state.State, error)
+	CreateReloadTask(workspaceID resource.ID) (*task.Task, error)
 }
 
 type workspaceGetter interface {
 	Get(workspaceID resource.ID) (*workspace.Workspace, error)
+	VarFiles(ws *workspace.Workspace) []string
 }
 
 func NewService(opts ServiceOptions) *Service {
 	broker := pubsub.NewBroker[*plan](opts.Logger)
+	archiveBroker := pubsub.NewBroker[*Archived](opts.Logger)
+	archive := &archive{
+		ArchiveOptions: opts.Archive,
+		table:          resource.NewTable(archiveBroker),
+	}
+	if opts.Archive.Enabled && opts.DataDir != "" {
+		archive.dir = filepath.Join(opts.DataDir, archiveDirName)
+	}
 	return &Service{
-		table:      resource.NewTable(broker),
-		Broker:     broker,
-		tasks:      opts.Tasks,
-		modules:    opts.Modules,
-		workspaces: opts.Workspaces,
-		states:     opts.States,
-		logger:     opts.Logger,
+		table:         resource.NewTable(broker),
+		Broker:        broker,
+		ArchiveBroker: archiveBroker,
+		archive:       archive,
+		tasks:         opts.Tasks,
+		modules:       opts.Modules,
+		workspaces:    opts.Workspaces,
+		states:        opts.States,
+		logger:        opts.Logger,
+		policy:        opts.Policy,
 		factory: &factory{
 			dataDir:    opts.DataDir,
 			workdir:    opts.Workdir,
@@ -101,13 +137,98 @@ func (s *Service) Plan(workspaceID resource.ID, opts CreateOptions) (task.Spec,
 		return task.Spec{}, err
 	}
 	s.table.Add(plan.ID, plan)
+	if !opts.Variables.IsZero() {
+		s.recentVariables.set(workspaceID, opts.Variables)
+	}
+
+	spec := plan.planTaskSpec()
+	spec.AfterRunning = s.recordInputs(plan.ID)
+	spec.AfterExited = func(*task.Task) {
+		if !plan.HasChanges {
+			return
+		}
+		if err := s.showPlan(plan); err != nil {
+			s.logger.Error("showing plan", "error", err, "plan", plan)
+		}
+	}
+	return spec, nil
+}
 
-	return plan.planTaskSpec(), nil
+// showPlan creates a task to show the plan file in order to populate its
+// resource changes.
+func (s *Service) showPlan(p *plan) error {
+	showTask, err := s.tasks.Create(p.showTaskSpec(s.afterShow(p.ID)))
+	if err != nil {
+		return err
+	}
+	s.table.Update(p.ID, func(p *plan) error {
+		p.showTaskID = &showTask.ID
+		return nil
+	})
+	return nil
 }
 
+// showError returns the reason the plan file could not be shown, or nil if
+// it has not failed to be shown.
+func (s *Service) showError(p *plan) error {
+	if p.showTaskID == nil || p.changes != nil {
+		return nil
+	}
+	showTask, err := s.tasks.Get(*p.showTaskID)
+	if err != nil {
+		return nil
+	}
+	showTask = showTask.LatestAttempt()
+	switch showTask.State {
+	case task.Errored, task.TimedOut, task.Canceled:
+		if showTask.WillRetry() {
+			return nil
+		}
+		if showTask.Err != nil {
+			return showTask.Err
+		}
+		return fmt.Errorf("task %s", showTask.State)
+	default:
+		return nil
+	}
+}
+
+// ShowError retrieves the reason the plan created by the plan task with the
+// given ID could not be shown. Nil is returned if it has not failed to be
+// shown.
+func (s *Service) ShowError(taskID resource.ID) error {
+	plan, err := s.getByTaskID(taskID)
+	if err != nil {
+		return nil
+	}
+	return s.showError(plan)
+}
+
+// RetryShow re-runs the showing of the plan created by the plan task with the
+// given ID, after it has failed to be shown.
+func (s *Service) RetryShow(taskID resource.ID) error {
+	plan, err := s.getByTaskID(taskID)
+	if err != nil {
+		return err
+	}
+	if s.showError(plan) == nil {
+		return errors.New("plan has not failed to be shown")
+	}
+	return s.showPlan(plan)
+}
+
+// ErrAutoApplyPolicy is returned when attempting to auto-apply changes while
+// policy is in force, because the changes cannot be checked against policy
+// before they are applied.
+var ErrAutoApplyPolicy = errors.New("cannot auto-apply while policy is in force: create a plan and apply it instead")
+
 // Apply creates a task spec to auto-apply a plan, i.e. `terraform apply`. To
-// apply an existing plan, see ApplyPlan.
+// apply an existing plan, see ApplyPlan. If policy is in force then only a
+// refresh-only apply is permitted, which doesn't change any resources.
 func (s *Service) Apply(workspaceID resource.ID, opts CreateOptions) (task.Spec, error) {
+	if len(s.policy) > 0 && !opts.RefreshOnly {
+		return task.Spec{}, ErrAutoApplyPolicy
+	}
 	plan, err := s.newPlan(workspaceID, opts)
 	if err != nil {
 		return task.Spec{}, err
@@ -115,14 +236,32 @@ func (s *Service) Apply(workspaceID resource.ID, opts CreateOptions) (task.Spec,
 	return plan.applyTaskSpec()
 }
 
+// ApplyPlanOptions are options for applying an existing plan.
+type ApplyPlanOptions struct {
+	// OverridePolicy applies the plan even if it violates policy.
+	OverridePolicy bool
+	// IgnoreStale applies the plan even if its module has changed since the
+	// plan was created.
+	IgnoreStale bool
+}
+
 // ApplyPlan creates a task spec to apply an existing plan, i.e. `terraform
 // apply existing.plan`. The taskID is the ID of a plan task, which must have
-// finished successfully.
-func (s *Service) ApplyPlan(taskID resource.ID) (task.Spec, error) {
+// finished successfully. If the plan's module or variables files have changed
+// since the plan was created then a *StalePlanError is returned, unless
+// staleness is ignored. The plan's inputs are hashed, so this should not be
+// called from the UI thread. If
+// the plan violates policy then a *PolicyViolationError is returned, unless
+// the policy is overridden. A plan whose state has changed since the plan was
+// created cannot be applied.
+func (s *Service) ApplyPlan(taskID resource.ID, opts ApplyPlanOptions) (task.Spec, error) {
 	planTask, err := s.tasks.Get(taskID)
 	if err != nil {
 		return task.Spec{}, err
 	}
+	if planTask.History != nil {
+		return task.Spec{}, errors.New("cannot apply historical plan")
+	}
 	if planTask.State != task.Exited {
 		return task.Spec{}, fmt.Errorf("plan task is not in the exited state: %s", planTask.State)
 	}
@@ -130,7 +269,32 @@ func (s *Service) ApplyPlan(taskID resource.ID) (task.Spec, error) {
 	if err != nil {
 		return task.Spec{}, err
 	}
-	return plan.applyTaskSpec()
+	switch reason := s.checkStale(plan, s.newModuleHasher()); reason {
+	case "":
+	case StaleState:
+		// Terraform would refuse to apply the plan.
+		return task.Spec{}, fmt.Errorf("plan is stale: %s: create a new plan", reason)
+	default:
+		if !opts.IgnoreStale {
+			return task.Spec{}, &StalePlanError{Reason: reason}
+		}
+	}
+	if len(s.policy) > 0 && plan.HasChanges && plan.changes == nil {
+		if err := s.showError(plan); err != nil {
+			return task.Spec{}, fmt.Errorf("plan cannot be applied until it has been checked against policy: showing plan failed: %w", err)
+		}
+		return task.Spec{}, errors.New("plan cannot be applied until it has been checked against policy")
+	}
+	if len(plan.violations) > 0 && !opts.OverridePolicy {
+		return task.Spec{}, &PolicyViolationError{Violations: plan.violations}
+	}
+	spec, err := plan.applyTaskSpec()
+	if err != nil {
+		return task.Spec{}, err
+	}
+	// Once applied the plan can no longer become stale.
+	spec.AfterRunning = s.setApplied(plan.ID)
+	return spec, nil
 }
 
 func (s *Service) Get(runID resource.ID) (*plan, error) {
@@ -146,6 +310,87 @@ func (s *Service) getByTaskID(taskID resource.ID) (*plan, error) {
 	return nil, fmt.Errorf("task is not associated with a plan: %w", resource.ErrNotFound)
 }
 
+// afterShow returns a function that sets the resource changes of a plan once
+// it has been shown, evaluating the changes against policy, and archiving the
+// plan if retention is enabled.
+func (s *Service) afterShow(planID resource.ID) func([]*ResourceChange, []byte) {
+	return func(changes []*ResourceChange, shown []byte) {
+		s.setChanges(planID, changes)
+		if err := s.archivePlan(planID, shown); err != nil {
+			s.logger.Error("archiving plan", "error", err)
+		}
+	}
+}
+
+// setChanges sets the resource changes of a plan, and evaluates the changes
+// against policy.
+func (s *Service) setChanges(planID resource.ID, changes []*ResourceChange) {
+	s.table.Update(planID, func(p *plan) error {
+		p.changes = changes
+		if len(s.policy) == 0 {
+			return nil
+		}
+		ws, err := s.workspaces.Get(p.WorkspaceID)
+		if err != nil {
+			return err
+		}
+		p.violations = evaluatePolicy(s.policy, ws.Name, changes)
+		if len(p.violations) > 0 {
+			s.logger.Warn("plan violates policy", "plan", p, "violations", len(p.violations))
+		}
+		return nil
+	})
+}
+
+// Violations retrieves the policy violations of the plan created by the plan
+// task with the given ID.
+func (s *Service) Violations(taskID resource.ID) []Violation {
+	plan, err := s.getByTaskID(taskID)
+	if err != nil {
+		return nil
+	}
+	return plan.violations
+}
+
+// ResourceChanges retrieves the resource changes in the plan created by the
+// plan task with the given ID.
+func (s *Service) ResourceChanges(taskID resource.ID) ([]*ResourceChange, error) {
+	plan, err := s.getByTaskID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.changes == nil {
+		if !plan.HasChanges {
+			return nil, errors.New("plan does not have any changes")
+		}
+		if err := s.showError(plan); err != nil {
+			return nil, fmt.Errorf("plan changes are not available: showing plan failed: %w", err)
+		}
+		return nil, errors.New("plan changes are not yet available")
+	}
+	return plan.changes, nil
+}
+
+// GetResourceChange retrieves a resource change from a plan or an archived
+// plan.
+func (s *Service) GetResourceChange(id resource.ID) (*ResourceChange, error) {
+	for _, plan := range s.List() {
+		for _, rc := range plan.changes {
+			if rc.ID == id {
+				return rc, nil
+			}
+		}
+	}
+	for _, archived := range s.ListArchived() {
+		for _, rc := range archived.changes {
+			if rc.ID == id {
+				return rc, nil
+			}
+		}
+	}
+	return nil, resource.ErrNotFound
+}
+
 func (s *Service) List() []*plan {
 	return s.table.List()
 }
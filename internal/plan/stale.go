@@ -0,0 +1,274 @@
+package plan
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/task"
+)
+
+// Reasons for a plan becoming stale.
+const (
+	StaleState  = "state has changed since plan"
+	StaleModule = "module has changed since plan"
+)
+
+// staleCheckInterval is how often plans are checked for changes to their
+// module.
+const staleCheckInterval = 30 * time.Second
+
+// inputs are the inputs to a plan, recorded when the plan starts, in order to
+// detect whether the plan has since become stale.
+type inputs struct {
+	// serial is the serial of the workspace state. Nil if the state had not
+	// been loaded.
+	serial *int64
+	// hash is a hash of the module's configuration and variable files, and
+	// of the plan's variables files.
+	hash string
+}
+
+// StalePlanError is returned when applying a plan whose module has changed
+// since the plan was created.
+type StalePlanError struct {
+	Reason string
+}
+
+func (e *StalePlanError) Error() string {
+	return "plan is stale: " + e.Reason
+}
+
+// isInputFile determines whether the file is a terraform configuration or
+// variables file.
+func isInputFile(name string) bool {
+	for _, ext := range []string{".tf", ".tf.json", ".tfvars", ".tfvars.json"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// hashInputs hashes the configuration and variable files in a module
+// directory, including those in any child module directories. Hidden
+// directories, such as .terraform, are skipped, as are the directories of
+// other modules nested within the module, because they are planned
+// separately.
+func hashInputs(dir string, modules map[string]bool) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path == dir {
+				return nil
+			}
+			if strings.HasPrefix(d.Name(), ".") || modules[path] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isInputFile(d.Name()) {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		return hashFile(h, rel, path)
+	})
+	if err != nil {
+		return "", fmt.Errorf("hashing module inputs: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashFile writes the name and contents of a file to the hash. The name is
+// included so that renaming a file changes the hash. A file that does not exist
+// contributes only its name.
+func hashFile(h io.Writer, name, path string) error {
+	fmt.Fprintf(h, "%s\x00", name)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(h, f)
+	return err
+}
+
+// moduleHasher hashes the inputs of plans, caching the hash of each module
+// to avoid re-hashing a module shared by several plans.
+type moduleHasher struct {
+	workdir internal.Workdir
+	// modules is the set of module directories.
+	modules map[string]bool
+	hashes  map[string]string
+}
+
+func (s *Service) newModuleHasher() *moduleHasher {
+	h := &moduleHasher{
+		workdir: s.workdir,
+		modules: make(map[string]bool),
+		hashes:  make(map[string]string),
+	}
+	for _, mod := range s.modules.List() {
+		h.modules[s.workdir.Join(mod.Path)] = true
+	}
+	return h
+}
+
+// hash hashes the inputs of a plan: the configuration and variable files in
+// its module directory, and its variables files, which may reside outside of
+// the module directory, e.g. ../common.tfvars.
+func (h *moduleHasher) hash(p *plan) (string, error) {
+	dir := h.workdir.Join(p.ModulePath)
+	moduleHash, ok := h.hashes[p.ModulePath]
+	if !ok {
+		var err error
+		moduleHash, err = hashInputs(dir, h.modules)
+		if err != nil {
+			return "", err
+		}
+		h.hashes[p.ModulePath] = moduleHash
+	}
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%s\x00", moduleHash)
+	for _, name := range p.varFiles {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, name)
+		}
+		if err := hashFile(sum, name, path); err != nil {
+			return "", fmt.Errorf("hashing variables file: %w", err)
+		}
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// currentSerial retrieves the serial of the plan's workspace state. Nil is
+// returned if the state has not been loaded.
+func (s *Service) currentSerial(p *plan) *int64 {
+	if current, err := s.states.Get(p.WorkspaceID); err == nil {
+		// Copy the serial rather than referencing the state.
+		serial := current.Serial
+		return &serial
+	}
+	return nil
+}
+
+// recordInputs returns a function that records the inputs of a plan when
+// its plan task starts.
+func (s *Service) recordInputs(planID resource.ID) func(*task.Task) {
+	return func(*task.Task) {
+		s.table.Update(planID, func(p *plan) error {
+			hash, err := s.newModuleHasher().hash(p)
+			if err != nil {
+				s.logger.Error("recording plan inputs", "error", err, "plan", p)
+				return nil
+			}
+			p.inputs = &inputs{serial: s.currentSerial(p), hash: hash}
+			return nil
+		})
+	}
+}
+
+// setApplied returns a function that marks a plan as applied once its apply
+// task starts.
+func (s *Service) setApplied(planID resource.ID) func(*task.Task) {
+	return func(*task.Task) {
+		s.table.Update(planID, func(p *plan) error {
+			p.applied = true
+			return nil
+		})
+	}
+}
+
+// staleness determines whether a plan is stale, returning the reason, or an
+// empty string if it is not stale. Plans that have been applied, or that are
+// yet to finish, are never stale. The plan's module is only checked for
+// changes if a hasher is provided.
+func (s *Service) staleness(p *plan, hasher *moduleHasher) string {
+	if p.inputs == nil || p.applied || !p.HasChanges || p.taskID == nil {
+		return ""
+	}
+	if serial := s.currentSerial(p); p.inputs.serial != nil && serial != nil && *p.inputs.serial != *serial {
+		return StaleState
+	}
+	if hasher == nil {
+		return ""
+	}
+	hash, err := hasher.hash(p)
+	if err != nil {
+		s.logger.Error("checking plan staleness", "error", err, "plan", p)
+		return ""
+	}
+	if p.inputs.hash != hash {
+		return StaleModule
+	}
+	return ""
+}
+
+// checkStale checks whether a plan has become stale, and if so marks it, and
+// its plan task, as stale. The reason for its staleness is returned, or an
+// empty string if it is not stale. Without a hasher, only the state is
+// checked.
+func (s *Service) checkStale(p *plan, hasher *moduleHasher) string {
+	if p.stale != "" {
+		return p.stale
+	}
+	reason := s.staleness(p, hasher)
+	if reason == "" {
+		return ""
+	}
+	s.table.Update(p.ID, func(p *plan) error {
+		p.stale = reason
+		return nil
+	})
+	s.logger.Info("plan is stale", "plan", p, "reason", reason)
+	if planTask, err := s.tasks.Get(*p.taskID); err == nil {
+		planTask.Warn("stale: " + reason)
+	}
+	return reason
+}
+
+// DetectStalePlans checks plans for staleness whenever workspace state is
+// updated, and periodically checks plans for changes to their module, until
+// the context is canceled.
+func (s *Service) DetectStalePlans(ctx context.Context, sub <-chan resource.Event[*state.State]) {
+	ticker := time.NewTicker(staleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-sub:
+			if !ok {
+				return
+			}
+			for _, p := range s.List() {
+				if p.WorkspaceID == event.Payload.WorkspaceID {
+					s.checkStale(p, nil)
+				}
+			}
+		case <-ticker.C:
+			hasher := s.newModuleHasher()
+			for _, p := range s.List() {
+				s.checkStale(p, hasher)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
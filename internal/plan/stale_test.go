@@ -0,0 +1,147 @@
+package plan
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/module"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashInputs(t *testing.T) {
+	tests := []struct {
+		name string
+		// file to write after the initial hash
+		file string
+		want bool
+	}{
+		{"edit config", "main.tf", true},
+		{"add variables", "dev.tfvars", true},
+		{"edit child module", "modules/child/main.tf", true},
+		{"add json config", "override.tf.json", true},
+		{"ignore non-input file", "README.md", false},
+		{"ignore hidden directory", ".terraform/modules/child/main.tf", false},
+		{"ignore nested module", "nested/main.tf", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "main.tf"), `resource "random_pet" "pet" {}`)
+			// Other modules nested within the module.
+			modules := map[string]bool{filepath.Join(dir, "nested"): true}
+
+			before, err := hashInputs(dir, modules)
+			require.NoError(t, err)
+
+			writeFile(t, filepath.Join(dir, tt.file), "changed")
+
+			after, err := hashInputs(dir, modules)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, before != after)
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestService_ApplyPlan_Stale(t *testing.T) {
+	tests := []struct {
+		name string
+		// change to make after the plan has been created
+		change func(t *testing.T, f *factory, mod *module.Module, states *fakeStateService)
+		// want a stale plan error
+		wantStale bool
+		// want an error refusing to apply the plan
+		wantErr bool
+	}{
+		{
+			name:   "unchanged",
+			change: func(*testing.T, *factory, *module.Module, *fakeStateService) {},
+		},
+		{
+			name: "edit config",
+			change: func(t *testing.T, f *factory, mod *module.Module, _ *fakeStateService) {
+				writeFile(t, f.workdir.Join(mod.Path, "main.tf"), "changed")
+			},
+			wantStale: true,
+		},
+		{
+			name: "edit var file outside module",
+			change: func(t *testing.T, f *factory, mod *module.Module, _ *fakeStateService) {
+				writeFile(t, f.workdir.Join(mod.Path, "../common.tfvars"), "changed")
+			},
+			wantStale: true,
+		},
+		{
+			name: "state serial changed",
+			change: func(_ *testing.T, _ *factory, _ *module.Module, states *fakeStateService) {
+				states.state.Serial++
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, mod, ws := setupTest(t)
+			writeFile(t, f.workdir.Join(mod.Path, "main.tf"), `resource "random_pet" "pet" {}`)
+			writeFile(t, f.workdir.Join(mod.Path, "../common.tfvars"), `foo = "bar"`)
+
+			planTask := &task.Task{ID: resource.NewID(resource.Task), State: task.Exited}
+			states := &fakeStateService{state: &state.State{WorkspaceID: ws.ID, Serial: 1}}
+			svc := &Service{
+				table:   resource.NewTable(&fakePublisher[*plan]{}),
+				factory: f,
+				modules: f.modules,
+				tasks:   newFakeTaskService(planTask),
+				states:  states,
+				logger:  logging.Discard,
+			}
+
+			p, err := svc.newPlan(ws.ID, CreateOptions{
+				Variables: Variables{Files: []string{"../common.tfvars"}},
+				planFile:  true,
+			})
+			require.NoError(t, err)
+			p.taskID = &planTask.ID
+			p.HasChanges = true
+			svc.table.Add(p.ID, p)
+			svc.recordInputs(p.ID)(planTask)
+
+			tt.change(t, f, mod, states)
+
+			_, err = svc.ApplyPlan(planTask.ID, ApplyPlanOptions{})
+			var staleErr *StalePlanError
+			switch {
+			case tt.wantStale:
+				require.ErrorAs(t, err, &staleErr)
+				assert.Equal(t, StaleModule, staleErr.Reason)
+
+				// Staleness can be ignored.
+				_, err = svc.ApplyPlan(planTask.ID, ApplyPlanOptions{IgnoreStale: true})
+				assert.NoError(t, err)
+			case tt.wantErr:
+				require.Error(t, err)
+				assert.False(t, errors.As(err, &staleErr))
+
+				// A changed state cannot be ignored.
+				_, err = svc.ApplyPlan(planTask.ID, ApplyPlanOptions{IgnoreStale: true})
+				assert.Error(t, err)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/leg100/pug/internal/resource"
+)
+
+// Variables are variable overrides for a plan, in addition to any workspace
+// tfvars file.
+type Variables struct {
+	// Vars are variable assignments of the form key=value, passed with
+	// -var.
+	Vars []string
+	// Files are paths to variable files, relative to the module directory,
+	// passed with -var-file.
+	Files []string
+}
+
+// ParseVariables parses a space-separated list of variable overrides, where
+// each override is either a key=value assignment or the path to a .tfvars or
+// .tfvars.json file. Overrides are split in the manner of a shell, so a value
+// containing spaces can be quoted, e.g. name="foo bar". Spaces within the
+// brackets or braces of a list or map value need not be quoted, and quotes
+// within them are preserved, e.g. tags={Name="foo bar"}.
+func ParseVariables(s string) (Variables, error) {
+	fields, err := splitVariables(s)
+	if err != nil {
+		return Variables{}, err
+	}
+	var vars Variables
+	for _, field := range fields {
+		if strings.HasSuffix(field, ".tfvars") || strings.HasSuffix(field, ".tfvars.json") {
+			vars.Files = append(vars.Files, field)
+			continue
+		}
+		key, _, ok := strings.Cut(field, "=")
+		if !ok || key == "" {
+			return Variables{}, fmt.Errorf("invalid variable override: %s: must be key=value or a .tfvars file, and values containing spaces must be quoted", field)
+		}
+		vars.Vars = append(vars.Vars, field)
+	}
+	return vars, nil
+}
+
+// splitVariables splits a list of variable overrides into fields separated
+// by whitespace. Outside of brackets and braces, quotes group characters
+// into a field and are removed, as they are by a shell: characters within
+// single quotes are taken literally, and within double quotes a backslash
+// escapes a double quote or a backslash. Within brackets and braces, quotes
+// are preserved, and whitespace within them does not separate fields.
+func splitVariables(s string) ([]string, error) {
+	var (
+		fields []string
+		field  strings.Builder
+		// inField is true if a field has been started, which may yet be
+		// empty, e.g. "".
+		inField bool
+		// quote is the quote character of the current quoted string, or
+		// zero if not within a quoted string.
+		quote rune
+		// depth is the depth of nested brackets and braces.
+		depth int
+		// escaped is true if the previous character was a backslash
+		// escaping the current character.
+		escaped bool
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+			field.WriteRune(r)
+		case quote != 0:
+			switch {
+			case r == quote:
+				quote = 0
+				if depth > 0 {
+					field.WriteRune(r)
+				}
+			case r == '\\' && quote == '"':
+				escaped = true
+				if depth > 0 {
+					field.WriteRune(r)
+				}
+			default:
+				field.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inField = true
+			if depth > 0 {
+				field.WriteRune(r)
+			}
+		case r == '\\' && depth == 0:
+			escaped = true
+			inField = true
+		case unicode.IsSpace(r) && depth == 0:
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			switch r {
+			case '[', '{', '(':
+				depth++
+			case ']', '}', ')':
+				depth = max(0, depth-1)
+			}
+			field.WriteRune(r)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("invalid variable overrides: unterminated quote: %s", s)
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	return fields, nil
+}
+
+// IsZero is true if there are no variable overrides.
+func (v Variables) IsZero() bool {
+	return len(v.Vars) == 0 && len(v.Files) == 0
+}
+
+// String returns the overrides in the format parsed by ParseVariables.
+func (v Variables) String() string {
+	fields := make([]string, 0, len(v.Vars)+len(v.Files))
+	for _, field := range append(append([]string{}, v.Vars...), v.Files...) {
+		fields = append(fields, quoteVariable(field))
+	}
+	return strings.Join(fields, " ")
+}
+
+// quoteVariable quotes a variable override if it contains characters that
+// would otherwise be interpreted by ParseVariables.
+func quoteVariable(field string) string {
+	if !strings.ContainsAny(field, " \t\n'\"\\[]{}()") {
+		return field
+	}
+	if !strings.Contains(field, "'") {
+		return "'" + field + "'"
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(field) + `"`
+}
+
+// args returns the overrides as terraform flags. Files are passed before
+// vars, so that a var takes precedence over the same variable in a file.
+func (v Variables) args() []string {
+	args := make([]string, 0, len(v.Files)+len(v.Vars))
+	for _, f := range v.Files {
+		args = append(args, fmt.Sprintf("-var-file=%s", f))
+	}
+	for _, kv := range v.Vars {
+		args = append(args, fmt.Sprintf("-var=%s", kv))
+	}
+	return args
+}
+
+// recentVariables remembers the most recent variable overrides used to plan
+// each workspace.
+type recentVariables struct {
+	mu   sync.Mutex
+	vars map[resource.ID]Variables
+}
+
+func (r *recentVariables) set(workspaceID resource.ID, vars Variables) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.vars == nil {
+		r.vars = make(map[resource.ID]Variables)
+	}
+	r.vars[workspaceID] = vars
+}
+
+func (r *recentVariables) get(workspaceID resource.ID) Variables {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.vars[workspaceID]
+}
+
+// RecentVariables returns the variable overrides most recently used to plan
+// the workspace.
+func (s *Service) RecentVariables(workspaceID resource.ID) Variables {
+	return s.recentVariables.get(workspaceID)
+}
@@ -0,0 +1,72 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVariables(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Variables
+		wantErr bool
+	}{
+		{"empty", "", Variables{}, false},
+		{"var", "image_tag=v1.2.3", Variables{Vars: []string{"image_tag=v1.2.3"}}, false},
+		{"value containing equals", "flags=a=b", Variables{Vars: []string{"flags=a=b"}}, false},
+		{"empty value", "feature_x=", Variables{Vars: []string{"feature_x="}}, false},
+		{"var file", "extra.tfvars", Variables{Files: []string{"extra.tfvars"}}, false},
+		{"json var file", "../shared.tfvars.json", Variables{Files: []string{"../shared.tfvars.json"}}, false},
+		{
+			"mixed",
+			"feature_x=true  extra.tfvars image_tag=v2",
+			Variables{Vars: []string{"feature_x=true", "image_tag=v2"}, Files: []string{"extra.tfvars"}},
+			false,
+		},
+		{"double quoted value", `name="foo bar"`, Variables{Vars: []string{"name=foo bar"}}, false},
+		{"single quoted override", `'name=foo bar'`, Variables{Vars: []string{"name=foo bar"}}, false},
+		{"escaped quote", `name="say \"hi\""`, Variables{Vars: []string{`name=say "hi"`}}, false},
+		{"escaped space", `name=foo\ bar`, Variables{Vars: []string{"name=foo bar"}}, false},
+		{"empty quoted value", `name=""`, Variables{Vars: []string{"name="}}, false},
+		{"map", `tags={a="b c"}`, Variables{Vars: []string{`tags={a="b c"}`}}, false},
+		{"list", `list=["a", "b"] extra.tfvars`, Variables{Vars: []string{`list=["a", "b"]`}, Files: []string{"extra.tfvars"}}, false},
+		{"nested", `x={a=["b c", {d="e f"}]}`, Variables{Vars: []string{`x={a=["b c", {d="e f"}]}`}}, false},
+		{"quoted file with space", `'my vars.tfvars'`, Variables{Files: []string{"my vars.tfvars"}}, false},
+		{"unquoted value with space", "name=foo bar", Variables{}, true},
+		{"unterminated quote", `name="foo`, Variables{}, true},
+		{"missing value", "feature_x", Variables{}, true},
+		{"missing key", "=true", Variables{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseVariables(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestVariables_String(t *testing.T) {
+	tests := []struct {
+		name string
+		vars Variables
+	}{
+		{"simple", Variables{Vars: []string{"feature_x=true"}, Files: []string{"extra.tfvars"}}},
+		{"spaces", Variables{Vars: []string{"name=foo bar"}, Files: []string{"my vars.tfvars"}}},
+		{"quotes", Variables{Vars: []string{`tags={a="b c"}`, `msg=it's "quoted" \ok`}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseVariables(tt.vars.String())
+			require.NoError(t, err)
+			assert.Equal(t, tt.vars, got)
+		})
+	}
+}
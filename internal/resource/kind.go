@@ -13,6 +13,11 @@ const (
 	LogAttr
 	State
 	StateResource
+	ResourceChange
+	ArchivedPlan
+	StateOutput
+	StateDiff
+	StateNode
 )
 
 func (k Kind) String() string {
@@ -27,5 +32,10 @@ func (k Kind) String() string {
 		"attr",
 		"state",
 		"res",
+		"change",
+		"archive",
+		"output",
+		"diff",
+		"node",
 	}[k]
 }
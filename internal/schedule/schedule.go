@@ -0,0 +1,170 @@
+// Package schedule periodically creates task groups, for the purpose of
+// detecting drift.
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/workspace"
+)
+
+// PlanCommand is the only command that can currently be scheduled.
+const PlanCommand = "plan"
+
+// Schedule specifies a command to run on a recurring basis on every workspace
+// belonging to modules with a path matching a glob.
+type Schedule struct {
+	Command  string
+	Glob     string
+	Interval time.Duration
+}
+
+// Parse parses a schedule of the form <command>:<glob>=<interval>, e.g.
+// plan:prod/*=30m.
+func Parse(s string) (Schedule, error) {
+	i := strings.LastIndex(s, "=")
+	if i < 0 {
+		return Schedule{}, fmt.Errorf("invalid schedule: %s: missing =<interval>", s)
+	}
+	command, glob, ok := strings.Cut(s[:i], ":")
+	if !ok || glob == "" {
+		return Schedule{}, fmt.Errorf("invalid schedule: %s: missing <command>:<glob>", s)
+	}
+	if command != PlanCommand {
+		return Schedule{}, fmt.Errorf("invalid schedule: %s: command must be %s", s, PlanCommand)
+	}
+	if _, err := path.Match(glob, ""); err != nil {
+		return Schedule{}, fmt.Errorf("invalid schedule: %s: %w", s, err)
+	}
+	interval, err := time.ParseDuration(s[i+1:])
+	if err != nil || interval <= 0 {
+		return Schedule{}, fmt.Errorf("invalid schedule: %s: interval must be a positive duration", s)
+	}
+	return Schedule{Command: command, Glob: glob, Interval: interval}, nil
+}
+
+func (s Schedule) String() string {
+	return fmt.Sprintf("%s:%s=%s", s.Command, s.Glob, s.Interval)
+}
+
+type Options struct {
+	Schedules  []Schedule
+	Tasks      *task.Service
+	Plans      *plan.Service
+	Workspaces *workspace.Service
+	Logger     logging.Interface
+}
+
+type scheduler struct {
+	tasks      groupCreator
+	plans      planner
+	workspaces workspaceService
+	logger     logging.Interface
+}
+
+type groupCreator interface {
+	CreateGroup(policy task.FailurePolicy, specs ...task.Spec) (*task.Group, error)
+}
+
+type planner interface {
+	Plan(workspaceID resource.ID, opts plan.CreateOptions) (task.Spec, error)
+}
+
+type workspaceService interface {
+	List(opts workspace.ListOptions) []*workspace.Workspace
+	RecordDrift(workspaceID resource.ID, drifted bool, summary string) (*workspace.Workspace, error)
+}
+
+// Start starts a timer for each schedule, creating a task group each time the
+// timer fires, until the context is canceled.
+func Start(ctx context.Context, opts Options) {
+	s := &scheduler{
+		tasks:      opts.Tasks,
+		plans:      opts.Plans,
+		workspaces: opts.Workspaces,
+		logger:     opts.Logger,
+	}
+	for _, sched := range opts.Schedules {
+		go s.start(ctx, sched)
+	}
+}
+
+func (s *scheduler) start(ctx context.Context, sched Schedule) {
+	ticker := time.NewTicker(sched.Interval)
+	defer ticker.Stop()
+
+	// The task group created the last time the schedule fired.
+	var last *task.Group
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if last != nil && last.Finished() < len(last.Tasks) {
+				// Don't pile up task groups if the previous group has yet to
+				// finish.
+				s.logger.Warn("skipping scheduled task group: previous group has not finished", "schedule", sched)
+				continue
+			}
+			group, err := s.run(sched)
+			if err != nil {
+				s.logger.Error("creating scheduled task group", "error", err, "schedule", sched)
+				continue
+			}
+			last = group
+		}
+	}
+}
+
+// run creates a task group for the schedule, planning each workspace
+// belonging to a module with a path matching the schedule's glob, and
+// recording whether each plan detects drift.
+func (s *scheduler) run(sched Schedule) (*task.Group, error) {
+	var specs []task.Spec
+	for _, ws := range s.workspaces.List(workspace.ListOptions{}) {
+		if !internal.MatchGlob(sched.Glob, ws.ModulePath) {
+			continue
+		}
+		spec, err := s.plans.Plan(ws.ID, plan.CreateOptions{Scheduled: true})
+		if err != nil {
+			s.logger.Error("creating scheduled plan", "error", err, "workspace", ws)
+			continue
+		}
+		spec.Description += " (scheduled)"
+		spec.BeforeExited = s.recordDrift(ws.ID, spec.BeforeExited)
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("no workspaces found in modules matching %s", sched.Glob)
+	}
+	group, err := s.tasks.CreateGroup(task.ContinueOnFailure, specs...)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("created scheduled task group", "group", group, "schedule", sched)
+	return group, nil
+}
+
+// recordDrift wraps a plan task's callback to record whether the plan detected
+// drift.
+func (s *scheduler) recordDrift(workspaceID resource.ID, fn func(*task.Task) (task.Summary, error)) func(*task.Task) (task.Summary, error) {
+	return func(t *task.Task) (task.Summary, error) {
+		summary, err := fn(t)
+		if err != nil {
+			return summary, err
+		}
+		if report, ok := summary.(plan.Report); ok {
+			s.workspaces.RecordDrift(workspaceID, report.HasChanges(), report.String())
+		}
+		return summary, nil
+	}
+}
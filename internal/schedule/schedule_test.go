@@ -0,0 +1,126 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/workspace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		s       string
+		want    Schedule
+		wantErr bool
+	}{
+		{"plan:prod/*=30m0s", Schedule{Command: "plan", Glob: "prod/*", Interval: 30 * time.Minute}, false},
+		{"plan:**=1h0m0s", Schedule{Command: "plan", Glob: "**", Interval: time.Hour}, false},
+		{"apply:prod/*=30m", Schedule{}, true},
+		{"plan:prod/*", Schedule{}, true},
+		{"plan=30m", Schedule{}, true},
+		{"plan:[=30m", Schedule{}, true},
+		{"plan:prod/*=often", Schedule{}, true},
+		{"plan:prod/*=0s", Schedule{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := Parse(tt.s)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.s, got.String())
+		})
+	}
+}
+
+func TestScheduler_run(t *testing.T) {
+	t.Parallel()
+
+	prod := &workspace.Workspace{ID: resource.NewID(resource.Workspace), ModulePath: "prod/vpc"}
+	dev := &workspace.Workspace{ID: resource.NewID(resource.Workspace), ModulePath: "dev/vpc"}
+	workspaces := &fakeWorkspaceService{workspaces: []*workspace.Workspace{prod, dev}}
+	tasks := &fakeGroupCreator{}
+	planner := &fakePlanner{report: plan.Report{Changes: 1}}
+	s := &scheduler{
+		tasks:      tasks,
+		plans:      planner,
+		workspaces: workspaces,
+		logger:     logging.Discard,
+	}
+
+	_, err := s.run(Schedule{Command: PlanCommand, Glob: "prod/*", Interval: time.Minute})
+	require.NoError(t, err)
+
+	// Only the prod workspace is planned.
+	require.Len(t, tasks.specs, 1)
+	assert.Equal(t, &prod.ID, tasks.specs[0].WorkspaceID)
+	assert.Equal(t, "plan (scheduled)", tasks.specs[0].Description)
+	// Scheduled plans are not archived.
+	assert.Equal(t, []plan.CreateOptions{{Scheduled: true}}, planner.opts)
+
+	// Drift is recorded once the plan finishes.
+	_, err = tasks.specs[0].BeforeExited(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []recordedDrift{{prod.ID, true, "+0/~1/−0"}}, workspaces.recorded)
+
+	t.Run("no matching workspaces", func(t *testing.T) {
+		_, err := s.run(Schedule{Command: PlanCommand, Glob: "staging/*", Interval: time.Minute})
+		assert.Error(t, err)
+	})
+}
+
+type fakeGroupCreator struct {
+	specs []task.Spec
+}
+
+func (f *fakeGroupCreator) CreateGroup(_ task.FailurePolicy, specs ...task.Spec) (*task.Group, error) {
+	f.specs = specs
+	return &task.Group{}, nil
+}
+
+type fakePlanner struct {
+	report plan.Report
+	opts   []plan.CreateOptions
+}
+
+func (f *fakePlanner) Plan(workspaceID resource.ID, opts plan.CreateOptions) (task.Spec, error) {
+	f.opts = append(f.opts, opts)
+	return task.Spec{
+		WorkspaceID: &workspaceID,
+		Description: "plan",
+		BeforeExited: func(*task.Task) (task.Summary, error) {
+			return f.report, nil
+		},
+	}, nil
+}
+
+type recordedDrift struct {
+	workspaceID resource.ID
+	drifted     bool
+	summary     string
+}
+
+type fakeWorkspaceService struct {
+	workspaces []*workspace.Workspace
+	recorded   []recordedDrift
+}
+
+func (f *fakeWorkspaceService) List(workspace.ListOptions) []*workspace.Workspace {
+	return f.workspaces
+}
+
+func (f *fakeWorkspaceService) RecordDrift(workspaceID resource.ID, drifted bool, summary string) (*workspace.Workspace, error) {
+	f.recorded = append(f.recorded, recordedDrift{workspaceID, drifted, summary})
+	return nil, nil
+}
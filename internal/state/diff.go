@@ -0,0 +1,73 @@
+package state
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/leg100/pug/internal/attribute"
+	"github.com/leg100/pug/internal/resource"
+)
+
+// Diff is the difference between two states of a workspace.
+type Diff struct {
+	resource.ID
+
+	From *State
+	To   *State
+	// Added are the addresses of resources in To but not in From.
+	Added []ResourceAddress
+	// Removed are the addresses of resources in From but not in To.
+	Removed []ResourceAddress
+	// Changed are the resources in both states whose attributes differ.
+	Changed []ResourceDiff
+}
+
+// ResourceDiff is the difference between the attributes of a resource in two
+// states.
+type ResourceDiff struct {
+	Address    ResourceAddress
+	Attributes []attribute.Diff
+}
+
+// NewDiff determines the difference between two states.
+func NewDiff(from, to *State) *Diff {
+	diff := &Diff{
+		ID:   resource.NewID(resource.StateDiff),
+		From: from,
+		To:   to,
+	}
+	for addr, after := range to.Resources {
+		before, ok := from.Resources[addr]
+		if !ok {
+			diff.Added = append(diff.Added, addr)
+			continue
+		}
+		if attrs := attribute.Compare(before.values(), after.values(), nil); len(attrs) > 0 {
+			diff.Changed = append(diff.Changed, ResourceDiff{
+				Address:    addr,
+				Attributes: attrs,
+			})
+		}
+	}
+	for addr := range from.Resources {
+		if _, ok := to.Resources[addr]; !ok {
+			diff.Removed = append(diff.Removed, addr)
+		}
+	}
+	slices.Sort(diff.Added)
+	slices.Sort(diff.Removed)
+	slices.SortFunc(diff.Changed, func(a, b ResourceDiff) int {
+		return cmp.Compare(a.Address, b.Address)
+	})
+	return diff
+}
+
+func (d *Diff) String() string {
+	return fmt.Sprintf("%d..%d", d.From.Serial, d.To.Serial)
+}
+
+// IsEmpty is true if there are no differences between the two states.
+func (d *Diff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
@@ -0,0 +1,126 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/leg100/pug/internal/attribute"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDiff(t *testing.T) {
+	from := &State{
+		Serial: 4,
+		Resources: map[ResourceAddress]*Resource{
+			"random_pet.removed": {Attributes: map[string]any{"id": "old"}},
+			"random_pet.same":    {Attributes: map[string]any{"id": "same", "length": float64(2)}},
+			"random_pet.changed": {Attributes: map[string]any{
+				"id":      "before",
+				"length":  float64(2),
+				"keepers": map[string]any{"a": "1", "b": "2"},
+				"tags":    []any{"x"},
+			}},
+		},
+	}
+	to := &State{
+		Serial: 5,
+		Resources: map[ResourceAddress]*Resource{
+			"random_pet.added": {Attributes: map[string]any{"id": "new"}},
+			"random_pet.same":  {Attributes: map[string]any{"id": "same", "length": float64(2)}},
+			"random_pet.changed": {Attributes: map[string]any{
+				"id":      "after",
+				"length":  float64(2),
+				"keepers": map[string]any{"a": "1", "c": "3"},
+				"tags":    []any{"x", "y"},
+			}},
+		},
+	}
+
+	got := NewDiff(from, to)
+
+	assert.Equal(t, resource.StateDiff, got.ID.Kind)
+	assert.Equal(t, "4..5", got.String())
+	assert.False(t, got.IsEmpty())
+	assert.Equal(t, []ResourceAddress{"random_pet.added"}, got.Added)
+	assert.Equal(t, []ResourceAddress{"random_pet.removed"}, got.Removed)
+	want := []ResourceDiff{
+		{
+			Address: "random_pet.changed",
+			Attributes: []attribute.Diff{
+				{Path: "id", Before: `"before"`, After: `"after"`},
+				{Path: "keepers.b", Before: `"2"`},
+				{Path: "keepers.c", After: `"3"`},
+				{Path: "tags[1]", After: `"y"`},
+			},
+		},
+	}
+	assert.Equal(t, want, got.Changed)
+}
+
+func TestNewDiff_Identical(t *testing.T) {
+	state := &State{
+		Resources: map[ResourceAddress]*Resource{
+			"random_pet.pet": {Attributes: map[string]any{"id": "same"}},
+		},
+	}
+
+	assert.True(t, NewDiff(state, state).IsEmpty())
+}
+
+func TestNewDiff_Sensitive(t *testing.T) {
+	from := &State{
+		Resources: map[ResourceAddress]*Resource{
+			"random_password.pw": {
+				Attributes: map[string]any{"result": "old", "length": float64(8)},
+				Sensitive:  map[string]bool{"result": true},
+			},
+		},
+	}
+	to := &State{
+		Resources: map[ResourceAddress]*Resource{
+			"random_password.pw": {
+				Attributes: map[string]any{"result": "new", "length": float64(9)},
+				Sensitive:  map[string]bool{"result": true},
+			},
+		},
+	}
+
+	got := NewDiff(from, to)
+
+	want := []ResourceDiff{
+		{
+			Address: "random_password.pw",
+			Attributes: []attribute.Diff{
+				{Path: "length", Before: "8", After: "9"},
+				{Path: "result", Before: attribute.SensitiveValue, After: attribute.SensitiveValue},
+			},
+		},
+	}
+	assert.Equal(t, want, got.Changed)
+}
+
+func TestSensitivePaths(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want map[string]bool
+	}{
+		{"none", `[]`, nil},
+		{
+			"paths",
+			`[
+				[{"type":"get_attr","value":"password"}],
+				[{"type":"get_attr","value":"tags"},{"type":"index","value":{"value":"secret","type":"string"}}],
+				[{"type":"get_attr","value":"ingress"},{"type":"index","value":{"value":0,"type":"number"}},{"type":"get_attr","value":"key"}]
+			]`,
+			map[string]bool{"password": true, "tags.secret": true, "ingress[0].key": true},
+		},
+		{"unknown format", `{"password":true}`, map[string]bool{"": true}},
+		{"unknown step", `[[{"type":"splat"}]]`, map[string]bool{"": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sensitivePaths([]byte(tt.raw)))
+		})
+	}
+}
@@ -44,6 +44,20 @@ type (
 		IndexKey   any `json:"index_key"`
 		Status     StateFileResourceInstanceStatus
 		Attributes json.RawMessage
+		// SensitiveAttributes are the paths of sensitive attributes, each
+		// path a list of steps, i.e. [][]StateFilePathStep.
+		SensitiveAttributes json.RawMessage `json:"sensitive_attributes"`
+	}
+
+	// StateFilePathStep is a step in the path to an attribute: either the
+	// name of an attribute, or an index into a list or map.
+	StateFilePathStep struct {
+		// Type is either get_attr or index.
+		Type string
+		// Value is the name of the attribute for a get_attr step, or a
+		// JSON-encoded typed value for an index step, e.g.
+		// {"value":0,"type":"number"}.
+		Value json.RawMessage
 	}
 
 	StateFileResourceInstanceStatus string
@@ -0,0 +1,177 @@
+package state
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/leg100/pug/internal/resource"
+)
+
+// historySize is the maximum number of states retained per workspace.
+const historySize = 10
+
+var (
+	ErrNoPreviousState     = errors.New("no previous state retained")
+	ErrDifferentWorkspaces = errors.New("states belong to different workspaces")
+)
+
+// history retains the most recent states of each workspace, each identified
+// by its lineage and serial.
+type history struct {
+	mu     sync.Mutex
+	states map[resource.ID][]*State
+	// diffs are the diffs that have been requested, which are retained for as
+	// long as both their states are retained.
+	diffs map[resource.ID]*Diff
+	// recorded is when each state was added to the history.
+	recorded map[resource.ID]time.Time
+}
+
+func newHistory() *history {
+	return &history{
+		states:   make(map[resource.ID][]*State),
+		diffs:    make(map[resource.ID]*Diff),
+		recorded: make(map[resource.ID]time.Time),
+	}
+}
+
+// add adds a state to the history of its workspace, unless the state is
+// empty or a state with the same lineage and serial has already been added.
+// The oldest states are removed once the history exceeds historySize.
+func (h *history) add(state *State, now time.Time) {
+	if state.Serial < 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	states := h.states[state.WorkspaceID]
+	for _, existing := range states {
+		if existing.Lineage == state.Lineage && existing.Serial == state.Serial {
+			return
+		}
+	}
+	h.recorded[state.ID] = now
+	states = append(states, state)
+	if len(states) > historySize {
+		evicted := states[0]
+		states = states[1:]
+		delete(h.recorded, evicted.ID)
+		for id, diff := range h.diffs {
+			if diff.From == evicted || diff.To == evicted {
+				delete(h.diffs, id)
+			}
+		}
+	}
+	h.states[state.WorkspaceID] = states
+}
+
+// list lists the states of a workspace, newest first.
+func (h *history) list(workspaceID resource.ID) []*State {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	states := slices.Clone(h.states[workspaceID])
+	slices.Reverse(states)
+	return states
+}
+
+// get retrieves a state from the history of any workspace.
+func (h *history) get(stateID resource.ID) (*State, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, states := range h.states {
+		for _, state := range states {
+			if state.ID == stateID {
+				return state, nil
+			}
+		}
+	}
+	return nil, resource.ErrNotFound
+}
+
+// previous retrieves the state added to the history before the given state.
+func (h *history) previous(state *State) (*State, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	states := h.states[state.WorkspaceID]
+	for i, existing := range states {
+		if existing.ID == state.ID && i > 0 {
+			return states[i-1], true
+		}
+	}
+	return nil, false
+}
+
+func (h *history) addDiff(diff *Diff) *Diff {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.diffs[diff.ID] = diff
+	return diff
+}
+
+// Recorded returns when a state was added to the history. The zero time is
+// returned if the state is not in the history.
+func (s *Service) Recorded(stateID resource.ID) time.Time {
+	s.history.mu.Lock()
+	defer s.history.mu.Unlock()
+
+	return s.history.recorded[stateID]
+}
+
+// History lists the retained states of a workspace, newest first.
+func (s *Service) History(workspaceID resource.ID) []*State {
+	return s.history.list(workspaceID)
+}
+
+// Diff determines the difference between two retained states of the same
+// workspace, from the older to the newer.
+func (s *Service) Diff(stateID1, stateID2 resource.ID) (*Diff, error) {
+	from, err := s.history.get(stateID1)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.history.get(stateID2)
+	if err != nil {
+		return nil, err
+	}
+	if from.WorkspaceID != to.WorkspaceID {
+		return nil, ErrDifferentWorkspaces
+	}
+	if from.Serial > to.Serial {
+		from, to = to, from
+	}
+	return s.history.addDiff(NewDiff(from, to)), nil
+}
+
+// DiffPrevious determines the difference between a retained state and the
+// state retained before it.
+func (s *Service) DiffPrevious(stateID resource.ID) (*Diff, error) {
+	to, err := s.history.get(stateID)
+	if err != nil {
+		return nil, err
+	}
+	from, ok := s.history.previous(to)
+	if !ok {
+		return nil, ErrNoPreviousState
+	}
+	return s.history.addDiff(NewDiff(from, to)), nil
+}
+
+// GetDiff retrieves a diff previously determined with Diff.
+func (s *Service) GetDiff(diffID resource.ID) (*Diff, error) {
+	s.history.mu.Lock()
+	defer s.history.mu.Unlock()
+
+	diff, ok := s.history.diffs[diffID]
+	if !ok {
+		return nil, resource.ErrNotFound
+	}
+	return diff, nil
+}
@@ -0,0 +1,84 @@
+package state
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	workspaceID := resource.NewID(resource.Workspace)
+	newTestState := func(lineage string, serial int64) *State {
+		return &State{
+			ID:          resource.NewID(resource.State),
+			WorkspaceID: workspaceID,
+			Lineage:     lineage,
+			Serial:      serial,
+			Resources: map[ResourceAddress]*Resource{
+				ResourceAddress(fmt.Sprintf("random_pet.pet%d", serial)): {},
+			},
+		}
+	}
+
+	t.Run("skip empty and duplicate states", func(t *testing.T) {
+		h := newHistory()
+		first := newTestState("abc", 1)
+
+		h.add(&State{WorkspaceID: workspaceID, Serial: -1}, time.Now())
+		h.add(first, time.Now())
+		h.add(newTestState("abc", 1), time.Now())
+		h.add(newTestState("def", 1), time.Now())
+
+		got := h.list(workspaceID)
+		require.Len(t, got, 2)
+		assert.Equal(t, "def", got[0].Lineage)
+		assert.Equal(t, first, got[1])
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		h := newHistory()
+		first := newTestState("abc", 0)
+		h.add(first, time.Now())
+		for i := 1; i <= historySize; i++ {
+			h.add(newTestState("abc", int64(i)), time.Now())
+		}
+
+		got := h.list(workspaceID)
+		require.Len(t, got, historySize)
+		assert.Equal(t, int64(historySize), got[0].Serial)
+		assert.Equal(t, int64(1), got[historySize-1].Serial)
+
+		_, err := h.get(first.ID)
+		assert.ErrorIs(t, err, resource.ErrNotFound)
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		svc := &Service{history: newHistory()}
+		older := newTestState("abc", 1)
+		newer := newTestState("abc", 2)
+		svc.history.add(older, time.Now())
+		svc.history.add(newer, time.Now())
+
+		_, err := svc.DiffPrevious(older.ID)
+		assert.ErrorIs(t, err, ErrNoPreviousState)
+
+		diff, err := svc.DiffPrevious(newer.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []ResourceAddress{"random_pet.pet2"}, diff.Added)
+		assert.Equal(t, []ResourceAddress{"random_pet.pet1"}, diff.Removed)
+
+		// States are diffed from older to newer regardless of order.
+		diff, err = svc.Diff(newer.ID, older.ID)
+		require.NoError(t, err)
+		assert.Equal(t, older, diff.From)
+		assert.Equal(t, newer, diff.To)
+
+		got, err := svc.GetDiff(diff.ID)
+		require.NoError(t, err)
+		assert.Equal(t, diff, got)
+	})
+}
@@ -0,0 +1,276 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/leg100/pug/internal/attribute"
+	"github.com/leg100/pug/internal/resource"
+)
+
+// index indexes the resources in the current state of every workspace, by ID,
+// by type, and by attribute value.
+type index struct {
+	mu sync.RWMutex
+	// resources maps resource IDs to resources
+	resources map[resource.ID]*Resource
+	// workspaces maps workspace IDs to the IDs of their indexed resources
+	workspaces map[resource.ID][]resource.ID
+	// types maps resource types to resource IDs
+	types map[string]idSet
+	// attributes maps attribute path=value terms to resource IDs
+	attributes map[string]idSet
+	// values maps attribute values to resource IDs
+	values map[string]idSet
+}
+
+type idSet map[resource.ID]struct{}
+
+func newIndex() *index {
+	return &index{
+		resources:  make(map[resource.ID]*Resource),
+		workspaces: make(map[resource.ID][]resource.ID),
+		types:      make(map[string]idSet),
+		attributes: make(map[string]idSet),
+		values:     make(map[string]idSet),
+	}
+}
+
+// listIndex matches the list indices in an attribute path, which are
+// removed so that a query need not specify them.
+var listIndex = regexp.MustCompile(`\[\d+\]`)
+
+// update replaces the indexed resources of the state's workspace with the
+// resources in the state.
+func (x *index) update(state *State) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(state.WorkspaceID)
+
+	ids := make([]resource.ID, 0, len(state.Resources))
+	for _, res := range state.Resources {
+		x.resources[res.ID] = res
+		ids = append(ids, res.ID)
+		add(x.types, strings.ToLower(res.Type), res.ID)
+		for _, leaf := range indexLeaves(res) {
+			add(x.attributes, leaf.path+"="+leaf.value, res.ID)
+			add(x.values, leaf.value, res.ID)
+		}
+	}
+	x.workspaces[state.WorkspaceID] = ids
+}
+
+// remove removes the indexed resources of a workspace. The caller must hold
+// the lock.
+func (x *index) remove(workspaceID resource.ID) {
+	for _, id := range x.workspaces[workspaceID] {
+		res, ok := x.resources[id]
+		if !ok {
+			continue
+		}
+		discard(x.types, strings.ToLower(res.Type), id)
+		for _, leaf := range indexLeaves(res) {
+			discard(x.attributes, leaf.path+"="+leaf.value, id)
+			discard(x.values, leaf.value, id)
+		}
+		delete(x.resources, id)
+	}
+	delete(x.workspaces, workspaceID)
+}
+
+func (x *index) get(id resource.ID) (*Resource, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	res, ok := x.resources[id]
+	return res, ok
+}
+
+// search returns the resources matching every term in the query.
+func (x *index) search(q query) []*Resource {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	// Start with the resources matching the most selective indexed term, or
+	// if there are no indexed terms, all resources.
+	var candidates idSet
+	for _, term := range q {
+		var ids idSet
+		switch term.kind {
+		case typeTerm:
+			ids = x.types[term.value]
+		case attributeTerm:
+			ids = x.attributes[term.value]
+		default:
+			continue
+		}
+		if candidates == nil || len(ids) < len(candidates) {
+			candidates = ids
+		}
+		if len(candidates) == 0 {
+			return nil
+		}
+	}
+	var matches []*Resource
+	if candidates == nil {
+		for id, res := range x.resources {
+			if x.match(q, id, res) {
+				matches = append(matches, res)
+			}
+		}
+		return matches
+	}
+	for id := range candidates {
+		if res := x.resources[id]; x.match(q, id, res) {
+			matches = append(matches, res)
+		}
+	}
+	return matches
+}
+
+// match determines whether a resource matches every term in the query. The
+// caller must hold the lock.
+func (x *index) match(q query, id resource.ID, res *Resource) bool {
+	for _, term := range q {
+		var ok bool
+		switch term.kind {
+		case typeTerm:
+			_, ok = x.types[term.value][id]
+		case attributeTerm:
+			_, ok = x.attributes[term.value][id]
+		case addressTerm:
+			ok = strings.Contains(strings.ToLower(string(res.Address)), term.value)
+		case anyTerm:
+			_, ok = x.values[term.value][id]
+			if !ok {
+				ok = strings.Contains(strings.ToLower(string(res.Address)), term.value)
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func add(m map[string]idSet, term string, id resource.ID) {
+	ids, ok := m[term]
+	if !ok {
+		ids = make(idSet)
+		m[term] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func discard(m map[string]idSet, term string, id resource.ID) {
+	ids, ok := m[term]
+	if !ok {
+		return
+	}
+	delete(ids, id)
+	if len(ids) == 0 {
+		delete(m, term)
+	}
+}
+
+// leaf is an attribute value and its path.
+type leaf struct {
+	path  string
+	value string
+}
+
+// indexLeaves flattens a resource's attributes into their lower-cased leaf
+// values and paths, with list indices removed from the paths. String values
+// are unquoted. Sensitive values are omitted, so that they cannot be
+// discovered by searching for them.
+func indexLeaves(res *Resource) []leaf {
+	flattened := attribute.Flatten(res.Attributes)
+
+	leaves := make([]leaf, 0, len(flattened))
+	for path, v := range flattened {
+		if attribute.Marked(res.Sensitive, path) {
+			continue
+		}
+		value, ok := v.(string)
+		if !ok {
+			value = attribute.Render(v, false)
+		}
+		leaves = append(leaves, leaf{
+			path:  strings.ToLower(listIndex.ReplaceAllString(path, "")),
+			value: strings.ToLower(value),
+		})
+	}
+	return leaves
+}
+
+type termKind int
+
+const (
+	// anyTerm matches any attribute value, or part of an address
+	anyTerm termKind = iota
+	// typeTerm matches a resource type, e.g. type:aws_s3_bucket
+	typeTerm
+	// addressTerm matches part of an address, e.g. addr:module.vpc
+	addressTerm
+	// attributeTerm matches an attribute value, e.g. versioning.enabled=false
+	attributeTerm
+)
+
+type term struct {
+	kind  termKind
+	value string
+}
+
+// query is a search query, a list of terms which must all match.
+type query []term
+
+var ErrEmptyQuery = errors.New("empty search query")
+
+// parseQuery parses a search query. Each space-separated term is one of:
+//
+//	type:<type>         resources of the given type
+//	addr:<text>         resources with an address containing text
+//	<path>=<value>      resources with an attribute with the given value,
+//	                    omitting any list indices from the path
+//	<value>             resources with any attribute with the given value, or
+//	                    with an address containing the value
+//
+// Terms are case-insensitive.
+func parseQuery(s string) (query, error) {
+	var q query
+	for _, field := range strings.Fields(strings.ToLower(s)) {
+		t := term{kind: anyTerm, value: field}
+		if value, ok := strings.CutPrefix(field, "type:"); ok {
+			t = term{kind: typeTerm, value: value}
+		} else if value, ok := strings.CutPrefix(field, "addr:"); ok {
+			t = term{kind: addressTerm, value: value}
+		} else if path, value, ok := strings.Cut(field, "="); ok {
+			if path == "" {
+				return nil, fmt.Errorf("invalid search term: %s: missing attribute path", field)
+			}
+			t = term{kind: attributeTerm, value: listIndex.ReplaceAllString(path, "") + "=" + value}
+		}
+		if t.value == "" {
+			return nil, fmt.Errorf("invalid search term: %s: missing value", field)
+		}
+		q = append(q, t)
+	}
+	if len(q) == 0 {
+		return nil, ErrEmptyQuery
+	}
+	return q, nil
+}
+
+// Search searches the resources in the current state of every workspace.
+// See parseQuery for the query syntax.
+func (s *Service) Search(query string) ([]*Resource, error) {
+	q, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return s.index.search(q), nil
+}
@@ -0,0 +1,182 @@
+package state
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex(t *testing.T) {
+	f, err := os.Open("./testdata/with_mods/terraform.tfstate.d/dev/terraform.tfstate")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		f.Close()
+	})
+	state, err := newState(resource.NewID(resource.Workspace), f)
+	require.NoError(t, err)
+
+	x := newIndex()
+	x.update(state)
+
+	search := func(t *testing.T, s string) []ResourceAddress {
+		t.Helper()
+
+		q, err := parseQuery(s)
+		require.NoError(t, err)
+		var addrs []ResourceAddress
+		for _, res := range x.search(q) {
+			addrs = append(addrs, res.Address)
+		}
+		slices.Sort(addrs)
+		return addrs
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []ResourceAddress
+	}{
+		{
+			name:  "type",
+			query: "type:random_integer",
+			want: []ResourceAddress{
+				"module.child1.random_integer.suffix",
+				"module.child2.module.child3.random_integer.suffix",
+				"module.child2.random_integer.suffix",
+				"random_integer.suffix",
+			},
+		},
+		{
+			name:  "attribute",
+			query: "result=2001",
+			want:  []ResourceAddress{"module.child1.random_integer.suffix"},
+		},
+		{
+			name:  "nested attribute",
+			query: "type:random_integer keepers.now=2024-05-17T17:23:31Z addr:child2",
+			want: []ResourceAddress{
+				"module.child2.module.child3.random_integer.suffix",
+				"module.child2.random_integer.suffix",
+			},
+		},
+		{
+			name:  "any attribute value",
+			query: "Outgoing-Sheep",
+			want:  []ResourceAddress{"random_pet.pet[1]"},
+		},
+		{
+			name:  "any attribute value or address",
+			query: "child3",
+			want: []ResourceAddress{
+				"module.child2.module.child3.random_integer.suffix",
+				"module.child2.module.child3.random_pet.pet",
+			},
+		},
+		{
+			name:  "no match",
+			query: "type:random_pet result=2001",
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, search(t, tt.query))
+		})
+	}
+
+	t.Run("get", func(t *testing.T) {
+		want := state.Resources["random_integer.suffix"]
+		got, ok := x.get(want.ID)
+		require.True(t, ok)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("replace state", func(t *testing.T) {
+		x.update(&State{WorkspaceID: state.WorkspaceID})
+
+		assert.Empty(t, x.resources)
+		assert.Empty(t, x.types)
+		assert.Empty(t, x.attributes)
+		assert.Empty(t, x.values)
+	})
+}
+
+func TestIndex_Sensitive(t *testing.T) {
+	workspaceID := resource.NewID(resource.Workspace)
+	res := &Resource{
+		ID:      resource.NewID(resource.StateResource),
+		Address: "aws_db_instance.db",
+		Type:    "aws_db_instance",
+		Attributes: map[string]any{
+			"username": "admin",
+			"password": "hunter2",
+			"tags":     map[string]any{"secret": "s3cret"},
+		},
+		Sensitive: map[string]bool{"password": true, "tags": true},
+	}
+	x := newIndex()
+	x.update(&State{
+		WorkspaceID: workspaceID,
+		Resources:   map[ResourceAddress]*Resource{res.Address: res},
+	})
+
+	for _, s := range []string{"password=hunter2", "hunter2", "tags.secret=s3cret", "s3cret"} {
+		q, err := parseQuery(s)
+		require.NoError(t, err)
+		assert.Empty(t, x.search(q), s)
+	}
+
+	q, err := parseQuery("username=admin")
+	require.NoError(t, err)
+	assert.Equal(t, []*Resource{res}, x.search(q))
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    query
+		wantErr bool
+	}{
+		{
+			name:  "terms",
+			query: "type:aws_s3_bucket Versioning[0].Enabled=false addr:module.vpc 10.2.3.4",
+			want: query{
+				{kind: typeTerm, value: "aws_s3_bucket"},
+				{kind: attributeTerm, value: "versioning.enabled=false"},
+				{kind: addressTerm, value: "module.vpc"},
+				{kind: anyTerm, value: "10.2.3.4"},
+			},
+		},
+		{
+			name:    "empty",
+			query:   "  ",
+			wantErr: true,
+		},
+		{
+			name:    "missing type",
+			query:   "type:",
+			wantErr: true,
+		},
+		{
+			name:    "missing attribute path",
+			query:   "=false",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseQuery(tt.query)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+)
+
+// lockInfoHeader precedes the lock info block in the output of a terraform
+// command that failed to acquire the state lock.
+const lockInfoHeader = "Lock Info:"
+
+// lockCreatedLayout is the format of the time at which a lock was created,
+// which is that of time.Time.String().
+const lockCreatedLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+// LockInfo describes a lock held on a workspace's state.
+type LockInfo struct {
+	ID        string
+	Path      string
+	Operation string
+	Who       string
+	Version   string
+	Created   time.Time
+}
+
+// Age returns how long the lock has been held.
+func (l LockInfo) Age(now time.Time) time.Duration {
+	if l.Created.IsZero() {
+		return 0
+	}
+	return now.Sub(l.Created)
+}
+
+// LockError is the error of a task that failed because the state is locked.
+type LockError struct {
+	LockInfo
+	// Err is the original error.
+	Err error
+}
+
+func (e *LockError) Error() string {
+	return fmt.Sprintf("state is locked by %s (lock ID %s)", e.Who, e.ID)
+}
+
+func (e *LockError) Unwrap() error {
+	return e.Err
+}
+
+// WrapLockError is a task.Spec.WrapError function that returns a *LockError
+// if the task failed because the state is locked, otherwise it returns the
+// original error.
+func WrapLockError(t *task.Task, err error) error {
+	info, ok := parseLockInfo(t.NewReader(true))
+	if !ok {
+		return err
+	}
+	return &LockError{LockInfo: info, Err: err}
+}
+
+// parseLockInfo parses the lock info block from the output of a terraform
+// command that failed to acquire the state lock. False is returned if the
+// output does not contain a lock info block.
+func parseLockInfo(r io.Reader) (LockInfo, bool) {
+	var (
+		info  LockInfo
+		found bool
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !found {
+			found = bytes.Equal(bytes.TrimSpace(line), []byte(lockInfoHeader))
+			continue
+		}
+		k, v, ok := strings.Cut(strings.TrimSpace(string(line)), ":")
+		if !ok {
+			// End of block
+			break
+		}
+		v = strings.TrimSpace(v)
+		switch k {
+		case "ID":
+			info.ID = v
+		case "Path":
+			info.Path = v
+		case "Operation":
+			info.Operation = v
+		case "Who":
+			info.Who = v
+		case "Version":
+			info.Version = v
+		case "Created":
+			// Leave the time unset if it cannot be parsed.
+			info.Created, _ = time.Parse(lockCreatedLayout, v)
+		}
+	}
+	// Without an ID the lock cannot be force-unlocked.
+	return info, found && info.ID != ""
+}
+
+// ForceUnlock creates a task spec to forcibly remove the lock with the given
+// ID from the workspace's state, i.e. `terraform force-unlock`.
+func (s *Service) ForceUnlock(workspaceID resource.ID, lockID string) (task.Spec, error) {
+	return s.createTaskSpec(workspaceID, task.Spec{
+		Blocking: true,
+		Execution: task.Execution{
+			TerraformCommand: []string{"force-unlock"},
+			Args:             []string{"-force", lockID},
+		},
+		Description: "force-unlock",
+		AfterError: func(t *task.Task) {
+			s.logger.Error("force-unlocking state", "error", t.Err, "lock", lockID)
+		},
+		AfterExited: func(t *task.Task) {
+			s.logger.Warn("force-unlocked state", "workspace", workspaceID, "lock", lockID)
+		},
+	})
+}
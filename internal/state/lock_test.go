@@ -0,0 +1,41 @@
+package state
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLockInfo(t *testing.T) {
+	f, err := os.Open("./testdata/lock_error.txt")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		f.Close()
+	})
+
+	got, ok := parseLockInfo(f)
+	require.True(t, ok)
+
+	want := LockInfo{
+		ID:        "9db590f1-b6fe-c5f2-2678-8804f089deba",
+		Path:      "pug-state/prod/terraform.tfstate",
+		Operation: "OperationTypeApply",
+		Who:       "ci@runner-42",
+		Version:   "1.8.2",
+		Created:   time.Date(2024, 5, 7, 8, 20, 11, 123456789, time.UTC),
+	}
+	// Compare times independently of their location.
+	assert.True(t, want.Created.Equal(got.Created))
+	got.Created = want.Created
+	assert.Equal(t, want, got)
+	assert.Equal(t, time.Hour, got.Age(want.Created.Add(time.Hour)))
+}
+
+func TestParseLockInfo_NotLocked(t *testing.T) {
+	_, ok := parseLockInfo(strings.NewReader("Error: Unsupported argument\n"))
+	assert.False(t, ok)
+}
@@ -0,0 +1,85 @@
+package state
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/leg100/pug/internal/resource"
+)
+
+// Output is an output in a workspace's state.
+type Output struct {
+	resource.ID
+
+	WorkspaceID resource.ID
+	Name        string
+	// Value is the JSON-encoded value of the output.
+	Value     json.RawMessage
+	Sensitive bool
+}
+
+func newOutput(workspaceID resource.ID, name string, out StateFileOutput) *Output {
+	return &Output{
+		ID:          resource.NewID(resource.StateOutput),
+		WorkspaceID: workspaceID,
+		Name:        name,
+		Value:       out.Value,
+		Sensitive:   out.Sensitive,
+	}
+}
+
+func (o *Output) String() string {
+	return o.Name
+}
+
+// Plain renders the value of the output in a form suitable for copying: a
+// string value is unquoted, and any other value is rendered as compact JSON.
+func (o *Output) Plain() string {
+	var s string
+	if err := json.Unmarshal(o.Value, &s); err == nil {
+		return s
+	}
+	var b bytes.Buffer
+	if err := json.Compact(&b, o.Value); err != nil {
+		return string(o.Value)
+	}
+	return b.String()
+}
+
+// SortOutputs sorts outputs by name.
+func SortOutputs(i, j *Output) int {
+	return cmp.Compare(i.Name, j.Name)
+}
+
+// ExportOutputsJSON exports outputs as a JSON object mapping output names to
+// values.
+func ExportOutputsJSON(outputs []*Output) ([]byte, error) {
+	m := make(map[string]json.RawMessage, len(outputs))
+	for _, out := range outputs {
+		m[out.Name] = out.Value
+	}
+	body, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("exporting outputs: %w", err)
+	}
+	return append(body, '\n'), nil
+}
+
+// ExportOutputsDotenv exports outputs in the dotenv format, one NAME="value"
+// line per output, in order of name. Names are upper-cased, and values are
+// rendered as with Output.Plain.
+func ExportOutputsDotenv(outputs []*Output) []byte {
+	outputs = slices.Clone(outputs)
+	slices.SortFunc(outputs, SortOutputs)
+
+	var b bytes.Buffer
+	for _, out := range outputs {
+		fmt.Fprintf(&b, "%s=%s\n", strings.ToUpper(out.Name), strconv.Quote(out.Plain()))
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,47 @@
+package state
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutput_Plain(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"string", `"lb-123.eu-west-2.elb.amazonaws.com"`, "lb-123.eu-west-2.elb.amazonaws.com"},
+		{"number", `5432`, "5432"},
+		{"object", "{\n  \"host\": \"db\",\n  \"port\": 5432\n}", `{"host":"db","port":5432}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &Output{Name: tt.name, Value: json.RawMessage(tt.value)}
+			assert.Equal(t, tt.want, out.Plain())
+		})
+	}
+}
+
+func TestExportOutputs(t *testing.T) {
+	outputs := []*Output{
+		{Name: "lb_dns_name", Value: json.RawMessage(`"lb-123.elb.amazonaws.com"`)},
+		{Name: "db", Value: json.RawMessage(`{"port":5432}`)},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		got, err := ExportOutputsJSON(outputs)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"lb_dns_name":"lb-123.elb.amazonaws.com","db":{"port":5432}}`, string(got))
+	})
+
+	t.Run("dotenv", func(t *testing.T) {
+		want := `DB="{\"port\":5432}"
+LB_DNS_NAME="lb-123.elb.amazonaws.com"
+`
+		assert.Equal(t, want, string(ExportOutputsDotenv(outputs)))
+	})
+}
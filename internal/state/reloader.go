@@ -2,6 +2,7 @@ package state
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/leg100/pug/internal/resource"
 	"github.com/leg100/pug/internal/task"
@@ -19,6 +20,8 @@ func (r *reloader) Reload(workspaceID resource.ID) (task.Spec, error) {
 			TerraformCommand: []string{"state", "pull"},
 		},
 		JSON: true,
+		// Reloading state is interactive, so run it ahead of bulk tasks.
+		Priority: task.HighPriority,
 		BeforeExited: func(t *task.Task) (task.Summary, error) {
 			state, err := newState(workspaceID, t.NewReader(false))
 			if err != nil {
@@ -35,8 +38,23 @@ func (r *reloader) Reload(workspaceID resource.ID) (task.Spec, error) {
 			if err == nil && old.Serial == state.Serial {
 				return newReloadSummary(old, state), nil
 			}
+			// Retain and index state before adding it to the cache, so that
+			// the history and index are up to date when the cache publishes
+			// an event.
+			r.history.add(state, time.Now())
+			r.index.update(state)
 			// Add/replace state in cache.
 			r.cache.Add(workspaceID, state)
+			if old != nil && old.Serial >= 0 && state.Serial >= 0 {
+				diff := NewDiff(old, state)
+				r.logger.Info("state updated",
+					"workspace", workspaceID,
+					"serial", state.Serial,
+					"added", len(diff.Added),
+					"changed", len(diff.Changed),
+					"removed", len(diff.Removed),
+				)
+			}
 			return newReloadSummary(old, state), nil
 		},
 	})
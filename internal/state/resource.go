@@ -2,7 +2,9 @@ package state
 
 import (
 	"encoding/json"
+	"fmt"
 
+	"github.com/leg100/pug/internal/attribute"
 	"github.com/leg100/pug/internal/resource"
 )
 
@@ -12,24 +14,101 @@ type Resource struct {
 
 	WorkspaceID resource.ID
 	Address     ResourceAddress
-	Attributes  map[string]any
-	Tainted     bool
+	// Module is the module call path, e.g. module.a.module.b, or empty for
+	// the root module.
+	Module     string
+	Type       string
+	Attributes map[string]any
+	// Sensitive are the paths of sensitive attributes.
+	Sensitive map[string]bool
+	Tainted   bool
 }
 
 func (r *Resource) String() string {
 	return string(r.Address)
 }
 
-func newResource(workspaceID resource.ID, addr ResourceAddress, attrs json.RawMessage) (*Resource, error) {
+func newResource(workspaceID resource.ID, addr ResourceAddress, module, typ string, instance StateFileResourceInstance) (*Resource, error) {
 	res := &Resource{
 		ID:          resource.NewID(resource.StateResource),
 		WorkspaceID: workspaceID,
 		Address:     addr,
+		Module:      module,
+		Type:        typ,
 	}
-	if err := json.Unmarshal(attrs, &res.Attributes); err != nil {
+	if err := json.Unmarshal(instance.Attributes, &res.Attributes); err != nil {
 		return nil, err
 	}
+	res.Sensitive = sensitivePaths(instance.SensitiveAttributes)
 	return res, nil
 }
 
+// sensitivePaths decodes the paths of sensitive attributes. If a path cannot
+// be decoded then all attributes are treated as sensitive, rather than risk
+// revealing a sensitive value.
+func sensitivePaths(raw json.RawMessage) map[string]bool {
+	if len(raw) == 0 {
+		return nil
+	}
+	var paths [][]StateFilePathStep
+	if err := json.Unmarshal(raw, &paths); err != nil {
+		return map[string]bool{"": true}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	sensitive := make(map[string]bool, len(paths))
+	for _, steps := range paths {
+		path, err := sensitivePath(steps)
+		if err != nil {
+			return map[string]bool{"": true}
+		}
+		sensitive[path] = true
+	}
+	return sensitive
+}
+
+// sensitivePath converts the steps in the path to a sensitive attribute into
+// the form of the paths of flattened attributes, e.g. tags.Name or
+// ingress[0].port.
+func sensitivePath(steps []StateFilePathStep) (string, error) {
+	var path string
+	for _, step := range steps {
+		switch step.Type {
+		case "get_attr":
+			var name string
+			if err := json.Unmarshal(step.Value, &name); err != nil {
+				return "", err
+			}
+			path = attribute.Join(path, name)
+		case "index":
+			var key struct {
+				Value any
+			}
+			if err := json.Unmarshal(step.Value, &key); err != nil {
+				return "", err
+			}
+			switch v := key.Value.(type) {
+			case float64:
+				path = attribute.Index(path, int(v))
+			case string:
+				path = attribute.Join(path, v)
+			default:
+				return "", fmt.Errorf("invalid index: %s", step.Value)
+			}
+		default:
+			return "", fmt.Errorf("invalid path step type: %s", step.Type)
+		}
+	}
+	return path, nil
+}
+
+// values returns the resource's attribute values for comparison.
+func (r *Resource) values() attribute.Values {
+	return attribute.Values{
+		Leaves:    attribute.Flatten(r.Attributes),
+		Sensitive: r.Sensitive,
+	}
+}
+
 type ResourceAddress string
@@ -17,6 +17,10 @@ type Service struct {
 
 	// Table mapping workspace IDs to states
 	cache *resource.Table[*State]
+	// Recent states of each workspace
+	history *history
+	// Index of resources in the current state of each workspace
+	index *index
 
 	*pubsub.Broker[*State]
 	*reloader
@@ -36,6 +40,8 @@ func NewService(opts ServiceOptions) *Service {
 		workspaces: opts.Workspaces,
 		tasks:      opts.Tasks,
 		cache:      resource.NewTable(broker),
+		history:    newHistory(),
+		index:      newIndex(),
 		Broker:     broker,
 		logger:     opts.Logger,
 	}
@@ -49,17 +55,12 @@ func (s *Service) Get(workspaceID resource.ID) (*State, error) {
 }
 
 // GetResource retrieves a state resource.
-//
-// TODO: this is massively inefficient
 func (s *Service) GetResource(resourceID resource.ID) (*Resource, error) {
-	for _, state := range s.cache.List() {
-		for _, res := range state.Resources {
-			if res.ID == resourceID {
-				return res, nil
-			}
-		}
+	res, ok := s.index.get(resourceID)
+	if !ok {
+		return nil, resource.ErrNotFound
 	}
-	return nil, resource.ErrNotFound
+	return res, nil
 }
 
 func (s *Service) Delete(workspaceID resource.ID, addrs ...ResourceAddress) (task.Spec, error) {
@@ -144,6 +145,8 @@ func (s *Service) createTaskSpec(workspaceID resource.ID, opts task.Spec) (task.
 	opts.WorkspaceID = &ws.ID
 	opts.Env = []string{ws.TerraformEnv()}
 	opts.Path = mod.Path
+	// Any task on the state may fail because the state is locked.
+	opts.WrapError = WrapLockError
 
 	return opts, nil
 }
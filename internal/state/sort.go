@@ -1,5 +1,7 @@
 package state
 
+import "cmp"
+
 func Sort(i, j *Resource) int {
 	if i.Address < j.Address {
 		return -1
@@ -7,3 +9,11 @@ func Sort(i, j *Resource) int {
 		return 1
 	}
 }
+
+// SortHistory sorts states, newest first.
+func SortHistory(i, j *State) int {
+	if n := cmp.Compare(j.Serial, i.Serial); n != 0 {
+		return n
+	}
+	return cmp.Compare(j.ID.Serial, i.ID.Serial)
+}
@@ -16,6 +16,7 @@ type State struct {
 
 	WorkspaceID      resource.ID
 	Resources        map[ResourceAddress]*Resource
+	Outputs          map[string]*Output
 	Serial           int64
 	TerraformVersion string
 	Lineage          string
@@ -74,7 +75,7 @@ func newState(workspaceID resource.ID, r io.Reader) (*State, error) {
 
 			addr := ResourceAddress(b.String())
 			var err error
-			m[addr], err = newResource(workspaceID, addr, instance.Attributes)
+			m[addr], err = newResource(workspaceID, addr, res.Module, res.Type, instance)
 			if err != nil {
 				return nil, fmt.Errorf("decoding resource %s: %w", addr, err)
 			}
@@ -85,6 +86,11 @@ func newState(workspaceID resource.ID, r io.Reader) (*State, error) {
 	}
 	state.Resources = m
 
+	state.Outputs = make(map[string]*Output, len(file.Outputs))
+	for name, out := range file.Outputs {
+		state.Outputs[name] = newOutput(workspaceID, name, out)
+	}
+
 	return state, nil
 }
 
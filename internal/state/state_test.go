@@ -47,6 +47,12 @@ func TestState(t *testing.T) {
 		assert.Equal(t, wantAttrs, got.Resources["random_pet.pet[3]"].Attributes)
 
 		assert.True(t, got.Resources["random_pet.pet[3]"].Tainted)
+
+		assert.Len(t, got.Outputs, 3)
+		if assert.Contains(t, got.Outputs, "pet1") {
+			assert.Equal(t, "outgoing-sheep", got.Outputs["pet1"].Plain())
+			assert.False(t, got.Outputs["pet1"].Sensitive)
+		}
 	})
 
 	t.Run("empty", func(t *testing.T) {
@@ -0,0 +1,205 @@
+package state
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/leg100/pug/internal/resource"
+)
+
+// NodeKind is the kind of node in a tree of state resources.
+type NodeKind int
+
+const (
+	// ModuleNode is a module call, e.g. module.a.module.b
+	ModuleNode NodeKind = iota
+	// TypeNode groups the resources of a type in a module, e.g. aws_instance
+	TypeNode
+	// ResourceNode is a resource
+	ResourceNode
+)
+
+// Node is a node in a tree of a state's resources, grouped by module call
+// path and then by resource type.
+type Node struct {
+	resource.ID
+
+	Kind NodeKind
+	// Key uniquely identifies the node in the tree, and is the same for the
+	// equivalent node in a tree of a later state.
+	Key string
+	// Name is the name of the node relative to its parent, e.g. module.b,
+	// aws_instance, or aws_instance.web[0].
+	Name string
+	// Depth is the number of ancestors of the node, excluding the root.
+	Depth    int
+	Children []*Node
+	// Resource is only set for a ResourceNode.
+	Resource *Resource
+	// Count is the number of resources in the node and its descendants.
+	Count int
+	// Order is the position of the node in a depth-first walk of the tree.
+	Order int
+}
+
+func (n *Node) String() string {
+	return n.Name
+}
+
+// Resources returns the resources in the node and its descendants.
+func (n *Node) Resources() []*Resource {
+	if n.Resource != nil {
+		return []*Resource{n.Resource}
+	}
+	var resources []*Resource
+	for _, child := range n.Children {
+		resources = append(resources, child.Resources()...)
+	}
+	return resources
+}
+
+// Tree is a tree of a state's resources. The root is the root module.
+type Tree struct {
+	Root *Node
+
+	nodes map[string]*Node
+}
+
+// NewTree constructs a tree of the state's resources. If previous is non-nil
+// then nodes retain the IDs of their equivalents in the previous tree.
+func NewTree(state *State, previous *Tree) *Tree {
+	t := &Tree{nodes: make(map[string]*Node)}
+	t.Root = t.node(previous, ModuleNode, "", "", nil)
+	// Children of the root have a depth of zero.
+	t.Root.Depth = -1
+	for _, res := range state.Resources {
+		parent := t.Root
+		for _, call := range moduleCalls(res.Module) {
+			parent = t.node(previous, ModuleNode, joinKey(parent.Key, call), call, parent)
+		}
+		// Address relative to the module
+		name := strings.TrimPrefix(string(res.Address), res.Module+".")
+		typ := res.Type
+		if strings.HasPrefix(name, "data.") {
+			typ = "data." + typ
+		}
+		parent = t.node(previous, TypeNode, joinKey(parent.Key, typ), typ, parent)
+		leaf := t.node(previous, ResourceNode, string(res.Address), name, parent)
+		leaf.Resource = res
+	}
+	t.Root.count()
+	t.Root.sort()
+	return t
+}
+
+// node retrieves the node with the given key, creating it if it doesn't exist.
+func (t *Tree) node(previous *Tree, kind NodeKind, key, name string, parent *Node) *Node {
+	if n, ok := t.nodes[nodeKey(kind, key)]; ok {
+		return n
+	}
+	n := &Node{Kind: kind, Key: key, Name: name}
+	if previous != nil {
+		if existing, ok := previous.nodes[nodeKey(kind, key)]; ok {
+			n.ID = existing.ID
+		}
+	}
+	if n.ID == (resource.ID{}) {
+		n.ID = resource.NewID(resource.StateNode)
+	}
+	if parent != nil {
+		n.Depth = parent.Depth + 1
+		parent.Children = append(parent.Children, n)
+	}
+	t.nodes[nodeKey(kind, key)] = n
+	return n
+}
+
+func joinKey(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// nodeKey distinguishes the keys of different kinds of node, e.g. a module
+// key and a type key.
+func nodeKey(kind NodeKind, key string) string {
+	return fmt.Sprintf("%d:%s", kind, key)
+}
+
+// Walk walks the tree depth-first, setting the order of each node, and
+// returns the nodes beneath the root, skipping the descendants of collapsed
+// nodes.
+func (t *Tree) Walk(collapsed func(*Node) bool) []*Node {
+	var (
+		nodes []*Node
+		walk  func(*Node)
+	)
+	walk = func(n *Node) {
+		for _, child := range n.Children {
+			child.Order = len(nodes)
+			nodes = append(nodes, child)
+			if !collapsed(child) {
+				walk(child)
+			}
+		}
+	}
+	walk(t.Root)
+	return nodes
+}
+
+func (n *Node) count() int {
+	if n.Kind == ResourceNode {
+		n.Count = 1
+		return 1
+	}
+	n.Count = 0
+	for _, child := range n.Children {
+		n.Count += child.count()
+	}
+	return n.Count
+}
+
+// sort sorts the descendants of the node: resource types before module calls,
+// and then by name.
+func (n *Node) sort() {
+	slices.SortFunc(n.Children, func(a, b *Node) int {
+		if a.Kind != b.Kind {
+			// Module nodes last
+			if a.Kind == ModuleNode {
+				return 1
+			}
+			if b.Kind == ModuleNode {
+				return -1
+			}
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	for _, child := range n.Children {
+		child.sort()
+	}
+}
+
+// moduleCalls splits a module path into its module calls, e.g.
+// module.a.module.b becomes module.a and module.b.
+func moduleCalls(path string) []string {
+	if path == "" {
+		return nil
+	}
+	var calls []string
+	for {
+		i := strings.Index(path, ".module.")
+		if i < 0 {
+			return append(calls, path)
+		}
+		calls = append(calls, path[:i])
+		path = path[i+1:]
+	}
+}
+
+// SortNodes sorts nodes by the order in which they were walked.
+func SortNodes(i, j *Node) int {
+	return cmp.Compare(i.Order, j.Order)
+}
@@ -0,0 +1,92 @@
+package state
+
+import (
+	"os"
+	"testing"
+
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTree(t *testing.T) {
+	f, err := os.Open("./testdata/with_mods/terraform.tfstate.d/dev/terraform.tfstate")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		f.Close()
+	})
+	state, err := newState(resource.NewID(resource.Workspace), f)
+	require.NoError(t, err)
+
+	tree := NewTree(state, nil)
+	assert.Equal(t, 17, tree.Root.Count)
+
+	type node struct {
+		Name  string
+		Depth int
+		Count int
+	}
+	walk := func(tree *Tree, collapsed func(*Node) bool) []node {
+		var got []node
+		for _, n := range tree.Walk(collapsed) {
+			got = append(got, node{n.Name, n.Depth, n.Count})
+		}
+		return got
+	}
+
+	t.Run("collapse types", func(t *testing.T) {
+		got := walk(tree, func(n *Node) bool { return n.Kind == TypeNode })
+		want := []node{
+			{"random_integer", 0, 1},
+			{"random_pet", 0, 10},
+			{"module.child1", 0, 2},
+			{"random_integer", 1, 1},
+			{"random_pet", 1, 1},
+			{"module.child2", 0, 4},
+			{"random_integer", 1, 1},
+			{"random_pet", 1, 1},
+			{"module.child3", 1, 2},
+			{"random_integer", 2, 1},
+			{"random_pet", 2, 1},
+		}
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("expand all", func(t *testing.T) {
+		nodes := tree.Walk(func(*Node) bool { return false })
+		require.Len(t, nodes, 11+17)
+		assert.Equal(t, "random_pet.pet[0]", nodes[3].Name)
+		assert.Equal(t, ResourceAddress("random_pet.pet[0]"), nodes[3].Resource.Address)
+		// child3 module resources
+		assert.Equal(t, "random_integer.suffix", nodes[len(nodes)-3].Name)
+		assert.Equal(t, ResourceAddress("module.child2.module.child3.random_integer.suffix"), nodes[len(nodes)-3].Resource.Address)
+	})
+
+	t.Run("resources", func(t *testing.T) {
+		nodes := tree.Walk(func(*Node) bool { return true })
+		// module.child2
+		var addrs []ResourceAddress
+		for _, res := range nodes[3].Resources() {
+			addrs = append(addrs, res.Address)
+		}
+		assert.ElementsMatch(t, []ResourceAddress{
+			"module.child2.random_integer.suffix",
+			"module.child2.random_pet.pet",
+			"module.child2.module.child3.random_integer.suffix",
+			"module.child2.module.child3.random_pet.pet",
+		}, addrs)
+	})
+
+	t.Run("retain IDs", func(t *testing.T) {
+		next := NewTree(state, tree)
+		for key, n := range tree.nodes {
+			assert.Equal(t, n.ID, next.nodes[key].ID, key)
+		}
+	})
+}
+
+func TestModuleCalls(t *testing.T) {
+	assert.Nil(t, moduleCalls(""))
+	assert.Equal(t, []string{"module.a"}, moduleCalls("module.a"))
+	assert.Equal(t, []string{`module.a["x"]`, "module.b[0]"}, moduleCalls(`module.a["x"].module.b[0]`))
+}
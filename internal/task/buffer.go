@@ -2,32 +2,99 @@ package task
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"sync"
 )
 
+// outputDirName is the name of the directory in the data directory in which
+// task output is written.
+const outputDirName = "output"
+
+// maxStreamChunk is the maximum number of bytes sent in a single chunk to a
+// streamer.
+const maxStreamChunk = 64 * 1024
+
+// buffer stores the output of a task. Output is held in memory until it
+// exceeds the buffer's memory limit, at which point it is flushed to a segment
+// file on disk, leaving only a small tail of output in memory.
 type buffer struct {
-	buf *bytes.Buffer
+	// dir is the directory in which segments are written. If empty then the
+	// buffer is memory-only.
+	dir string
+	// maxMemory is the maximum number of bytes to hold in memory before
+	// flushing them to a segment. Ignored if dir is empty.
+	maxMemory int
+
+	// segments are output flushed to disk, in order of writing.
+	segments []segment
+	// flushed is the total number of bytes written to segments
+	flushed int
+	// tail is output yet to be flushed to a segment.
+	tail *bytes.Buffer
 
 	avail chan struct{}
 	mu    sync.Mutex
 }
 
+// segment is a file containing a contiguous portion of buffer output.
+type segment struct {
+	path string
+	// offset is the position of the first byte of the segment within the
+	// buffer.
+	offset int
+	size   int
+}
+
 func newBuffer() *buffer {
 	return &buffer{
-		buf:   new(bytes.Buffer),
+		tail:  new(bytes.Buffer),
 		avail: make(chan struct{}, 1),
 	}
 }
 
+// newDiskBuffer constructs a buffer that flushes output to segment files in
+// dir once more than maxMemory bytes are held in memory.
+func newDiskBuffer(dir string, maxMemory int) *buffer {
+	buf := newBuffer()
+	buf.dir = dir
+	buf.maxMemory = maxMemory
+	return buf
+}
+
+// newFileBuffer constructs a closed buffer from the contents of an existing
+// file, without reading the file into memory.
+func newFileBuffer(path string) (*buffer, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	buf := newBuffer()
+	if size := int(info.Size()); size > 0 {
+		buf.segments = []segment{{path: path, size: size}}
+		buf.flushed = size
+	}
+	buf.Close()
+	return buf, nil
+}
+
 func (b *buffer) Write(p []byte) (int, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	n, err := b.buf.Write(p)
+	n, err := b.tail.Write(p)
 	if err != nil {
 		return n, err
 	}
+	if b.dir != "" && b.tail.Len() > b.maxMemory {
+		if err := b.flush(); err != nil {
+			// Failing to flush to disk should not fail the task, so stop
+			// flushing and hold all further output in memory instead.
+			b.dir = ""
+		}
+	}
 	// Let streamers know there are now available bytes to be read.
 	select {
 	case b.avail <- struct{}{}:
@@ -36,14 +103,114 @@ func (b *buffer) Write(p []byte) (int, error) {
 	return n, nil
 }
 
-// NewReader returns a copy of the buffer to read from.
-func (b *buffer) NewReader() io.Reader {
+// flush writes the tail to a new segment. The caller must hold the lock.
+func (b *buffer) flush() error {
+	if err := os.MkdirAll(b.dir, 0o700); err != nil {
+		return err
+	}
+	seg := segment{
+		path:   filepath.Join(b.dir, fmt.Sprintf("%d.seg", len(b.segments))),
+		offset: b.flushed,
+		size:   b.tail.Len(),
+	}
+	if err := os.WriteFile(seg.path, b.tail.Bytes(), 0o600); err != nil {
+		return err
+	}
+	b.segments = append(b.segments, seg)
+	b.flushed += seg.size
+	b.tail.Reset()
+	return nil
+}
+
+// size returns the number of bytes written to the buffer.
+func (b *buffer) size() int {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	r := new(bytes.Buffer)
-	r.Write(b.buf.Bytes())
-	return r
+	return b.flushed + b.tail.Len()
+}
+
+// readAt reads up to len(p) bytes into p starting at offset off, returning the
+// number of bytes read.
+func (b *buffer) readAt(p []byte, off int) (int, error) {
+	var n int
+	for n < len(p) {
+		b.mu.Lock()
+		if off >= b.flushed {
+			// Read from tail
+			if off-b.flushed >= b.tail.Len() {
+				b.mu.Unlock()
+				return n, io.EOF
+			}
+			copied := copy(p[n:], b.tail.Bytes()[off-b.flushed:])
+			b.mu.Unlock()
+			return n + copied, nil
+		}
+		// Read from segment. Segments are immutable, so the lock can be
+		// released before reading the file.
+		seg := b.findSegment(off)
+		b.mu.Unlock()
+
+		read, err := seg.readAt(p[n:], off-seg.offset)
+		n += read
+		off += read
+		if err != nil && err != io.EOF {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// findSegment finds the segment containing the given offset. The caller must
+// hold the lock and ensure the offset is within the flushed segments.
+func (b *buffer) findSegment(off int) segment {
+	for _, seg := range b.segments {
+		if off < seg.offset+seg.size {
+			return seg
+		}
+	}
+	return b.segments[len(b.segments)-1]
+}
+
+func (s segment) readAt(p []byte, off int) (int, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return f.ReadAt(p[:min(len(p), s.size-off)], int64(off))
+}
+
+// NewReader returns a reader of what has been written to the buffer thus far.
+// Each reader reads from the beginning of the buffer independently of other
+// readers.
+func (b *buffer) NewReader() io.Reader {
+	return &bufferReader{buf: b, limit: b.size()}
+}
+
+type bufferReader struct {
+	buf *buffer
+	// offset of next byte to read
+	offset int
+	// limit is the size of the buffer when the reader was created; the reader
+	// reads no further than the limit.
+	limit int
+}
+
+func (r *bufferReader) Read(p []byte) (int, error) {
+	if r.offset >= r.limit {
+		return 0, io.EOF
+	}
+	if remaining := r.limit - r.offset; len(p) > remaining {
+		p = p[:remaining]
+	}
+	n, err := r.buf.readAt(p, r.offset)
+	r.offset += n
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
 }
 
 // Stream buffer as it is written to. The return channel is closed when the
@@ -54,25 +221,24 @@ func (b *buffer) Stream() <-chan []byte {
 		ch     = make(chan []byte)
 	)
 
-	copyBytes := func() []byte {
-		b.mu.Lock()
-		byts := b.buf.Bytes()
-		size := b.buf.Len() - offset
-		dst := make([]byte, size)
-		offset += copy(dst, byts[offset:])
-		b.mu.Unlock()
-		return dst
+	// send bytes written since the last send, in chunks.
+	sendBytes := func() {
+		for size := b.size(); offset < size; {
+			dst := make([]byte, min(size-offset, maxStreamChunk))
+			n, err := b.readAt(dst, offset)
+			if n == 0 || (err != nil && err != io.EOF) {
+				return
+			}
+			offset += n
+			ch <- dst[:n]
+		}
 	}
 
 	go func() {
-		if b := copyBytes(); len(b) > 0 {
-			ch <- b
-		}
+		sendBytes()
 		for {
 			_, ok := <-b.avail
-			if b := copyBytes(); len(b) > 0 {
-				ch <- b
-			}
+			sendBytes()
 			if !ok {
 				close(ch)
 				return
@@ -1,6 +1,9 @@
 package task
 
 import (
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -53,3 +56,57 @@ func TestBuffer_Stream(t *testing.T) {
 	got = <-ch
 	assert.Nil(t, got)
 }
+
+func TestBuffer_Disk(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	buf := newDiskBuffer(dir, 4)
+
+	_, err := buf.Write([]byte("hello"))
+	require.NoError(t, err)
+	_, err = buf.Write([]byte(" wo"))
+	require.NoError(t, err)
+	_, err = buf.Write([]byte("rld"))
+	require.NoError(t, err)
+
+	// "hello" and " world" exceed the memory limit and are flushed to
+	// segments; nothing remains in memory.
+	assert.Len(t, buf.segments, 2)
+	assert.Equal(t, 0, buf.tail.Len())
+
+	got, err := io.ReadAll(buf.NewReader())
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(got))
+
+	// Reader should only read what was written when it was created.
+	r := buf.NewReader()
+	_, err = buf.Write([]byte("!"))
+	require.NoError(t, err)
+	got, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(got))
+
+	// Stream should send everything written, including output on disk.
+	ch := buf.Stream()
+	buf.Close()
+	var streamed []byte
+	for b := range ch {
+		streamed = append(streamed, b...)
+	}
+	assert.Equal(t, "hello world!", string(streamed))
+}
+
+func TestBuffer_File(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "output")
+	require.NoError(t, os.WriteFile(path, []byte("hello world"), 0o600))
+
+	buf, err := newFileBuffer(path)
+	require.NoError(t, err)
+
+	got, err := io.ReadAll(buf.NewReader())
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(got))
+}
@@ -0,0 +1,135 @@
+package task
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/resource"
+)
+
+// CapKind is the kind of attribute upon which a concurrency cap is keyed.
+type CapKind string
+
+const (
+	// BackendCap caps tasks belonging to modules with a backend type.
+	BackendCap CapKind = "backend"
+	// PathCap caps tasks belonging to modules with a path matching a glob.
+	PathCap CapKind = "path"
+	// LabelCap caps tasks belonging to modules with a label.
+	LabelCap CapKind = "label"
+)
+
+// ConcurrencyCap limits the number of tasks that may run concurrently, over
+// and above the global maximum number of tasks.
+type ConcurrencyCap struct {
+	Kind CapKind
+	// Value is the backend type, module path glob, or label, depending on
+	// the kind of cap.
+	Value string
+	// Max is the maximum number of matching tasks that may run concurrently.
+	Max int
+}
+
+// ParseConcurrencyCap parses a concurrency cap of the form <kind>:<value>=<max>,
+// e.g. backend:s3=3, path:prod/**=1, or label:shared-account=2.
+func ParseConcurrencyCap(s string) (ConcurrencyCap, error) {
+	key, maxStr, ok := internal.CutLast(s, "=")
+	if !ok {
+		return ConcurrencyCap{}, fmt.Errorf("invalid concurrency cap: %s: missing =<max>", s)
+	}
+	kind, value, ok := strings.Cut(key, ":")
+	if !ok || value == "" {
+		return ConcurrencyCap{}, fmt.Errorf("invalid concurrency cap: %s: missing <kind>:<value>", s)
+	}
+	c := ConcurrencyCap{Kind: CapKind(kind), Value: value}
+	switch c.Kind {
+	case BackendCap, LabelCap:
+	case PathCap:
+		if _, err := path.Match(value, ""); err != nil {
+			return ConcurrencyCap{}, fmt.Errorf("invalid concurrency cap: %s: %w", s, err)
+		}
+	default:
+		return ConcurrencyCap{}, fmt.Errorf("invalid concurrency cap: %s: kind must be one of backend, path, or label", s)
+	}
+	var err error
+	c.Max, err = strconv.Atoi(maxStr)
+	if err != nil || c.Max < 1 {
+		return ConcurrencyCap{}, fmt.Errorf("invalid concurrency cap: %s: max must be a positive integer", s)
+	}
+	return c, nil
+}
+
+func (c ConcurrencyCap) String() string {
+	return fmt.Sprintf("%s:%s=%d", c.Kind, c.Value, c.Max)
+}
+
+// ModuleLabel assigns a label to modules with a path matching a glob.
+type ModuleLabel struct {
+	Name string
+	Glob string
+}
+
+// ParseModuleLabel parses a module label of the form <name>=<glob>, e.g.
+// shared-account=accounts/shared/**.
+func ParseModuleLabel(s string) (ModuleLabel, error) {
+	name, glob, ok := strings.Cut(s, "=")
+	if !ok || name == "" || glob == "" {
+		return ModuleLabel{}, fmt.Errorf("invalid module label: %s: must be of the form <name>=<glob>", s)
+	}
+	if _, err := path.Match(glob, ""); err != nil {
+		return ModuleLabel{}, fmt.Errorf("invalid module label: %s: %w", s, err)
+	}
+	return ModuleLabel{Name: name, Glob: glob}, nil
+}
+
+// capMatcher determines which concurrency caps apply to a task.
+type capMatcher struct {
+	caps   []ConcurrencyCap
+	labels []ModuleLabel
+	// backend retrieves the backend type of a module.
+	backend func(moduleID resource.ID) string
+}
+
+// match returns the indices of the caps that apply to the task.
+func (m *capMatcher) match(t *Task) []int {
+	if len(m.caps) == 0 || t.ModuleID == nil {
+		return nil
+	}
+	// Tasks belonging to a module have their path set to the module path.
+	modulePath := t.Spec.Path
+	var (
+		backend string
+		labels  []string
+	)
+	if m.backend != nil {
+		backend = m.backend(*t.ModuleID)
+	}
+	for _, l := range m.labels {
+		if internal.MatchGlob(l.Glob, modulePath) {
+			labels = append(labels, l.Name)
+		}
+	}
+	var matched []int
+	for i, c := range m.caps {
+		switch c.Kind {
+		case BackendCap:
+			if backend != c.Value {
+				continue
+			}
+		case PathCap:
+			if !internal.MatchGlob(c.Value, modulePath) {
+				continue
+			}
+		case LabelCap:
+			if !slices.Contains(labels, c.Value) {
+				continue
+			}
+		}
+		matched = append(matched, i)
+	}
+	return matched
+}
@@ -0,0 +1,88 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConcurrencyCap(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		s       string
+		want    ConcurrencyCap
+		wantErr bool
+	}{
+		{"backend:s3=3", ConcurrencyCap{Kind: BackendCap, Value: "s3", Max: 3}, false},
+		{"path:prod/**=1", ConcurrencyCap{Kind: PathCap, Value: "prod/**", Max: 1}, false},
+		{"label:shared-account=2", ConcurrencyCap{Kind: LabelCap, Value: "shared-account", Max: 2}, false},
+		{"backend:s3", ConcurrencyCap{}, true},
+		{"s3=3", ConcurrencyCap{}, true},
+		{"color:red=3", ConcurrencyCap{}, true},
+		{"path:[=1", ConcurrencyCap{}, true},
+		{"backend:s3=0", ConcurrencyCap{}, true},
+		{"backend:s3=many", ConcurrencyCap{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseConcurrencyCap(tt.s)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.s, got.String())
+		})
+	}
+}
+
+func TestParseModuleLabel(t *testing.T) {
+	t.Parallel()
+
+	got, err := ParseModuleLabel("shared-account=accounts/shared/**")
+	require.NoError(t, err)
+	assert.Equal(t, ModuleLabel{Name: "shared-account", Glob: "accounts/shared/**"}, got)
+
+	_, err = ParseModuleLabel("shared-account")
+	assert.Error(t, err)
+
+	_, err = ParseModuleLabel("shared-account=[")
+	assert.Error(t, err)
+}
+
+func TestCapMatcher_match(t *testing.T) {
+	t.Parallel()
+
+	m := capMatcher{
+		caps: []ConcurrencyCap{
+			{Kind: BackendCap, Value: "s3", Max: 1},
+			{Kind: PathCap, Value: "prod/**", Max: 1},
+			{Kind: LabelCap, Value: "shared", Max: 1},
+		},
+		labels: []ModuleLabel{{Name: "shared", Glob: "*/shared"}},
+		backend: func(resource.ID) string {
+			return "s3"
+		},
+	}
+	modID := resource.NewID(resource.Module)
+
+	tests := []struct {
+		name string
+		task *Task
+		want []int
+	}{
+		{"no module", &Task{}, nil},
+		{"backend", &Task{ModuleID: &modID, Spec: Spec{Path: "dev/vpc"}}, []int{0}},
+		{"backend and path", &Task{ModuleID: &modID, Spec: Spec{Path: "prod/vpc"}}, []int{0, 1}},
+		{"backend, path, and label", &Task{ModuleID: &modID, Spec: Spec{Path: "prod/shared"}}, []int{0, 1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, m.match(tt.task))
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package task
 
 import (
+	"cmp"
 	"context"
+	"slices"
 
 	"github.com/leg100/pug/internal/resource"
 )
@@ -14,8 +16,12 @@ import (
 // (c) if it belongs to a module then no other task has "blocked" that module
 // (d) if it has dependencies on other tasks then those tasks have all finished
 // successfully.
+// (e) if it is a blocking task then no older blocking task belonging to the same
+// workspace, or module, is pending.
 //
-// Otherwise the enqueuer leaves the task in a pending state.
+// Otherwise the enqueuer leaves the task in a pending state. Held tasks are
+// never enqueued, and while the queue is paused only immediate tasks are
+// enqueued.
 type enqueuer struct {
 	tasks enqueuerTaskService
 }
@@ -24,6 +30,7 @@ type enqueuerTaskService interface {
 	taskLister
 
 	Get(taskID resource.ID) (*Task, error)
+	Paused() bool
 }
 
 func StartEnqueuer(tasks *Service) {
@@ -65,19 +72,51 @@ func (e *enqueuer) enqueuable() []*Task {
 			}
 		}
 	}
-	// Retrieve pending tasks in order of oldest first.
+	// Retrieve pending tasks, oldest first.
 	pending := e.tasks.List(ListOptions{
 		Status: []Status{Pending},
 		Oldest: true,
 	})
+	// Blocking tasks change state, so regardless of priority they must be
+	// enqueued in the order they were queued relative to other blocking tasks
+	// belonging to the same workspace, or module if they don't belong to a
+	// workspace. firstBlocking maps the workspace or module ID to the first
+	// such task.
+	firstBlocking := make(map[resource.ID]*Task)
+	byPosition := slices.Clone(pending)
+	slices.SortStableFunc(byPosition, func(i, j *Task) int {
+		return cmp.Compare(i.position, j.position)
+	})
+	for _, t := range byPosition {
+		if !t.Blocking || t.Held || t.Immediate {
+			continue
+		}
+		if id, ok := t.blockingID(); ok {
+			if _, ok := firstBlocking[id]; !ok {
+				firstBlocking[id] = t
+			}
+		}
+	}
+	// Consider pending tasks in order of highest priority first, and then
+	// their position in the queue.
+	slices.SortStableFunc(pending, byQueueOrder)
+	paused := e.tasks.Paused()
 	// Build list of tasks to enqueue
 	var enqueue []*Task
 	for _, t := range pending {
+		if t.Held {
+			// Don't enqueue held task
+			continue
+		}
 		if t.Immediate {
 			// Always enqueue immediate tasks.
 			enqueue = append(enqueue, t)
 			continue
 		}
+		if paused {
+			// Don't enqueue task while queue is paused
+			continue
+		}
 		if t.WorkspaceID != nil {
 			if _, ok := blockedWorkspaces[*t.WorkspaceID]; ok {
 				// Don't enqueue task belonging to workspace blocked by another task
@@ -90,6 +129,13 @@ func (e *enqueuer) enqueuable() []*Task {
 				continue
 			}
 		}
+		if t.Blocking {
+			if id, ok := t.blockingID(); ok && firstBlocking[id] != t {
+				// Don't enqueue blocking task ahead of an older blocking task
+				// belonging to the same workspace or module.
+				continue
+			}
+		}
 		if !e.enqueueDependentTask(t) {
 			// Don't enqueue task with dependencies on other tasks that have yet
 			// to complete or have failed.
@@ -114,6 +160,19 @@ func (e *enqueuer) enqueuable() []*Task {
 	return enqueue
 }
 
+// blockingID returns the ID of the workspace the task belongs to, or if it
+// doesn't belong to a workspace, the ID of its module. False is returned if it
+// belongs to neither.
+func (t *Task) blockingID() (resource.ID, bool) {
+	if t.WorkspaceID != nil {
+		return *t.WorkspaceID, true
+	}
+	if t.ModuleID != nil {
+		return *t.ModuleID, true
+	}
+	return resource.ID{}, false
+}
+
 func (e *enqueuer) enqueueDependentTask(t *Task) bool {
 	for _, id := range t.DependsOn {
 		dependency, err := e.tasks.Get(id)
@@ -121,10 +180,17 @@ func (e *enqueuer) enqueueDependentTask(t *Task) bool {
 			// TODO: decide what to do in case of error
 			return false
 		}
+		// If the dependency has been retried then depend on its latest
+		// attempt instead.
+		dependency = dependency.LatestAttempt()
 		switch dependency.State {
 		case Exited:
 			// Is enqueuable if all dependencies have exited successfully.
-		case Canceled, Errored:
+		case Canceled, Errored, TimedOut:
+			if dependency.WillRetry() {
+				// Wait for the dependency to be retried.
+				return false
+			}
 			// Dependency failed so mark task as failed too by cancelling it
 			// along with a reason why it was canceled.
 			t.stdout.Write([]byte("task dependency failed"))
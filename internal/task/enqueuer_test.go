@@ -15,6 +15,8 @@ func TestEnqueuer(t *testing.T) {
 
 	mod1ID := resource.NewID(resource.Module)
 	ws1ID := resource.NewID(resource.Workspace)
+	mod2ID := resource.NewID(resource.Module)
+	ws2ID := resource.NewID(resource.Workspace)
 
 	mod1Task1 := newTestTask(t, Spec{ModuleID: &mod1ID})
 	mod1TaskBlocking1 := newTestTask(t, Spec{ModuleID: &mod1ID, Blocking: true})
@@ -26,6 +28,8 @@ func TestEnqueuer(t *testing.T) {
 	ws1TaskBlocking2 := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, Blocking: true})
 	ws1TaskBlocking3 := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, Blocking: true})
 	ws1TaskImmediate := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, Immediate: true})
+	ws1TaskBlockingHighPriority := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, Blocking: true, Priority: HighPriority})
+	ws2TaskBlockingHighPriority := newTestTask(t, Spec{ModuleID: &mod2ID, WorkspaceID: &ws2ID, Blocking: true, Priority: HighPriority})
 	ws1TaskDependOnTask1 := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, dependsOn: []resource.ID{ws1Task1.ID}})
 
 	ws1TaskCompleted := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID})
@@ -33,6 +37,25 @@ func TestEnqueuer(t *testing.T) {
 
 	ws1TaskDependOnCompletedTask := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, dependsOn: []resource.ID{ws1TaskCompleted.ID}})
 
+	ws1TaskRetrying := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID})
+	ws1TaskRetrying.updateState(Errored)
+	ws1TaskRetrying.retrying.Store(true)
+
+	ws1TaskDependOnRetryingTask := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, dependsOn: []resource.ID{ws1TaskRetrying.ID}})
+
+	ws1TaskRetried := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID})
+	ws1TaskRetried.updateState(Errored)
+	ws1TaskRetried.retrying.Store(true)
+	ws1TaskRetried.retriedBy.Store(ws1TaskCompleted)
+
+	ws1TaskDependOnRetriedTask := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, dependsOn: []resource.ID{ws1TaskRetried.ID}})
+
+	ws1TaskHeld := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID})
+	ws1TaskHeld.Held = true
+
+	ws1TaskBlockingMoved := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, Blocking: true})
+	ws1TaskBlockingMoved.position = 0
+
 	tests := []struct {
 		name string
 		// Active tasks
@@ -41,6 +64,8 @@ func TestEnqueuer(t *testing.T) {
 		pending []*Task
 		// Other tasks for retrieval via their ID
 		other []*Task
+		// Whether the queue is paused
+		paused bool
 		// Want these tasks enqueued
 		want []*Task
 	}{
@@ -86,6 +111,18 @@ func TestEnqueuer(t *testing.T) {
 			pending: []*Task{ws1TaskBlocking1, ws1TaskBlocking2, ws1TaskBlocking3},
 			want:    []*Task{ws1TaskBlocking1},
 		},
+		{
+			name:    "don't enqueue higher priority blocking workspace task before older blocking workspace tasks",
+			active:  []*Task{},
+			pending: []*Task{ws1TaskBlocking1, ws1TaskBlocking2, ws1TaskBlockingHighPriority},
+			want:    []*Task{ws1TaskBlocking1},
+		},
+		{
+			name:    "enqueue higher priority blocking workspace task before older blocking task in another workspace",
+			active:  []*Task{},
+			pending: []*Task{ws1TaskBlocking1, ws2TaskBlockingHighPriority},
+			want:    []*Task{ws2TaskBlockingHighPriority, ws1TaskBlocking1},
+		},
 		{
 			name:    "enqueue immediate task despite being blocked",
 			active:  []*Task{ws1TaskBlocking1},
@@ -104,6 +141,34 @@ func TestEnqueuer(t *testing.T) {
 			pending: []*Task{ws1TaskDependOnCompletedTask},
 			want:    []*Task{ws1TaskDependOnCompletedTask},
 		},
+		{
+			name:    "don't enqueue task with a dependency on a failed task awaiting retry",
+			other:   []*Task{ws1TaskRetrying},
+			pending: []*Task{ws1TaskDependOnRetryingTask},
+			want:    nil,
+		},
+		{
+			name:    "enqueue task with a dependency on a failed task that was successfully retried",
+			other:   []*Task{ws1TaskRetried},
+			pending: []*Task{ws1TaskDependOnRetriedTask},
+			want:    []*Task{ws1TaskDependOnRetriedTask},
+		},
+		{
+			name:    "don't enqueue held task",
+			pending: []*Task{ws1TaskHeld, ws1Task1},
+			want:    []*Task{ws1Task1},
+		},
+		{
+			name:    "enqueue only immediate task while queue is paused",
+			pending: []*Task{ws1Task1, ws1TaskImmediate},
+			paused:  true,
+			want:    []*Task{ws1TaskImmediate},
+		},
+		{
+			name:    "enqueue blocking task moved to front of queue",
+			pending: []*Task{ws1TaskBlocking1, ws1TaskBlockingMoved},
+			want:    []*Task{ws1TaskBlockingMoved},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -112,6 +177,7 @@ func TestEnqueuer(t *testing.T) {
 					pending: tt.pending,
 					active:  tt.active,
 					other:   tt.other,
+					paused:  tt.paused,
 				},
 			}
 			assert.Equal(t, tt.want, e.enqueuable())
@@ -128,6 +194,7 @@ func newTestTask(t *testing.T, spec Spec) *Task {
 
 type fakeEnqueuerTaskService struct {
 	pending, active, other []*Task
+	paused                 bool
 }
 
 func (f *fakeEnqueuerTaskService) List(opts ListOptions) []*Task {
@@ -148,3 +215,7 @@ func (f *fakeEnqueuerTaskService) Get(id resource.ID) (*Task, error) {
 	}
 	return nil, resource.ErrNotFound
 }
+
+func (f *fakeEnqueuerTaskService) Paused() bool {
+	return f.paused
+}
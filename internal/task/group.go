@@ -4,6 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/leg100/pug/internal/resource"
@@ -16,15 +19,88 @@ type Group struct {
 	Command      string
 	Tasks        []*Task
 	CreateErrors []error
+	// FailurePolicy determines how the group responds to the failure of its
+	// tasks. Use Policy to read it once the group has been created.
+	FailurePolicy FailurePolicy
+
+	// historyID uniquely identifies the group across pug sessions.
+	historyID string
+
+	// failures is the number of tasks that have failed.
+	failures int
+	// halted is true if the failure policy has been breached and the group's
+	// remaining tasks have been canceled.
+	halted bool
+	// mu guards FailurePolicy, failures and halted.
+	mu sync.Mutex
+}
+
+// FailurePolicy determines how a task group responds to the failure of its
+// tasks.
+type FailurePolicy struct {
+	// MaxFailures is the number of task failures at which the group's pending
+	// and queued tasks are canceled. Zero means the remaining tasks are never
+	// canceled.
+	MaxFailures int
+}
+
+var (
+	// ContinueOnFailure continues running a group's tasks regardless of how
+	// many of them fail.
+	ContinueOnFailure = FailurePolicy{}
+	// FailFast cancels a group's remaining tasks upon the first failure.
+	FailFast = FailurePolicy{MaxFailures: 1}
+)
+
+func (p FailurePolicy) String() string {
+	switch p.MaxFailures {
+	case 0:
+		return "continue"
+	case 1:
+		return "fail-fast"
+	default:
+		return fmt.Sprintf("max %d failures", p.MaxFailures)
+	}
+}
+
+// ParseFailurePolicy parses a failure policy from either "continue",
+// "fail-fast", or the maximum number of failures.
+func ParseFailurePolicy(s string) (FailurePolicy, error) {
+	switch s = strings.TrimSpace(s); s {
+	case "continue":
+		return ContinueOnFailure, nil
+	case "fail-fast":
+		return FailFast, nil
+	}
+	max, err := strconv.Atoi(s)
+	if err != nil || max < 0 {
+		return FailurePolicy{}, fmt.Errorf("invalid failure policy: %s: must be continue, fail-fast, or a maximum number of failures", s)
+	}
+	return FailurePolicy{MaxFailures: max}, nil
 }
 
-func newGroup(service *Service, specs ...Spec) (*Group, error) {
+func newGroup(service *Service, policy FailurePolicy, specs ...Spec) (*Group, error) {
 	if len(specs) == 0 {
 		return nil, errors.New("no specs provided")
 	}
 	g := &Group{
-		ID:      resource.NewID(resource.TaskGroup),
-		Created: time.Now(),
+		ID:            resource.NewID(resource.TaskGroup),
+		Created:       time.Now(),
+		FailurePolicy: policy,
+		historyID:     newHistoryID(),
+	}
+	// Clone specs to avoid modifying the caller's specs
+	specs = slices.Clone(specs)
+	for i := range specs {
+		specs[i].groupID = &g.ID
+	}
+	// Enforce the failure policy whenever a task finishes, and cancel any
+	// retries of the group's tasks created after the group has halted. The
+	// callbacks are added regardless of the policy because the policy can be
+	// changed after the group has been created.
+	for i := range specs {
+		specs[i].AfterCreate = g.wrapCallback(specs[i].AfterCreate)
+		specs[i].AfterFinish = g.wrapCallback(specs[i].AfterFinish)
 	}
 	// Validate specifications. There are some settings that are incompatible
 	// with one another within a task group.
@@ -86,16 +162,108 @@ func newGroup(service *Service, specs ...Spec) (*Group, error) {
 
 func (g *Group) String() string { return g.Command }
 
+// Halted returns true if the group's failure policy has been breached and its
+// remaining tasks canceled.
+func (g *Group) Halted() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.halted
+}
+
+// Policy returns the group's failure policy.
+func (g *Group) Policy() FailurePolicy {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.FailurePolicy
+}
+
+// setFailurePolicy changes the group's failure policy. Failures that occurred
+// before the change count towards the new policy, so if they have already
+// reached its maximum then the group halts and its pending and queued tasks are
+// returned for cancelation. The policy of a halted group cannot be changed.
+func (g *Group) setFailurePolicy(policy FailurePolicy) ([]*Task, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.halted {
+		return nil, errors.New("task group has already halted")
+	}
+	g.FailurePolicy = policy
+	return g.haltIfBreached(), nil
+}
+
+func (g *Group) wrapCallback(fn func(*Task)) func(*Task) {
+	return func(t *Task) {
+		for _, t := range g.enforceFailurePolicy(t) {
+			_ = t.cancel()
+		}
+		if fn != nil {
+			fn(t)
+		}
+	}
+}
+
+// enforceFailurePolicy is called when a task belonging to the group is created
+// or finishes. If the task has failed, and the number of failures has reached
+// the policy's maximum, then the group halts and the group's pending and queued
+// tasks are returned for cancelation. Any tasks created after the group has
+// halted, i.e. retries, are also returned for cancelation.
+func (g *Group) enforceFailurePolicy(t *Task) []*Task {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.halted {
+		if t.RetryOf != nil && t.State == Pending {
+			return []*Task{t}
+		}
+		return nil
+	}
+	if t.State != Errored && t.State != TimedOut || t.WillRetry() {
+		return nil
+	}
+	g.failures++
+	return g.haltIfBreached()
+}
+
+// haltIfBreached halts the group if the number of failures has reached the
+// maximum of its failure policy, returning the group's pending and queued
+// tasks for cancelation. The caller must hold the mutex.
+func (g *Group) haltIfBreached() []*Task {
+	if g.FailurePolicy.MaxFailures == 0 || g.failures < g.FailurePolicy.MaxFailures {
+		return nil
+	}
+	g.halted = true
+
+	var cancel []*Task
+	for _, gt := range g.Tasks {
+		gt = gt.LatestAttempt()
+		if gt.State == Pending || gt.State == Queued {
+			cancel = append(cancel, gt)
+		}
+	}
+	return cancel
+}
+
+// historical returns true if the group was loaded from a previous pug session.
+func (g *Group) historical() bool {
+	return len(g.Tasks) > 0 && g.Tasks[0].History != nil
+}
+
 func (g *Group) IncludesTask(taskID resource.ID) bool {
 	return slices.ContainsFunc(g.Tasks, func(tgt *Task) bool {
 		return tgt.ID == taskID
 	})
 }
 
+// Finished returns the number of tasks that have finished. Tasks that have been
+// automatically retried are represented by their latest attempt.
 func (g *Group) Finished() int {
 	var finished int
 	for _, t := range g.Tasks {
-		if t.State.IsFinal() {
+		t = t.LatestAttempt()
+		if t.State.IsFinal() && !t.WillRetry() {
 			finished++
 		}
 	}
@@ -105,17 +273,20 @@ func (g *Group) Finished() int {
 func (g *Group) Exited() int {
 	var exited int
 	for _, t := range g.Tasks {
-		if t.State == Exited {
+		if t.LatestAttempt().State == Exited {
 			exited++
 		}
 	}
 	return exited
 }
 
+// Errored returns the number of tasks that have failed, including tasks that
+// have timed out. Tasks awaiting an automatic retry are not counted.
 func (g *Group) Errored() int {
 	var errored int
 	for _, t := range g.Tasks {
-		if t.State == Errored {
+		t = t.LatestAttempt()
+		if (t.State == Errored || t.State == TimedOut) && !t.WillRetry() {
 			errored++
 		}
 	}
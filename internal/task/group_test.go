@@ -0,0 +1,110 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFailurePolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		s       string
+		want    FailurePolicy
+		wantErr bool
+	}{
+		{"continue", ContinueOnFailure, false},
+		{"fail-fast", FailFast, false},
+		{"0", ContinueOnFailure, false},
+		{"3", FailurePolicy{MaxFailures: 3}, false},
+		{"-1", FailurePolicy{}, true},
+		{"sometimes", FailurePolicy{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseFailurePolicy(tt.s)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGroup_enforceFailurePolicy(t *testing.T) {
+	t.Parallel()
+
+	errored1 := newTestTask(t, Spec{})
+	errored1.updateState(Errored)
+	errored2 := newTestTask(t, Spec{})
+	errored2.updateState(Errored)
+	retrying := newTestTask(t, Spec{})
+	retrying.updateState(Errored)
+	retrying.retrying.Store(true)
+	running := newTestTask(t, Spec{})
+	running.updateState(Running)
+	pending := newTestTask(t, Spec{})
+	queued := newTestTask(t, Spec{})
+	queued.updateState(Queued)
+
+	tasks := []*Task{errored1, errored2, retrying, running, pending, queued}
+
+	t.Run("fail fast", func(t *testing.T) {
+		g := &Group{Tasks: tasks, FailurePolicy: FailFast}
+
+		assert.Nil(t, g.enforceFailurePolicy(running))
+		assert.Nil(t, g.enforceFailurePolicy(retrying))
+		assert.False(t, g.Halted())
+
+		assert.Equal(t, []*Task{pending, queued}, g.enforceFailurePolicy(errored1))
+		assert.True(t, g.Halted())
+	})
+
+	t.Run("max failures", func(t *testing.T) {
+		g := &Group{Tasks: tasks, FailurePolicy: FailurePolicy{MaxFailures: 2}}
+
+		assert.Nil(t, g.enforceFailurePolicy(errored1))
+		assert.False(t, g.Halted())
+
+		assert.Equal(t, []*Task{pending, queued}, g.enforceFailurePolicy(errored2))
+		assert.True(t, g.Halted())
+	})
+
+	t.Run("continue", func(t *testing.T) {
+		g := &Group{Tasks: tasks, FailurePolicy: ContinueOnFailure}
+
+		assert.Nil(t, g.enforceFailurePolicy(errored1))
+		assert.Nil(t, g.enforceFailurePolicy(errored2))
+		assert.False(t, g.Halted())
+	})
+
+	t.Run("set policy after failures", func(t *testing.T) {
+		g := &Group{Tasks: tasks, FailurePolicy: ContinueOnFailure}
+
+		assert.Nil(t, g.enforceFailurePolicy(errored1))
+
+		cancel, err := g.setFailurePolicy(FailurePolicy{MaxFailures: 2})
+		require.NoError(t, err)
+		assert.Nil(t, cancel)
+		assert.False(t, g.Halted())
+
+		cancel, err = g.setFailurePolicy(FailFast)
+		require.NoError(t, err)
+		assert.Equal(t, []*Task{pending, queued}, cancel)
+		assert.True(t, g.Halted())
+
+		_, err = g.setFailurePolicy(ContinueOnFailure)
+		assert.Error(t, err)
+	})
+
+	t.Run("cancel retry created after halting", func(t *testing.T) {
+		g := &Group{Tasks: tasks, FailurePolicy: FailFast, halted: true}
+
+		retry := newTestTask(t, Spec{retryOf: retrying})
+		assert.Equal(t, []*Task{retry}, g.enforceFailurePolicy(retry))
+	})
+}
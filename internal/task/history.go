@@ -0,0 +1,481 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leg100/pug/internal/resource"
+)
+
+const (
+	historyDirName    = "history"
+	historyGroupsDir  = "groups"
+	historyRecordFile = "task.json"
+	historyOutputFile = "output"
+)
+
+// History describes a task that was run in a previous pug session and has
+// since been reloaded from disk. Historical tasks are read-only: they cannot be
+// canceled, retried or applied.
+type History struct {
+	// ModulePath is the path of the task's module, relative to the working
+	// directory. Empty if the task did not belong to a module.
+	ModulePath string
+	// WorkspaceName is the name of the task's workspace. Empty if the task did
+	// not belong to a workspace.
+	WorkspaceName string
+}
+
+// HistoricalSummary is the summary of a historical task. The original summary
+// type is not retained, only its string representation.
+type HistoricalSummary string
+
+func (s HistoricalSummary) String() string { return string(s) }
+
+// taskRecord is the on-disk representation of a finished task.
+type taskRecord struct {
+	ID            string                      `json:"id"`
+	Identifier    Identifier                  `json:"identifier,omitempty"`
+	Program       string                      `json:"program"`
+	Args          []string                    `json:"args"`
+	Env           []string                    `json:"env"`
+	Variables     []string                    `json:"variables,omitempty"`
+	Path          string                      `json:"path"`
+	Description   string                      `json:"description"`
+	JSON          bool                        `json:"json"`
+	ModulePath    string                      `json:"module_path,omitempty"`
+	WorkspaceName string                      `json:"workspace_name,omitempty"`
+	Spec          specRecord                  `json:"spec"`
+	Status        Status                      `json:"status"`
+	Summary       string                      `json:"summary,omitempty"`
+	Err           string                      `json:"error,omitempty"`
+	Created       time.Time                   `json:"created"`
+	Updated       time.Time                   `json:"updated"`
+	Timestamps    map[Status]timestampsRecord `json:"timestamps"`
+}
+
+// specRecord is the serializable subset of a task spec.
+type specRecord struct {
+	Identifier          Identifier    `json:"identifier,omitempty"`
+	Path                string        `json:"path"`
+	Env                 []string      `json:"env,omitempty"`
+	Execution           Execution     `json:"execution"`
+	AdditionalExecution *Execution    `json:"additional_execution,omitempty"`
+	Blocking            bool          `json:"blocking,omitempty"`
+	Exclusive           bool          `json:"exclusive,omitempty"`
+	JSON                bool          `json:"json,omitempty"`
+	Description         string        `json:"description,omitempty"`
+	Timeout             time.Duration `json:"timeout,omitempty"`
+}
+
+type timestampsRecord struct {
+	Started time.Time `json:"started"`
+	Ended   time.Time `json:"ended"`
+}
+
+// groupRecord is the on-disk representation of a task group.
+type groupRecord struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	Command string    `json:"command"`
+	// MaxFailures is the maximum failures of the group's failure policy.
+	MaxFailures int `json:"max_failures,omitempty"`
+	// TaskIDs are the IDs of the task records belonging to the group.
+	TaskIDs []string `json:"task_ids"`
+}
+
+// HistoryOptions configure the retention of task history.
+type HistoryOptions struct {
+	// MaxAge is the maximum age of a persisted task. Zero means no limit.
+	MaxAge time.Duration
+	// MaxCount is the maximum number of persisted tasks. Zero means no limit.
+	MaxCount int
+}
+
+// history persists finished tasks and task groups to disk, and reloads them
+// into a new pug session.
+type history struct {
+	HistoryOptions
+
+	// dir is the directory in which history is stored. If empty then history
+	// is disabled.
+	dir string
+}
+
+func (h *history) disabled() bool {
+	return h.dir == ""
+}
+
+func (h *history) taskDir(id string) string {
+	return filepath.Join(h.dir, id)
+}
+
+// saveTask writes the task's metadata and combined output to disk. The task
+// must be in a finished state.
+func (h *history) saveTask(t *Task) error {
+	if h.disabled() || t.History != nil {
+		return nil
+	}
+	dir := h.taskDir(t.historyID)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("creating task history directory: %w", err)
+	}
+	// Write output first, so that a task record is never found without its
+	// output.
+	out, err := os.OpenFile(filepath.Join(dir, historyOutputFile), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, t.NewReader(true)); err != nil {
+		return fmt.Errorf("writing task output: %w", err)
+	}
+	rec := newTaskRecord(t)
+	body, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, historyRecordFile), body, 0o600)
+}
+
+// saveGroup writes the task group's metadata to disk.
+func (h *history) saveGroup(g *Group) error {
+	if h.disabled() {
+		return nil
+	}
+	dir := filepath.Join(h.dir, historyGroupsDir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("creating task group history directory: %w", err)
+	}
+	rec := groupRecord{
+		ID:          g.historyID,
+		Created:     g.Created,
+		Command:     g.Command,
+		MaxFailures: g.Policy().MaxFailures,
+		TaskIDs:     make([]string, len(g.Tasks)),
+	}
+	for i, t := range g.Tasks {
+		rec.TaskIDs[i] = t.historyID
+	}
+	body, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, g.historyID+".json"), body, 0o600)
+}
+
+// load reads historical tasks and task groups from disk. Task groups are only
+// returned if at least one of their tasks is found.
+func (h *history) load() ([]*Task, []*Group, error) {
+	if h.disabled() {
+		return nil, nil, nil
+	}
+	entries, err := os.ReadDir(h.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	var (
+		tasks []*Task
+		// map of record ID to task
+		byRecordID = make(map[string]*Task)
+	)
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == historyGroupsDir {
+			continue
+		}
+		t, err := h.loadTask(entry.Name())
+		if errors.Is(err, os.ErrNotExist) {
+			// Task record yet to be written, or partially written.
+			continue
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("loading task %s: %w", entry.Name(), err)
+		}
+		tasks = append(tasks, t)
+		byRecordID[t.historyID] = t
+	}
+	groups, err := h.loadGroups(byRecordID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tasks, groups, nil
+}
+
+// gc removes persisted tasks exceeding the maximum age or count, oldest first,
+// along with groups none of whose tasks remain. The IDs of the removed task and
+// group records are returned.
+func (h *history) gc(now time.Time) (tasks, groups []string, err error) {
+	if h.disabled() {
+		return nil, nil, nil
+	}
+	entries, err := os.ReadDir(h.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	var records []taskRecord
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == historyGroupsDir {
+			continue
+		}
+		body, err := os.ReadFile(filepath.Join(h.taskDir(entry.Name()), historyRecordFile))
+		if errors.Is(err, os.ErrNotExist) {
+			// Task record yet to be written, or partially written.
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		var rec taskRecord
+		if err := json.Unmarshal(body, &rec); err != nil {
+			return nil, nil, fmt.Errorf("loading task %s: %w", entry.Name(), err)
+		}
+		records = append(records, rec)
+	}
+	// Newest first
+	slices.SortFunc(records, func(i, j taskRecord) int {
+		return j.Created.Compare(i.Created)
+	})
+	// map of task record ID to whether it is retained
+	retained := make(map[string]bool, len(records))
+	for i, rec := range records {
+		tooMany := h.MaxCount > 0 && i >= h.MaxCount
+		tooOld := h.MaxAge > 0 && now.Sub(rec.Created) > h.MaxAge
+		if !tooMany && !tooOld {
+			retained[rec.ID] = true
+			continue
+		}
+		if err := os.RemoveAll(h.taskDir(rec.ID)); err != nil {
+			return tasks, groups, fmt.Errorf("removing task history: %w", err)
+		}
+		retained[rec.ID] = false
+		tasks = append(tasks, rec.ID)
+	}
+	if len(tasks) == 0 {
+		return nil, nil, nil
+	}
+	groupsDir := filepath.Join(h.dir, historyGroupsDir)
+	entries, err = os.ReadDir(groupsDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return tasks, nil, nil
+	} else if err != nil {
+		return tasks, nil, err
+	}
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(groupsDir, entry.Name())
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return tasks, groups, err
+		}
+		var rec groupRecord
+		if err := json.Unmarshal(body, &rec); err != nil {
+			return tasks, groups, fmt.Errorf("loading task group %s: %w", entry.Name(), err)
+		}
+		// Only remove groups with removed tasks, leaving alone groups whose
+		// tasks are yet to be written.
+		removed := slices.ContainsFunc(rec.TaskIDs, func(id string) bool {
+			kept, ok := retained[id]
+			return ok && !kept
+		})
+		if !removed || slices.ContainsFunc(rec.TaskIDs, func(id string) bool { return retained[id] }) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return tasks, groups, fmt.Errorf("removing task group history: %w", err)
+		}
+		groups = append(groups, rec.ID)
+	}
+	return tasks, groups, nil
+}
+
+func (h *history) loadTask(id string) (*Task, error) {
+	dir := h.taskDir(id)
+	body, err := os.ReadFile(filepath.Join(dir, historyRecordFile))
+	if err != nil {
+		return nil, err
+	}
+	var rec taskRecord
+	if err := json.Unmarshal(body, &rec); err != nil {
+		return nil, err
+	}
+	// Only the combined output is persisted, so back both buffers with the
+	// same output file rather than reading it into memory.
+	output := filepath.Join(dir, historyOutputFile)
+	stdout, err := newFileBuffer(output)
+	if err != nil {
+		return nil, err
+	}
+	combined, err := newFileBuffer(output)
+	if err != nil {
+		return nil, err
+	}
+	return rec.toTask(stdout, combined), nil
+}
+
+func (h *history) loadGroups(tasks map[string]*Task) ([]*Group, error) {
+	entries, err := os.ReadDir(filepath.Join(h.dir, historyGroupsDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var groups []*Group
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		body, err := os.ReadFile(filepath.Join(h.dir, historyGroupsDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var rec groupRecord
+		if err := json.Unmarshal(body, &rec); err != nil {
+			return nil, fmt.Errorf("loading task group %s: %w", entry.Name(), err)
+		}
+		g := &Group{
+			ID:            resource.NewID(resource.TaskGroup),
+			Created:       rec.Created,
+			Command:       rec.Command,
+			FailurePolicy: FailurePolicy{MaxFailures: rec.MaxFailures},
+			historyID:     rec.ID,
+		}
+		for _, id := range rec.TaskIDs {
+			if t, ok := tasks[id]; ok {
+				g.Tasks = append(g.Tasks, t)
+			}
+		}
+		if len(g.Tasks) == 0 {
+			continue
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+func newTaskRecord(t *Task) taskRecord {
+	rec := taskRecord{
+		ID:          t.historyID,
+		Identifier:  t.Identifier,
+		Program:     t.Program,
+		Args:        t.Args,
+		Variables:   t.Variables,
+		Env:         t.AdditionalEnv,
+		Path:        t.Path,
+		Description: t.Description,
+		JSON:        t.JSON,
+		Spec: specRecord{
+			Identifier:          t.Spec.Identifier,
+			Path:                t.Spec.Path,
+			Env:                 t.Spec.Env,
+			Execution:           t.Spec.Execution,
+			AdditionalExecution: t.Spec.AdditionalExecution,
+			Blocking:            t.Spec.Blocking,
+			Exclusive:           t.Spec.Exclusive,
+			JSON:                t.Spec.JSON,
+			Description:         t.Spec.Description,
+			Timeout:             t.Spec.Timeout,
+		},
+		Status:     t.State,
+		Created:    t.Created,
+		Updated:    t.Updated,
+		Timestamps: make(map[Status]timestampsRecord, len(t.timestamps)),
+	}
+	if t.ModuleID != nil {
+		rec.ModulePath = t.Spec.Path
+	}
+	if t.WorkspaceID != nil {
+		rec.WorkspaceName = workspaceNameFromEnv(t.Spec.Env)
+	}
+	if t.Summary != nil {
+		rec.Summary = t.Summary.String()
+	}
+	if t.Err != nil {
+		rec.Err = t.Err.Error()
+	}
+	for status, ts := range t.timestamps {
+		rec.Timestamps[status] = timestampsRecord{
+			Started: ts.started,
+			Ended:   ts.ended,
+		}
+	}
+	return rec
+}
+
+func (rec taskRecord) toTask(stdout, combined *buffer) *Task {
+	t := &Task{
+		ID:            resource.NewID(resource.Task),
+		Identifier:    rec.Identifier,
+		Program:       rec.Program,
+		Args:          rec.Args,
+		Variables:     rec.Variables,
+		AdditionalEnv: rec.Env,
+		Path:          rec.Path,
+		Description:   rec.Description,
+		JSON:          rec.JSON,
+		State:         rec.Status,
+		Created:       rec.Created,
+		Updated:       rec.Updated,
+		finished:      make(chan struct{}),
+		stdout:        stdout,
+		combined:      combined,
+		timestamps:    make(map[Status]statusTimestamps, len(rec.Timestamps)),
+		historyID:     rec.ID,
+		Spec: Spec{
+			Identifier:          rec.Spec.Identifier,
+			Path:                rec.Spec.Path,
+			Env:                 rec.Spec.Env,
+			Execution:           rec.Spec.Execution,
+			AdditionalExecution: rec.Spec.AdditionalExecution,
+			Blocking:            rec.Spec.Blocking,
+			Exclusive:           rec.Spec.Exclusive,
+			JSON:                rec.Spec.JSON,
+			Description:         rec.Spec.Description,
+			Timeout:             rec.Spec.Timeout,
+		},
+		History: &History{
+			ModulePath:    rec.ModulePath,
+			WorkspaceName: rec.WorkspaceName,
+		},
+	}
+	if rec.Summary != "" {
+		t.Summary = HistoricalSummary(rec.Summary)
+	}
+	if rec.Err != "" {
+		t.Err = errors.New(rec.Err)
+	}
+	for status, ts := range rec.Timestamps {
+		t.timestamps[status] = statusTimestamps{
+			started: ts.Started,
+			ended:   ts.Ended,
+		}
+	}
+	close(t.finished)
+	return t
+}
+
+// workspaceNameFromEnv retrieves the name of the workspace from the
+// TF_WORKSPACE environment variable.
+func workspaceNameFromEnv(envs []string) string {
+	for _, env := range envs {
+		if name, ok := strings.CutPrefix(env, "TF_WORKSPACE="); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+func newHistoryID() string {
+	return uuid.NewString()
+}
@@ -0,0 +1,142 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	h := &history{dir: t.TempDir()}
+
+	program, err := filepath.Abs("./testdata/task")
+	require.NoError(t, err)
+	workdir := internal.NewTestWorkdir(t)
+	require.NoError(t, os.MkdirAll(workdir.Join("a/b/c"), 0o755))
+
+	f := factory{
+		counter:   internal.Int(0),
+		program:   program,
+		publisher: &fakePublisher[*Task]{},
+		workdir:   workdir,
+	}
+	modID := resource.NewID(resource.Module)
+	wsID := resource.NewID(resource.Workspace)
+	task, err := f.newTask(Spec{
+		ModuleID:    &modID,
+		WorkspaceID: &wsID,
+		Path:        "a/b/c",
+		Env:         []string{"TF_WORKSPACE=dev"},
+		Identifier:  "plan",
+		BeforeExited: func(*Task) (Summary, error) {
+			return HistoricalSummary("+1/~0/-0"), errors.New("oops")
+		},
+	})
+	require.NoError(t, err)
+	task.updateState(Queued)
+	waitfn, err := task.start(context.Background())
+	require.NoError(t, err)
+	waitfn()
+
+	require.NoError(t, h.saveTask(task))
+	require.NoError(t, h.saveGroup(&Group{
+		Command:       "plan",
+		Tasks:         []*Task{task},
+		FailurePolicy: FailFast,
+		historyID:     newHistoryID(),
+	}))
+
+	tasks, groups, err := h.load()
+	require.NoError(t, err)
+
+	require.Len(t, tasks, 1)
+	got := tasks[0]
+	assert.NotEqual(t, task.ID, got.ID)
+	assert.Equal(t, Errored, got.State)
+	assert.Equal(t, "oops", got.Err.Error())
+	assert.Equal(t, "+1/~0/-0", got.Summary.String())
+	assert.Equal(t, task.Args, got.Args)
+	assert.Equal(t, Identifier("plan"), got.Identifier)
+	assert.Equal(t, &History{ModulePath: "a/b/c", WorkspaceName: "dev"}, got.History)
+	assert.InDelta(t, task.Elapsed(Running), got.Elapsed(Running), float64(time.Millisecond))
+	assert.Equal(t, "oops", got.Wait().Error(), "historical task should already be finished")
+
+	out, err := io.ReadAll(got.NewReader(true))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "bye\n")
+
+	require.Len(t, groups, 1)
+	assert.Equal(t, []*Task{got}, groups[0].Tasks)
+	assert.Equal(t, FailFast, groups[0].FailurePolicy)
+}
+
+func TestHistory_Disabled(t *testing.T) {
+	t.Parallel()
+
+	h := &history{}
+	tasks, groups, err := h.load()
+	require.NoError(t, err)
+	assert.Nil(t, tasks)
+	assert.Nil(t, groups)
+}
+
+func TestHistory_GC(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	h := &history{
+		dir:            t.TempDir(),
+		HistoryOptions: HistoryOptions{MaxAge: time.Hour, MaxCount: 2},
+	}
+	// Write task records directly, oldest first.
+	var ids []string
+	for _, age := range []time.Duration{3 * time.Hour, 30 * time.Minute, 20 * time.Minute, 10 * time.Minute} {
+		rec := taskRecord{ID: newHistoryID(), Created: now.Add(-age)}
+		require.NoError(t, os.MkdirAll(h.taskDir(rec.ID), 0o700))
+		body, err := json.Marshal(rec)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(h.taskDir(rec.ID), historyRecordFile), body, 0o600))
+		ids = append(ids, rec.ID)
+	}
+	// One group with only removed tasks, one with a retained task, and one
+	// with tasks yet to be written.
+	writeGroup := func(taskIDs ...string) string {
+		rec := groupRecord{ID: newHistoryID(), TaskIDs: taskIDs}
+		require.NoError(t, os.MkdirAll(filepath.Join(h.dir, historyGroupsDir), 0o700))
+		body, err := json.Marshal(rec)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(h.dir, historyGroupsDir, rec.ID+".json"), body, 0o600))
+		return rec.ID
+	}
+	removedGroup := writeGroup(ids[0], ids[1])
+	writeGroup(ids[1], ids[2])
+	writeGroup(newHistoryID())
+
+	tasks, groups, err := h.gc(now)
+	require.NoError(t, err)
+
+	// The first is too old, and the second exceeds the count.
+	assert.ElementsMatch(t, ids[:2], tasks)
+	assert.Equal(t, []string{removedGroup}, groups)
+
+	_, err = os.Stat(h.taskDir(ids[0]))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	_, err = os.Stat(h.taskDir(ids[2]))
+	assert.NoError(t, err)
+
+	entries, err := os.ReadDir(filepath.Join(h.dir, historyGroupsDir))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
@@ -0,0 +1,50 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Hook is a user-defined shell command run before or after a task.
+type Hook struct {
+	// Match is the identifier or terraform command of the tasks to which the
+	// hook applies, e.g. "plan" or "state pull".
+	Match string
+	// Command is the shell command to run.
+	Command string
+}
+
+// ParseHook parses a hook of the form <identifier or command>=<shell command>,
+// e.g. plan=tflint.
+func ParseHook(s string) (Hook, error) {
+	match, command, ok := strings.Cut(s, "=")
+	match, command = strings.TrimSpace(match), strings.TrimSpace(command)
+	if !ok || match == "" || command == "" {
+		return Hook{}, fmt.Errorf("invalid hook: %s: must be of the form <identifier>=<command>", s)
+	}
+	return Hook{Match: match, Command: command}, nil
+}
+
+func (h Hook) String() string {
+	return fmt.Sprintf("%s=%s", h.Match, h.Command)
+}
+
+// matches determines whether the hook applies to a task created from the spec.
+func (h Hook) matches(spec Spec) bool {
+	if spec.Identifier != "" && h.Match == string(spec.Identifier) {
+		return true
+	}
+	return len(spec.Execution.TerraformCommand) > 0 &&
+		h.Match == strings.Join(spec.Execution.TerraformCommand, " ")
+}
+
+// matchingHooks returns the commands of the hooks that apply to a task created
+// from the spec.
+func matchingHooks(hooks []Hook, spec Spec) (commands []string) {
+	for _, h := range hooks {
+		if h.matches(spec) {
+			commands = append(commands, h.Command)
+		}
+	}
+	return commands
+}
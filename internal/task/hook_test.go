@@ -0,0 +1,26 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHook(t *testing.T) {
+	t.Parallel()
+
+	got, err := ParseHook("plan=tflint --minimum-failure-severity=error")
+	require.NoError(t, err)
+	assert.Equal(t, Hook{Match: "plan", Command: "tflint --minimum-failure-severity=error"}, got)
+
+	_, err = ParseHook("plan")
+	assert.Error(t, err)
+
+	_, err = ParseHook("=tflint")
+	assert.Error(t, err)
+
+	assert.True(t, Hook{Match: "plan"}.matches(Spec{Identifier: "plan"}))
+	assert.True(t, Hook{Match: "state pull"}.matches(Spec{Execution: Execution{TerraformCommand: []string{"state", "pull"}}}))
+	assert.False(t, Hook{Match: "apply"}.matches(Spec{Identifier: "plan"}))
+}
@@ -0,0 +1,123 @@
+package task
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+
+	"github.com/leg100/pug/internal/resource"
+)
+
+// Pause pauses the task queue: pending tasks are no longer enqueued and queued
+// tasks are no longer started, with the exception of immediate tasks. Running
+// tasks are left to finish.
+func (s *Service) Pause() {
+	s.paused.Store(true)
+	s.logger.Info("paused task queue")
+}
+
+// Resume resumes the task queue.
+func (s *Service) Resume() {
+	s.paused.Store(false)
+	s.logger.Info("resumed task queue")
+
+	// The enqueuer and runner only act upon task events, so publish an event
+	// to prompt them into processing any tasks awaiting them.
+	waiting := s.List(ListOptions{Status: []Status{Pending, Queued}})
+	if len(waiting) > 0 {
+		s.TaskBroker.Publish(resource.UpdatedEvent, waiting[0])
+	}
+}
+
+// Paused returns true if the task queue is paused.
+func (s *Service) Paused() bool {
+	return s.paused.Load()
+}
+
+// Hold holds a pending or queued task, preventing it from being enqueued or
+// started until it is released.
+func (s *Service) Hold(taskID resource.ID) (*Task, error) {
+	task, err := s.tasks.Update(taskID, func(existing *Task) error {
+		if existing.State != Pending && existing.State != Queued {
+			return errors.New("only pending or queued tasks can be held")
+		}
+		existing.Held = true
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("holding task", "id", taskID, "error", err)
+		return nil, err
+	}
+	s.logger.Info("held task", "task", task)
+	return task, nil
+}
+
+// Release releases a held task.
+func (s *Service) Release(taskID resource.ID) (*Task, error) {
+	task, err := s.tasks.Update(taskID, func(existing *Task) error {
+		if !existing.Held {
+			return errors.New("task is not held")
+		}
+		existing.Held = false
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("releasing task", "id", taskID, "error", err)
+		return nil, err
+	}
+	s.logger.Info("released task", "task", task)
+	return task, nil
+}
+
+// Move moves a pending task forward (a negative offset) or backward (a positive
+// offset) in the queue, swapping its position with that of the pending task
+// alongside it. A task cannot be moved past a task with a different priority.
+func (s *Service) Move(taskID resource.ID, offset int) (*Task, error) {
+	task, err := func() (*Task, error) {
+		pending := s.List(ListOptions{Status: []Status{Pending}})
+		slices.SortStableFunc(pending, byQueueOrder)
+
+		i := slices.IndexFunc(pending, func(t *Task) bool {
+			return t.ID == taskID
+		})
+		if i < 0 {
+			return nil, errors.New("only pending tasks can be moved")
+		}
+		j := i + offset
+		if j < 0 || j >= len(pending) {
+			return nil, errors.New("task cannot be moved any further")
+		}
+		if pending[i].Priority != pending[j].Priority {
+			return nil, errors.New("task cannot be moved past a task with a different priority")
+		}
+		// Swap positions.
+		a, b := pending[i].position, pending[j].position
+		_, err := s.tasks.Update(pending[j].ID, func(existing *Task) error {
+			existing.position = a
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return s.tasks.Update(taskID, func(existing *Task) error {
+			existing.position = b
+			return nil
+		})
+	}()
+	if err != nil {
+		s.logger.Error("moving task", "id", taskID, "error", err)
+		return nil, err
+	}
+	s.logger.Info("moved task", "task", task, "offset", offset)
+	return task, nil
+}
+
+// byQueueOrder sorts tasks in the order in which they are to be enqueued:
+// tasks with a higher priority first, and then by their position in the
+// queue.
+func byQueueOrder(i, j *Task) int {
+	if c := ByPriority(i, j); c != 0 {
+		return c
+	}
+	return cmp.Compare(i.position, j.position)
+}
@@ -0,0 +1,82 @@
+package task
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Hold(t *testing.T) {
+	t.Parallel()
+
+	pending := &Task{ID: resource.NewID(resource.Task), State: Pending}
+	running := &Task{ID: resource.NewID(resource.Task), State: Running}
+
+	svc := &Service{
+		tasks:  resource.NewTable(&fakePublisher[*Task]{}),
+		logger: logging.Discard,
+	}
+	svc.tasks.Add(pending.ID, pending)
+	svc.tasks.Add(running.ID, running)
+
+	_, err := svc.Hold(pending.ID)
+	require.NoError(t, err)
+	assert.True(t, pending.Held)
+
+	_, err = svc.Release(pending.ID)
+	require.NoError(t, err)
+	assert.False(t, pending.Held)
+
+	_, err = svc.Release(pending.ID)
+	assert.Error(t, err)
+
+	_, err = svc.Hold(running.ID)
+	assert.Error(t, err)
+}
+
+func TestService_Move(t *testing.T) {
+	t.Parallel()
+
+	task1 := &Task{ID: resource.NewID(resource.Task), State: Pending, position: 1}
+	task2 := &Task{ID: resource.NewID(resource.Task), State: Pending, position: 2}
+	task3 := &Task{ID: resource.NewID(resource.Task), State: Pending, position: 3}
+	high := &Task{ID: resource.NewID(resource.Task), State: Pending, position: 4, Priority: HighPriority}
+
+	svc := &Service{
+		tasks:  resource.NewTable(&fakePublisher[*Task]{}),
+		logger: logging.Discard,
+	}
+	for _, task := range []*Task{task1, task2, task3, high} {
+		svc.tasks.Add(task.ID, task)
+	}
+	queue := func() []*Task {
+		pending := svc.List(ListOptions{Status: []Status{Pending}})
+		slices.SortFunc(pending, byQueueOrder)
+		return pending
+	}
+	require.Equal(t, []*Task{high, task1, task2, task3}, queue())
+
+	// Move task3 to the front of tasks of equal priority.
+	_, err := svc.Move(task3.ID, -1)
+	require.NoError(t, err)
+	_, err = svc.Move(task3.ID, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []*Task{high, task3, task1, task2}, queue())
+
+	// Cannot move task3 past the higher priority task.
+	_, err = svc.Move(task3.ID, -1)
+	assert.Error(t, err)
+
+	// Move task1 to the back.
+	_, err = svc.Move(task1.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []*Task{high, task3, task2, task1}, queue())
+
+	// Cannot move task1 any further back.
+	_, err = svc.Move(task1.ID, 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package task
+
+import (
+	"bufio"
+	"regexp"
+	"time"
+)
+
+// RetryPolicy specifies the automatic retry of a task that has failed with a
+// transient error.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times the task is run, including
+	// the first attempt. A value of one or less disables retries.
+	MaxAttempts int
+	// Backoff is the delay before the first retry. The delay doubles with each
+	// subsequent retry.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between retries. Zero means there is no cap.
+	MaxBackoff time.Duration
+	// Patterns are matched against each line of the combined output of a
+	// failed task. The task is only retried if at least one pattern matches.
+	Patterns []*regexp.Regexp
+}
+
+// DefaultRetryPatterns match output from commonly encountered transient
+// errors.
+var DefaultRetryPatterns = []string{
+	`Error acquiring the state lock`,
+	`(?i)registry\.terraform\.io.*(timeout|timed out)`,
+	`(?i)Client\.Timeout exceeded`,
+	`ThrottlingException|Throttling: Rate exceeded`,
+	`StatusCode: 429|429 Too Many Requests`,
+}
+
+// maxRetryLineSize is the maximum size of a line of output matched against
+// retry patterns. Scanning stops at a longer line.
+const maxRetryLineSize = 1 << 20
+
+// shouldRetry determines whether the task should be retried according to the
+// policy.
+func (p *RetryPolicy) shouldRetry(t *Task) bool {
+	if p == nil || t.Attempt >= p.MaxAttempts {
+		return false
+	}
+	// Scan output line by line rather than reading it all into memory, which
+	// may have been written to disk because it exceeded the memory limit.
+	scanner := bufio.NewScanner(t.NewReader(true))
+	scanner.Buffer(nil, maxRetryLineSize)
+	for scanner.Scan() {
+		for _, re := range p.Patterns {
+			if re.Match(scanner.Bytes()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// delay returns the delay before retrying the given attempt.
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && (p.MaxBackoff == 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 {
+		d = min(d, p.MaxBackoff)
+	}
+	return d
+}
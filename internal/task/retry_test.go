@@ -0,0 +1,81 @@
+package task
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_delay(t *testing.T) {
+	t.Parallel()
+
+	policy := &RetryPolicy{
+		Backoff:    time.Second,
+		MaxBackoff: 10 * time.Second,
+	}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{100, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, policy.delay(tt.attempt), "attempt %d", tt.attempt)
+	}
+}
+
+func TestRetryPolicy_shouldRetry(t *testing.T) {
+	t.Parallel()
+
+	policy := &RetryPolicy{
+		MaxAttempts: 2,
+		Patterns:    []*regexp.Regexp{regexp.MustCompile(`Error acquiring the state lock`)},
+	}
+	defaults := &RetryPolicy{MaxAttempts: 2}
+	for _, pattern := range DefaultRetryPatterns {
+		defaults.Patterns = append(defaults.Patterns, regexp.MustCompile(pattern))
+	}
+
+	tests := []struct {
+		name    string
+		policy  *RetryPolicy
+		attempt int
+		output  string
+		want    bool
+	}{
+		{"matching output", policy, 1, "Error: Error acquiring the state lock\n", true},
+		{"non-matching output", policy, 1, "Error: Unsupported argument\n", false},
+		{"exceeded max attempts", policy, 2, "Error: Error acquiring the state lock\n", false},
+		{"no policy", nil, 1, "Error: Error acquiring the state lock\n", false},
+		{"default patterns: throttled", defaults, 1, "Error: api error ThrottlingException: Rate exceeded\n", true},
+		{"default patterns: too many requests", defaults, 1, "Error: StatusCode: 429, RequestID: abc\n", true},
+		{"default patterns: plan output", defaults, 1, "  + throttling_rate_limit = 100\nError: Unsupported argument\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &Task{Attempt: tt.attempt, combined: newBuffer()}
+			task.combined.Write([]byte(tt.output))
+
+			assert.Equal(t, tt.want, tt.policy.shouldRetry(task))
+		})
+	}
+}
+
+func TestTask_LatestAttempt(t *testing.T) {
+	t.Parallel()
+
+	first := &Task{Attempt: 1}
+	second := &Task{Attempt: 2, RetryOf: first}
+	first.retriedBy.Store(second)
+
+	assert.Equal(t, second, first.LatestAttempt())
+	assert.Equal(t, second, second.LatestAttempt())
+}
@@ -2,26 +2,56 @@ package task
 
 import (
 	"context"
+	"slices"
 	"sync"
 
 	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/resource"
 )
 
-// Runner is the global task Runner that provides a couple of invariants:
+// Runner is the global task Runner that provides a few invariants:
 // (a) no more than MAX tasks run at any given time
 // (b) no more than one 'exclusive' task runs at any given time
+// (c) no more tasks matching a concurrency cap run than the cap permits
+// (d) no held tasks run, and no tasks other than immediate tasks run while the
+// queue is paused
 type runner struct {
 	max   int
-	tasks taskLister
+	tasks runnerTaskService
+	caps  capMatcher
+}
+
+type runnerTaskService interface {
+	taskLister
+
+	Paused() bool
+}
+
+// RunnerOptions are options for the task runner.
+type RunnerOptions struct {
+	// MaxTasks is the maximum number of tasks that may run concurrently.
+	MaxTasks int
+	// Caps further limit the number of tasks that may run concurrently.
+	Caps []ConcurrencyCap
+	// Labels assign labels to modules, for use with label caps.
+	Labels []ModuleLabel
+	// Backend retrieves the backend type of a module, for use with backend
+	// caps.
+	Backend func(moduleID resource.ID) string
 }
 
 // StartRunner starts the task runner and returns a function that waits for
 // running tasks to finish.
-func StartRunner(ctx context.Context, logger logging.Interface, tasks *Service, maxTasks int) func() {
+func StartRunner(ctx context.Context, logger logging.Interface, tasks *Service, opts RunnerOptions) func() {
 	sub := tasks.TaskBroker.Subscribe(context.Background())
 	r := &runner{
-		max:   maxTasks,
+		max:   opts.MaxTasks,
 		tasks: tasks,
+		caps: capMatcher{
+			caps:    opts.Caps,
+			labels:  opts.Labels,
+			backend: opts.Backend,
+		},
 	}
 	g := sync.WaitGroup{}
 
@@ -48,29 +78,75 @@ func StartRunner(ctx context.Context, logger logging.Interface, tasks *Service,
 	return g.Wait
 }
 
-// runnable retrieves a list of tasks to be run
+// runnable retrieves a list of tasks to be run. Tasks with a higher priority
+// are run first. Amongst tasks of equal priority, capacity is shared fairly
+// between task groups and modules: the next task to run is taken from the group
+// or module with the fewest running tasks, and then the oldest such task.
 func (r *runner) runnable() []*Task {
 	// exclusive is true if the one and only exclusive slot is occupied
 	var exclusive bool
 
+	paused := r.tasks.Paused()
+
 	running := r.tasks.List(ListOptions{
 		Status: []Status{Running},
 	})
 	avail := r.max - len(running)
 
+	// Number of running tasks for each task group or module.
+	load := make(map[resource.ID]int)
+	// Number of running tasks matching each concurrency cap.
+	capped := make([]int, len(r.caps.caps))
+	for _, rt := range running {
+		load[rt.fairnessKey()]++
+		for _, i := range r.caps.match(rt) {
+			capped[i]++
+		}
+	}
+
 	// Process queue, starting with oldest task
 	queued := r.tasks.List(ListOptions{
 		Status: []Status{Queued},
 		Oldest: true,
 	})
-	var i int
-	for _, qt := range queued {
+	runnable := make([]*Task, 0, len(queued))
+	for len(queued) > 0 {
+		// Pick next task
+		next := 0
+		for i, qt := range queued[1:] {
+			if r.before(qt, queued[next], load) {
+				next = i + 1
+			}
+		}
+		qt := queued[next]
+		queued = slices.Delete(queued, next, next+1)
+
+		if qt.Held {
+			// Don't run held task
+			continue
+		}
+		if paused && !qt.Immediate {
+			// Don't run task while queue is paused. Immediate tasks are exempt.
+			continue
+		}
+
 		if avail <= 0 && !qt.Immediate {
 			// No more available slots. Note: immediate tasks are immediately runnable, so they
 			// are exempt from the max. For this reason the number of slots may
 			// go into negative territory.
 			continue
 		}
+		// Immediate tasks are likewise exempt from concurrency caps.
+		var caps []int
+		if !qt.Immediate {
+			caps = r.caps.match(qt)
+			if slices.ContainsFunc(caps, func(i int) bool {
+				return capped[i] >= r.caps.caps[i].Max
+			}) {
+				// A concurrency cap has been reached.
+				continue
+			}
+		}
 		if qt.exclusive {
 			if exclusive {
 				// Exclusive slot taken
@@ -91,8 +167,21 @@ func (r *runner) runnable() []*Task {
 			exclusive = true
 		}
 		avail--
-		queued[i] = qt
-		i++
+		load[qt.fairnessKey()]++
+		for _, i := range caps {
+			capped[i]++
+		}
+		runnable = append(runnable, qt)
+	}
+	return runnable
+}
+
+// before returns true if task a should be run before task b, given the current
+// load on their groups or modules. If neither task takes precedence then false
+// is returned, leaving the older task first.
+func (r *runner) before(a, b *Task, load map[resource.ID]int) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
 	}
-	return queued[:i]
+	return load[a.fairnessKey()] < load[b.fairnessKey()]
 }
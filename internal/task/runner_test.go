@@ -4,6 +4,7 @@ import (
 	"slices"
 	"testing"
 
+	"github.com/leg100/pug/internal/resource"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,6 +17,26 @@ func TestRunner_runnable(t *testing.T) {
 	ex1 := &Task{exclusive: true}
 	ex2 := &Task{exclusive: true}
 	immediate := &Task{Immediate: true}
+	high := &Task{Priority: HighPriority}
+
+	group1ID := resource.NewID(resource.TaskGroup)
+	group2ID := resource.NewID(resource.TaskGroup)
+	group1Task1 := &Task{GroupID: &group1ID}
+	group1Task2 := &Task{GroupID: &group1ID}
+	group1Task3 := &Task{GroupID: &group1ID}
+	group2Task1 := &Task{GroupID: &group2ID}
+	group2Task2 := &Task{GroupID: &group2ID}
+
+	prodModID := resource.NewID(resource.Module)
+	devModID := resource.NewID(resource.Module)
+	prod1 := &Task{ModuleID: &prodModID, Spec: Spec{Path: "prod/vpc"}}
+	prod2 := &Task{ModuleID: &prodModID, Spec: Spec{Path: "prod/vpc"}}
+	dev1 := &Task{ModuleID: &devModID, Spec: Spec{Path: "dev/vpc"}}
+	immediateProd := &Task{ModuleID: &prodModID, Spec: Spec{Path: "prod/vpc"}, Immediate: true}
+	held := &Task{Held: true}
+	prodCap := capMatcher{
+		caps: []ConcurrencyCap{{Kind: PathCap, Value: "prod/**", Max: 1}},
+	}
 
 	tests := []struct {
 		name string
@@ -27,6 +48,10 @@ func TestRunner_runnable(t *testing.T) {
 		running []*Task
 		// Running exclusive tasks
 		exclusive []*Task
+		// Concurrency caps
+		caps capMatcher
+		// Whether the queue is paused
+		paused bool
 		// Want these runnable tasks
 		want []*Task
 	}{
@@ -93,6 +118,61 @@ func TestRunner_runnable(t *testing.T) {
 			running: []*Task{t1, t2},
 			want:    []*Task{immediate},
 		},
+		{
+			name:   "run higher priority task before older tasks",
+			max:    1,
+			queued: []*Task{t1, t2, high},
+			want:   []*Task{high},
+		},
+		{
+			name:   "share capacity between task groups",
+			max:    4,
+			queued: []*Task{group1Task1, group1Task2, group1Task3, group2Task1, group2Task2},
+			want:   []*Task{group1Task1, group2Task1, group1Task2, group2Task2},
+		},
+		{
+			name:    "run task from group with fewest running tasks",
+			max:     2,
+			queued:  []*Task{group1Task2, group1Task3, group2Task1},
+			running: []*Task{group1Task1},
+			want:    []*Task{group2Task1},
+		},
+		{
+			name:   "only one task matching cap is runnable",
+			max:    3,
+			queued: []*Task{prod1, prod2, dev1},
+			caps:   prodCap,
+			want:   []*Task{prod1, dev1},
+		},
+		{
+			name:    "no task matching cap is runnable because cap is reached",
+			max:     3,
+			queued:  []*Task{prod2, dev1},
+			running: []*Task{prod1},
+			caps:    prodCap,
+			want:    []*Task{dev1},
+		},
+		{
+			name:    "start immediate task, despite cap being reached",
+			max:     3,
+			queued:  []*Task{immediateProd},
+			running: []*Task{prod1},
+			caps:    prodCap,
+			want:    []*Task{immediateProd},
+		},
+		{
+			name:   "held task is not runnable",
+			max:    3,
+			queued: []*Task{held, t1},
+			want:   []*Task{t1},
+		},
+		{
+			name:   "only immediate task is runnable while queue is paused",
+			max:    3,
+			queued: []*Task{t1, immediate},
+			paused: true,
+			want:   []*Task{immediate},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -102,7 +182,9 @@ func TestRunner_runnable(t *testing.T) {
 					queued:    tt.queued,
 					running:   tt.running,
 					exclusive: tt.exclusive,
+					paused:    tt.paused,
 				},
+				caps: tt.caps,
 			}
 			assert.Equal(t, tt.want, runner.runnable())
 		})
@@ -111,6 +193,7 @@ func TestRunner_runnable(t *testing.T) {
 
 type fakeRunnerLister struct {
 	queued, running, exclusive []*Task
+	paused                     bool
 }
 
 func (f *fakeRunnerLister) List(opts ListOptions) []*Task {
@@ -125,3 +208,7 @@ func (f *fakeRunnerLister) List(opts ListOptions) []*Task {
 	}
 	return nil
 }
+
+func (f *fakeRunnerLister) Paused() bool {
+	return f.paused
+}
@@ -1,8 +1,15 @@
 package task
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"slices"
+	"sync/atomic"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/logging"
 	"github.com/leg100/pug/internal/pubsub"
@@ -14,6 +21,9 @@ type Service struct {
 	groups  *resource.Table[*Group]
 	counter *int
 	logger  logging.Interface
+	history *history
+	// paused is true if the task queue is paused.
+	paused atomic.Bool
 
 	TaskBroker  *pubsub.Broker[*Task]
 	GroupBroker *pubsub.Broker[*Group]
@@ -27,6 +37,29 @@ type ServiceOptions struct {
 	UserEnvs   []string
 	UserArgs   []string
 	Terragrunt bool
+	// DataDir is the directory in which task history is persisted, and to
+	// which task output is written once it exceeds MaxMemory. If empty then
+	// task history is neither persisted nor loaded, and task output is held
+	// entirely in memory.
+	DataDir string
+	// MaxMemory is the maximum number of bytes of output held in memory per
+	// task.
+	MaxMemory int
+	// History configures the retention of task history.
+	History HistoryOptions
+	// Timeouts are the default timeouts for each task identifier.
+	Timeouts map[Identifier]time.Duration
+	// GracePeriod is the duration to wait after interrupting a task, either
+	// because it was canceled or it timed out, before killing it.
+	GracePeriod time.Duration
+	// RetryPolicy is the default policy for automatically retrying tasks that
+	// fail with a transient error. If nil then tasks are not retried unless
+	// their spec specifies a retry policy.
+	RetryPolicy *RetryPolicy
+	// PreHooks and PostHooks are user-defined shell commands run before and
+	// after tasks.
+	PreHooks  []Hook
+	PostHooks []Hook
 }
 
 func NewService(opts ServiceOptions) *Service {
@@ -36,16 +69,21 @@ func NewService(opts ServiceOptions) *Service {
 	groupBroker := pubsub.NewBroker[*Group](opts.Logger)
 
 	factory := &factory{
-		publisher:  taskBroker,
-		counter:    &counter,
-		program:    opts.Program,
-		workdir:    opts.Workdir,
-		userEnvs:   opts.UserEnvs,
-		userArgs:   opts.UserArgs,
-		terragrunt: opts.Terragrunt,
+		publisher:   taskBroker,
+		counter:     &counter,
+		program:     opts.Program,
+		workdir:     opts.Workdir,
+		userEnvs:    opts.UserEnvs,
+		userArgs:    opts.UserArgs,
+		terragrunt:  opts.Terragrunt,
+		timeouts:    opts.Timeouts,
+		gracePeriod: opts.GracePeriod,
+		retryPolicy: opts.RetryPolicy,
+		preHooks:    opts.PreHooks,
+		postHooks:   opts.PostHooks,
 	}
 
-	return &Service{
+	svc := &Service{
 		tasks:       resource.NewTable(taskBroker),
 		groups:      resource.NewTable(groupBroker),
 		TaskBroker:  taskBroker,
@@ -53,7 +91,87 @@ func NewService(opts ServiceOptions) *Service {
 		factory:     factory,
 		counter:     &counter,
 		logger:      opts.Logger,
+		history:     &history{HistoryOptions: opts.History},
+	}
+	if opts.DataDir != "" {
+		svc.history.dir = filepath.Join(opts.DataDir, historyDirName)
+		// Each pug session writes output to its own directory, so that
+		// concurrent sessions sharing a data directory do not remove one
+		// another's output.
+		factory.outputDir = filepath.Join(opts.DataDir, outputDirName, uuid.NewString())
+		factory.maxMemory = opts.MaxMemory
+	}
+	return svc
+}
+
+// LoadHistory loads tasks and task groups persisted by previous pug sessions.
+// The loaded tasks are read-only and marked as historical.
+func (s *Service) LoadHistory() error {
+	tasks, groups, err := s.history.load()
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		s.tasks.Add(t.ID, t)
+	}
+	for _, g := range groups {
+		s.groups.Add(g.ID, g)
+	}
+	s.logger.Info("loaded task history", "tasks", len(tasks), "groups", len(groups))
+	return nil
+}
+
+// historyGCInterval is how often task history exceeding the retention limits is
+// removed.
+const historyGCInterval = time.Hour
+
+// StartHistoryGC periodically removes task history exceeding the retention
+// limits, until the context is canceled. Historical tasks and groups whose
+// history is removed are deleted.
+func (s *Service) StartHistoryGC(ctx context.Context) {
+	if s.history.disabled() {
+		return
+	}
+	gc := func() {
+		tasks, groups, err := s.history.gc(time.Now())
+		if err != nil {
+			s.logger.Error("removing task history", "error", err)
+		}
+		if len(tasks) > 0 {
+			s.logger.Info("removed task history", "tasks", len(tasks), "groups", len(groups))
+		}
+		for _, t := range s.tasks.List() {
+			if t.History != nil && slices.Contains(tasks, t.historyID) {
+				s.tasks.Delete(t.ID)
+			}
+		}
+		for _, g := range s.groups.List() {
+			if slices.Contains(groups, g.historyID) && g.historical() {
+				s.groups.Delete(g.ID)
+			}
+		}
+	}
+	gc()
+	go func() {
+		ticker := time.NewTicker(historyGCInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				gc()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// RemoveOutput removes task output written to disk during this session.
+func (s *Service) RemoveOutput() error {
+	if s.outputDir == "" {
+		return nil
 	}
+	return os.RemoveAll(s.outputDir)
 }
 
 // Create a task. The task is placed into a pending state and requires enqueuing
@@ -74,13 +192,26 @@ func (s *Service) Create(spec Spec) (*Task, error) {
 	if spec.AfterCreate != nil {
 		spec.AfterCreate(task)
 	}
+	// Link previous attempt to this attempt
+	if spec.retryOf != nil {
+		s.tasks.Update(spec.retryOf.ID, func(existing *Task) error {
+			existing.retriedBy.Store(task)
+			return nil
+		})
+	}
 
 	wait := make(chan error, 1)
 	go func() {
 		err := task.Wait()
+		if err := s.history.saveTask(task); err != nil {
+			s.logger.Error("saving task history", "error", err, "task", task)
+		}
 		wait <- err
 		if err != nil {
 			s.logger.Error("task failed", "error", err, "task", task)
+			if task.WillRetry() {
+				s.retry(task)
+			}
 			return
 		}
 		s.logger.Info("completed task", "task", task)
@@ -91,10 +222,33 @@ func (s *Service) Create(spec Spec) (*Task, error) {
 	return task, nil
 }
 
-// Create a task group from one or more task specs. An error is returned if zero
-// specs are provided, or if it fails to create at least one task.
-func (s *Service) CreateGroup(specs ...Spec) (*Group, error) {
-	g, err := newGroup(s, specs...)
+// retry creates a new attempt of a failed task after a backoff delay.
+func (s *Service) retry(t *Task) {
+	delay := t.retryPolicy.delay(t.Attempt)
+	s.logger.Info("retrying task", "task", t, "attempt", t.Attempt+1, "delay", delay)
+
+	time.AfterFunc(delay, func() {
+		spec := t.Spec
+		spec.Wait = false
+		spec.retryOf = t
+		if _, err := s.Create(spec); err != nil {
+			s.logger.Error("retrying task", "error", err, "task", t)
+			// Stop dependent tasks from waiting for a retry that will never
+			// happen.
+			s.tasks.Update(t.ID, func(existing *Task) error {
+				existing.retrying.Store(false)
+				return nil
+			})
+		}
+	})
+}
+
+// Create a task group from one or more task specs. The failure policy determines
+// whether the group's remaining tasks are canceled when tasks fail. An error is
+// returned if zero specs are provided, or if it fails to create at least one
+// task.
+func (s *Service) CreateGroup(policy FailurePolicy, specs ...Spec) (*Group, error) {
+	g, err := newGroup(s, policy, specs...)
 	if err != nil {
 		return nil, err
 	}
@@ -104,9 +258,39 @@ func (s *Service) CreateGroup(specs ...Spec) (*Group, error) {
 	// Add to db
 	s.AddGroup(g)
 
+	if err := s.history.saveGroup(g); err != nil {
+		s.logger.Error("saving task group history", "error", err, "group", g)
+	}
+
 	return g, nil
 }
 
+// SetGroupFailurePolicy changes the failure policy of a task group. If the
+// group's failures have already reached the maximum of the new policy then its
+// pending and queued tasks are canceled.
+func (s *Service) SetGroupFailurePolicy(groupID resource.ID, policy FailurePolicy) (*Group, error) {
+	var cancel []*Task
+	group, err := s.groups.Update(groupID, func(existing *Group) (err error) {
+		if existing.historical() {
+			return errors.New("cannot change the failure policy of a historical task group")
+		}
+		cancel, err = existing.setFailurePolicy(policy)
+		return err
+	})
+	if err != nil {
+		s.logger.Error("setting task group failure policy", "group", groupID, "error", err)
+		return nil, err
+	}
+	for _, t := range cancel {
+		_ = t.cancel()
+	}
+	if err := s.history.saveGroup(group); err != nil {
+		s.logger.Error("saving task group history", "error", err, "group", group)
+	}
+	s.logger.Info("set task group failure policy", "group", group, "policy", policy)
+	return group, nil
+}
+
 // AddGroup adds a task group to the DB.
 func (s *Service) AddGroup(group *Group) {
 	s.groups.Add(group.ID, group)
@@ -4,15 +4,15 @@ package task
 //
 // 1. running (ordered by last updated desc)
 // 2. queued (ordered by last updated asc)
-// 3. pending (ordered by last updated asc)
+// 3. pending (ordered by queue order)
 // 4. finished (ordered by last updated desc)
 func ByState(i, j *Task) int {
 	switch i.State {
 	case Pending:
 		switch j.State {
 		case Pending:
-			// pending==pending, ordered by last updated desc
-			if i.Updated.Before(j.Updated) {
+			// pending==pending, ordered by queue order
+			if byQueueOrder(i, j) > 0 {
 				return 1
 			}
 			return -1
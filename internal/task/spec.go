@@ -1,6 +1,11 @@
 package task
 
-import "github.com/leg100/pug/internal/resource"
+import (
+	"io"
+	"time"
+
+	"github.com/leg100/pug/internal/resource"
+)
 
 // Spec is a specification for creating a task.
 type Spec struct {
@@ -28,10 +33,32 @@ type Spec struct {
 	Exclusive bool
 	// Set to true to indicate that the task produces JSON output
 	JSON bool
+	// RenderStdout, if non-nil, renders the standard output of the program
+	// before it is written to the combined output stream, e.g. to render
+	// machine-readable output in a human-readable form. The standard output
+	// stream itself is left unaltered. The renderer is closed once the
+	// program has finished. The renderer may report the progress of the
+	// task via Task.ReportProgress.
+	RenderStdout func(*Task, io.Writer) io.WriteCloser
 	// Skip queue and immediately start task
 	Immediate bool
+	// Priority determines the order in which tasks are enqueued and run:
+	// tasks with a higher priority are enqueued and run before tasks with a
+	// lower priority.
+	Priority int
+	// Timeout is the maximum duration the task may run for before it is
+	// interrupted and placed into the timed out state. If zero then the
+	// default timeout for the task's identifier is used, and if there is no
+	// such default then the task never times out.
+	Timeout time.Duration
+	// Retry specifies the automatic retry of the task should it fail with a
+	// transient error. If nil then the default retry policy is used.
+	Retry *RetryPolicy
 	// Wait blocks until the task has finished
 	Wait bool
+	// Variables are variable overrides passed to the program, listed
+	// separately to the user.
+	Variables []string
 	// Description assigns an optional description to the task to display to the
 	// user, overriding the default of displaying the command.
 	Description string
@@ -47,6 +74,10 @@ type Spec struct {
 	AfterRunning func(*Task)
 	// Call this function after the task fails with an error
 	AfterError func(*Task)
+	// WrapError, if non-nil, is called when the task fails, with the task's
+	// error, and returns an error with which to replace it, e.g. a more
+	// specific error parsed from the task's output.
+	WrapError func(*Task, error) error
 	// Call this function after the task is successfully canceled
 	AfterCanceled func(*Task)
 	// Call this function after the task is successfully created
@@ -62,8 +93,21 @@ type Spec struct {
 	// task can be enqueued. If any of the other tasks are canceled or error
 	// then the task will be canceled.
 	dependsOn []resource.ID
+	// retryOf is the previous attempt of the task, if the task is an automatic
+	// retry.
+	retryOf *Task
+	// groupID is the ID of the task group the task belongs to, if any.
+	groupID *resource.ID
 }
 
+// Task priorities.
+const (
+	DefaultPriority = 0
+	// HighPriority is for interactive tasks that the user is waiting upon,
+	// such as reloading state.
+	HighPriority = 10
+)
+
 // SpecFunc is a function that creates a spec.
 type SpecFunc func(resource.ID) (Spec, error)
 
@@ -12,14 +12,15 @@ const (
 	Exited   Status = "exited"
 	Errored  Status = "errored"
 	Canceled Status = "canceled"
+	TimedOut Status = "timed out"
 
-	MaxStatusLen = len(Canceled)
+	MaxStatusLen = len(TimedOut)
 )
 
 // IsFinal returns true if the state is a final state.
 func (s Status) IsFinal() bool {
 	switch s {
-	case Errored, Exited, Canceled:
+	case Errored, Exited, Canceled, TimedOut:
 		return true
 	default:
 		return false
@@ -9,8 +9,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/leg100/pug/internal"
@@ -26,6 +28,7 @@ type Task struct {
 
 	ModuleID            *resource.ID
 	WorkspaceID         *resource.ID
+	GroupID             *resource.ID
 	Identifier          Identifier
 	Program             string
 	Args                []string
@@ -35,15 +38,59 @@ type Task struct {
 	State               Status
 	JSON                bool
 	Immediate           bool
-	AdditionalEnv       []string
-	DependsOn           []resource.ID
+	Priority            int
+	// Timeout is the maximum duration the task may run for. Zero means the
+	// task never times out.
+	Timeout       time.Duration
+	AdditionalEnv []string
+	DependsOn     []resource.ID
+	// PreHooks are shell commands run before the program. If any fail then
+	// the program is not run and the task fails.
+	PreHooks []string
+	// PostHooks are shell commands run after the program, regardless of
+	// whether it succeeded.
+	PostHooks []string
+	// Variables are variable overrides passed to the program.
+	Variables []string
+	// Attempt is the number of times the task has been attempted, starting
+	// at one.
+	Attempt int
+	// RetryOf is the previous attempt of the task. Nil if the task is not an
+	// automatic retry.
+	RetryOf *Task
+	// Held is true if the task is prevented from being enqueued or started
+	// until it is released.
+	Held bool
 	// Summary summarises the outcome of a task to the end-user.
 	Summary     Summary
 	Description string
 
 	exclusive bool
+	// position is the task's position in the queue relative to other tasks
+	// of equal priority.
+	position int64
+	// gracePeriod is the duration to wait after interrupting the task before
+	// killing it. Zero means the task is never killed.
+	gracePeriod time.Duration
+	// retryPolicy specifies the automatic retry of the task.
+	retryPolicy *RetryPolicy
+	// retrying is true if the task has failed and is to be automatically
+	// retried.
+	retrying atomic.Bool
+	// retriedBy is the next attempt of the task.
+	retriedBy atomic.Pointer[Task]
 	// terragrunt is true if terragrunt is in use.
 	terragrunt bool
+	// renderStdout renders the program's standard output before it is
+	// written to the combined output stream.
+	renderStdout func(*Task, io.Writer) io.WriteCloser
+	// progress is the latest progress reported by the task.
+	progress atomic.Pointer[Progress]
+	// progressSummary is the summary of the latest progress reported by the
+	// task.
+	progressSummary atomic.Pointer[string]
+	// warning is a warning attached to the task after it has finished.
+	warning atomic.Pointer[string]
 
 	// Nil until task has started
 	proc *os.Process
@@ -73,6 +120,11 @@ type Task struct {
 	// the task can be retried.
 	Spec Spec
 
+	// History is non-nil if the task was loaded from a previous pug session.
+	History *History
+	// historyID uniquely identifies the task across pug sessions.
+	historyID string
+
 	AfterCreate   func(*Task)
 	AfterQueued   func(*Task)
 	AfterRunning  func(*Task)
@@ -81,6 +133,7 @@ type Task struct {
 	AfterError    func(*Task)
 	AfterCanceled func(*Task)
 	AfterFinish   func(*Task)
+	wrapError     func(*Task, error) error
 	afterUpdate   func(*Task)
 	afterFinish   func(*Task)
 }
@@ -96,6 +149,35 @@ type factory struct {
 	userArgs []string
 	// Terragrunt mode
 	terragrunt bool
+	// outputDir is the directory to which task output exceeding maxMemory is
+	// written. If empty then task output is held entirely in memory.
+	outputDir string
+	// maxMemory is the maximum number of bytes of output held in memory per
+	// task.
+	maxMemory int
+	// timeouts are the default timeouts for each task identifier.
+	timeouts map[Identifier]time.Duration
+	// gracePeriod is the duration to wait after interrupting a task before
+	// killing it.
+	gracePeriod time.Duration
+	// retryPolicy is the default retry policy for tasks.
+	retryPolicy *RetryPolicy
+	// preHooks and postHooks are user-defined hooks run before and after
+	// tasks.
+	preHooks  []Hook
+	postHooks []Hook
+	// positions is a counter from which each new task is assigned a position
+	// in the queue.
+	positions atomic.Int64
+}
+
+// newBuffer constructs an output buffer for a task. The task's memory limit is
+// split evenly between its two buffers.
+func (f *factory) newBuffer(taskID, name string) *buffer {
+	if f.outputDir == "" {
+		return newBuffer()
+	}
+	return newDiskBuffer(filepath.Join(f.outputDir, taskID, name), f.maxMemory/2)
 }
 
 // Summary summarises the outcome of a task.
@@ -103,40 +185,60 @@ type Summary interface {
 	String() string
 }
 
+// Progress reports the progress of a running task.
+type Progress interface {
+	String() string
+}
+
 // TODO: check presence of mandatory options
 // TODO: use values from spec directly - embed spec in task and use those values
 func (f *factory) newTask(spec Spec) (*Task, error) {
 	if spec.WorkspaceID != nil && spec.ModuleID == nil {
 		return nil, errors.New("workspace ID cannot be provided without module ID")
 	}
+	historyID := newHistoryID()
 	task := &Task{
 		ID:                  resource.NewID(resource.Task),
 		ModuleID:            spec.ModuleID,
 		WorkspaceID:         spec.WorkspaceID,
+		GroupID:             spec.groupID,
 		Identifier:          spec.Identifier,
 		State:               Pending,
 		Created:             time.Now(),
 		Updated:             time.Now(),
 		finished:            make(chan struct{}),
-		stdout:              newBuffer(),
-		combined:            newBuffer(),
+		stdout:              f.newBuffer(historyID, "stdout"),
+		combined:            f.newBuffer(historyID, "combined"),
 		terragrunt:          f.terragrunt,
 		Path:                filepath.Join(f.workdir.String(), spec.Path),
 		AdditionalExecution: spec.AdditionalExecution,
 		AdditionalEnv:       append(f.userEnvs, spec.Env...),
 		JSON:                spec.JSON,
+		renderStdout:        spec.RenderStdout,
 		Blocking:            spec.Blocking,
 		DependsOn:           spec.dependsOn,
+		PreHooks:            matchingHooks(f.preHooks, spec),
+		PostHooks:           matchingHooks(f.postHooks, spec),
+		Variables:           spec.Variables,
 		Immediate:           spec.Immediate,
+		Priority:            spec.Priority,
+		Timeout:             spec.Timeout,
+		Attempt:             1,
+		position:            f.positions.Add(1),
+		RetryOf:             spec.retryOf,
+		retryPolicy:         spec.Retry,
 		exclusive:           spec.Exclusive,
+		gracePeriod:         f.gracePeriod,
 		Description:         spec.Description,
 		Spec:                spec,
+		historyID:           historyID,
 		AfterCreate:         spec.AfterCreate,
 		AfterRunning:        spec.AfterRunning,
 		AfterQueued:         spec.AfterQueued,
 		BeforeExited:        spec.BeforeExited,
 		AfterExited:         spec.AfterExited,
 		AfterError:          spec.AfterError,
+		wrapError:           spec.WrapError,
 		AfterCanceled:       spec.AfterCanceled,
 		AfterFinish:         spec.AfterFinish,
 		// Publish an event whenever task state is updated
@@ -157,6 +259,18 @@ func (f *factory) newTask(spec Spec) (*Task, error) {
 			},
 		},
 	}
+	if task.Timeout == 0 {
+		task.Timeout = f.timeouts[spec.Identifier]
+	}
+	if task.retryPolicy == nil {
+		task.retryPolicy = f.retryPolicy
+	}
+	if spec.retryOf != nil {
+		task.Attempt = spec.retryOf.Attempt + 1
+		// A manual retry of this task should start afresh rather than be
+		// treated as a further attempt.
+		task.Spec.retryOf = nil
+	}
 	// Determine the program and the args to pass to program.
 	if spec.Execution.Program == "" {
 		// Is terraform task
@@ -168,6 +282,14 @@ func (f *factory) newTask(spec Spec) (*Task, error) {
 	}
 	task.Args = append(task.Args, f.userArgs...)
 	task.Args = append(task.Args, spec.Execution.Args...)
+	// An additional terraform execution is likewise run with the configured
+	// program.
+	if add := spec.AdditionalExecution; add != nil && add.Program == "" {
+		task.AdditionalExecution = &Execution{
+			Program: f.program,
+			Args:    append(slices.Clone(add.TerraformCommand), add.Args...),
+		}
+	}
 
 	// If description is not explicitly set then set it using provided terraform
 	// commands or - if this is not a terraform execution - then using the
@@ -187,6 +309,9 @@ func (f *factory) newTask(spec Spec) (*Task, error) {
 	if task.Program == "terragrunt" && f.terragrunt {
 		task.AdditionalEnv = append(task.AdditionalEnv, "TERRAGRUNT_FORWARD_TF_STDOUT=1")
 		task.Args = append(task.Args, "--terragrunt-non-interactive")
+		if task.AdditionalExecution != nil && spec.AdditionalExecution.Program == "" {
+			task.AdditionalExecution.Args = append(task.AdditionalExecution.Args, "--terragrunt-non-interactive")
+		}
 	}
 	return task, nil
 }
@@ -211,6 +336,87 @@ func (t *Task) NewStreamer() <-chan []byte {
 	return t.combined.Stream()
 }
 
+// fairnessKey identifies the set of tasks amongst which the runner shares
+// capacity fairly: the task's group, or failing that its module, or failing that
+// the task itself.
+func (t *Task) fairnessKey() resource.ID {
+	if t.GroupID != nil {
+		return *t.GroupID
+	}
+	if t.ModuleID != nil {
+		return *t.ModuleID
+	}
+	return t.ID
+}
+
+// ByPriority sorts tasks by priority, highest first.
+func ByPriority(i, j *Task) int {
+	return j.Priority - i.Priority
+}
+
+// ReportProgress reports the progress of a running task, informing
+// subscribers that the task has been updated if the summary of its progress
+// has changed.
+func (t *Task) ReportProgress(p Progress) {
+	t.progress.Store(&p)
+	summary := p.String()
+	if previous := t.progressSummary.Swap(&summary); previous != nil && *previous == summary {
+		return
+	}
+	if t.afterUpdate != nil {
+		t.afterUpdate(t)
+	}
+}
+
+// Progress returns the latest progress reported by the task, or nil if no
+// progress has been reported.
+func (t *Task) Progress() Progress {
+	if p := t.progress.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// Warn attaches a warning to the task, e.g. that the plan it created has
+// become stale, informing subscribers that the task has been updated.
+func (t *Task) Warn(warning string) {
+	t.warning.Store(&warning)
+	if t.afterUpdate != nil {
+		t.afterUpdate(t)
+	}
+}
+
+// Warning returns the warning attached to the task, or an empty string if
+// there is no warning.
+func (t *Task) Warning() string {
+	if w := t.warning.Load(); w != nil {
+		return *w
+	}
+	return ""
+}
+
+// WillRetry returns true if the task has failed and is to be automatically
+// retried.
+func (t *Task) WillRetry() bool {
+	return t.retrying.Load()
+}
+
+// RetriedBy returns the next attempt of the task. Nil if the task has not been
+// automatically retried.
+func (t *Task) RetriedBy() *Task {
+	return t.retriedBy.Load()
+}
+
+// LatestAttempt returns the most recent attempt of the task, following any
+// automatic retries.
+func (t *Task) LatestAttempt() *Task {
+	latest := t
+	for next := latest.RetriedBy(); next != nil; next = latest.RetriedBy() {
+		latest = next
+	}
+	return latest
+}
+
 func (t *Task) IsActive() bool {
 	switch t.State {
 	case Queued, Running:
@@ -275,48 +481,106 @@ func (t *Task) cancel() error {
 	defer t.mu.Unlock()
 
 	switch t.State {
-	case Exited, Errored, Canceled:
+	case Exited, Errored, Canceled, TimedOut:
 		return errors.New("task has already finished")
 	case Pending, Queued:
 		t.updateState(Canceled)
 		return nil
 	default: // running
-		return t.proc.Signal(os.Interrupt)
+		if err := t.proc.Signal(os.Interrupt); err != nil {
+			return err
+		}
+		// Kill the task if it has not finished within the grace period.
+		if t.gracePeriod > 0 {
+			proc := t.proc
+			time.AfterFunc(t.gracePeriod, func() {
+				select {
+				case <-t.finished:
+				default:
+					_ = proc.Kill()
+				}
+			})
+		}
+		return nil
 	}
 }
 
 func (t *Task) start(ctx context.Context) (func(), error) {
-	cmd := t.execute(ctx, t.Program, t.Args)
+	// The timeout applies to the program, any additional program, and any
+	// hooks.
+	cancel := func() {}
+	if t.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.Timeout)
+	}
+	// Commands are run in order: pre-hooks, the program, and then any
+	// additional program.
+	var cmds []*exec.Cmd
+	for _, hook := range t.PreHooks {
+		cmds = append(cmds, t.executeHook(ctx, hook))
+	}
+	program := t.execute(ctx, t.Program, t.Args)
+	var renderer io.WriteCloser
+	if t.renderStdout != nil {
+		renderer = t.renderStdout(t, t.combined)
+		program.Stdout = io.MultiWriter(t.stdout, renderer)
+	}
+	cmds = append(cmds, program)
+	if t.AdditionalExecution != nil {
+		cmds = append(cmds, t.execute(ctx, t.AdditionalExecution.Program, t.AdditionalExecution.Args))
+	}
 
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	if t.State != Queued {
+		cancel()
 		return nil, errors.New("invalid state transition")
 	}
 
-	if err := cmd.Start(); err != nil {
+	if err := cmds[0].Start(); err != nil {
+		cancel()
 		t.updateState(Errored)
 		t.Err = fmt.Errorf("starting task: %w", err)
 		return nil, err
 	}
 	t.updateState(Running)
 	// save reference to process so that it can be cancelled via cancel()
-	t.proc = cmd.Process
+	t.proc = cmds[0].Process
 
 	wait := func() {
+		defer cancel()
+
+		// Run each command in turn, stopping at the first to fail.
+		err := cmds[0].Wait()
+		i := 0
+		for err == nil && i < len(cmds)-1 {
+			i++
+			err = t.run(cmds[i])
+		}
+		if renderer != nil {
+			// Flush any output the renderer has buffered.
+			renderer.Close()
+		}
 		state := Exited
-		if err := cmd.Wait(); err != nil {
+		if err != nil {
 			state = Errored
-			t.Err = fmt.Errorf("task failed: %w", err)
-		} else if t.AdditionalExecution != nil {
-			// Execute additional program.
-			cmd = t.execute(ctx, t.AdditionalExecution.Program, t.AdditionalExecution.Args)
-			if err := cmd.Run(); err != nil {
-				state = Errored
+			if i < len(t.PreHooks) {
+				t.Err = fmt.Errorf("pre-hook failed: %s: %w", t.PreHooks[i], err)
+			} else {
 				t.Err = fmt.Errorf("task failed: %w", err)
 			}
 		}
+		// A program interrupted upon timing out may nonetheless exit
+		// successfully, so the timeout is checked regardless of the outcome.
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			state = TimedOut
+			t.Err = fmt.Errorf("task timed out after %s", t.Timeout)
+		}
+		// Post-hooks are run regardless of whether the program succeeded,
+		// but not if a pre-hook failed.
+		if i >= len(t.PreHooks) {
+			t.runPostHooks(ctx, &state)
+		}
 
 		t.mu.Lock()
 		t.updateState(state)
@@ -325,6 +589,50 @@ func (t *Task) start(ctx context.Context) (func(), error) {
 	return wait, nil
 }
 
+// runPostHooks runs the post-hooks, updating the state if a hook fails a task
+// that has otherwise succeeded. If the task's context is already done, e.g.
+// because the task timed out, then the hooks are instead run with a context
+// that expires after the grace period.
+func (t *Task) runPostHooks(ctx context.Context, state *Status) {
+	if ctx.Err() != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.WithoutCancel(ctx), t.gracePeriod)
+		defer cancel()
+	}
+	for _, hook := range t.PostHooks {
+		cmd := t.executeHook(ctx, hook)
+		cmd.Env = append(cmd.Env, fmt.Sprintf("PUG_TASK_STATUS=%s", *state))
+		if err := t.run(cmd); err != nil && *state == Exited {
+			*state = Errored
+			t.Err = fmt.Errorf("post-hook failed: %s: %w", hook, err)
+		}
+	}
+}
+
+// run starts a command, saving a reference to its process so that it can be
+// cancelled via cancel(), and waits for it to finish.
+func (t *Task) run(cmd *exec.Cmd) error {
+	t.mu.Lock()
+	err := cmd.Start()
+	if err == nil {
+		t.proc = cmd.Process
+	}
+	t.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return cmd.Wait()
+}
+
+// executeHook prepares a hook's shell command for execution. The hook's
+// output is written only to the combined output stream, so as not to
+// interfere with parsing the program's standard output.
+func (t *Task) executeHook(ctx context.Context, hook string) *exec.Cmd {
+	cmd := t.execute(ctx, "sh", []string{"-c", hook})
+	cmd.Stdout = t.combined
+	return cmd
+}
+
 func (t *Task) execute(ctx context.Context, program string, args []string) *exec.Cmd {
 	// Use the provided context to kill the program if the context becomes done,
 	// but also to prevent the program from starting if the context becomes done.
@@ -333,6 +641,9 @@ func (t *Task) execute(ctx context.Context, program string, args []string) *exec
 		// Kill program gracefully
 		return cmd.Process.Signal(os.Interrupt)
 	}
+	// If the program has not terminated within the grace period then kill
+	// it.
+	cmd.WaitDelay = t.gracePeriod
 	cmd.Dir = t.Path
 	cmd.Stdout = io.MultiWriter(t.stdout, t.combined)
 	cmd.Stderr = t.combined
@@ -374,6 +685,16 @@ func (t *Task) updateState(state Status) {
 		}
 		t.Summary = summary
 	}
+	if (state == Errored || state == TimedOut) && t.Err != nil && t.wrapError != nil {
+		t.Err = t.wrapError(t, t.Err)
+	}
+
+	// Determine whether a failed task should be automatically retried. This is
+	// determined before the state is updated so that any tasks depending on
+	// this task wait for the retry rather than being canceled.
+	if state == Errored && t.retryPolicy.shouldRetry(t) {
+		t.retrying.Store(true)
+	}
 
 	t.State = state
 	if t.afterUpdate != nil {
@@ -404,7 +725,7 @@ func (t *Task) updateState(state Status) {
 		if t.AfterCanceled != nil {
 			t.AfterCanceled(t)
 		}
-	case Errored:
+	case Errored, TimedOut:
 		if t.AfterError != nil {
 			t.AfterError(t)
 		}
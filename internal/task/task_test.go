@@ -1,9 +1,13 @@
 package task
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/leg100/pug/internal"
 	"github.com/stretchr/testify/assert"
@@ -73,6 +77,70 @@ func TestTask_cancel(t *testing.T) {
 	assert.Equal(t, Exited, task.State)
 }
 
+func TestTask_cancel_kill(t *testing.T) {
+	t.Parallel()
+
+	f := factory{
+		counter:     internal.Int(0),
+		program:     "./testdata/ignoreme",
+		publisher:   &fakePublisher[*Task]{},
+		gracePeriod: 100 * time.Millisecond,
+	}
+	task, err := f.newTask(Spec{})
+	require.NoError(t, err)
+
+	task.updateState(Queued)
+	waitfn, err := task.start(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte("you can try to kill me\n"), <-task.NewStreamer())
+	require.NoError(t, task.cancel())
+	waitfn()
+	assert.Equal(t, Errored, task.State)
+}
+
+func TestTask_timeout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		program string
+	}{
+		{"interrupted", "./testdata/killme"},
+		{"killed", "./testdata/ignoreme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := factory{
+				counter:     internal.Int(0),
+				program:     tt.program,
+				publisher:   &fakePublisher[*Task]{},
+				gracePeriod: 100 * time.Millisecond,
+				timeouts:    map[Identifier]time.Duration{"plan": 100 * time.Millisecond},
+			}
+			var afterError bool
+			task, err := f.newTask(Spec{
+				Identifier: "plan",
+				WrapError: func(_ *Task, err error) error {
+					return fmt.Errorf("wrapped: %w", err)
+				},
+				AfterError: func(*Task) { afterError = true },
+			})
+			require.NoError(t, err)
+			assert.Equal(t, 100*time.Millisecond, task.Timeout)
+
+			task.updateState(Queued)
+			waitfn, err := task.start(context.Background())
+			require.NoError(t, err)
+			waitfn()
+
+			assert.Equal(t, TimedOut, task.State)
+			assert.EqualError(t, task.Err, "wrapped: task timed out after 100ms")
+			assert.True(t, afterError, "timed out task should trigger error handler")
+		})
+	}
+}
+
 // func TestTask_WaitFor_immediateExit(t *testing.T) {
 // 	f := factory{program: "../testdata/task"}
 // 	task, err := f.newTask(".")
@@ -106,3 +174,215 @@ func TestTask_cancel(t *testing.T) {
 // 	// verify task exits
 // 	require.True(t, <-got)
 // }
+
+func TestTask_hooks(t *testing.T) {
+	t.Parallel()
+
+	run := func(t *testing.T, f *factory) *Task {
+		task, err := f.newTask(Spec{Identifier: "plan"})
+		require.NoError(t, err)
+		task.updateState(Queued)
+		waitfn, err := task.start(context.Background())
+		require.NoError(t, err)
+		waitfn()
+		return task
+	}
+
+	t.Run("run hooks", func(t *testing.T) {
+		task := run(t, &factory{
+			counter:   internal.Int(0),
+			program:   "./testdata/task",
+			publisher: &fakePublisher[*Task]{},
+			preHooks:  []Hook{{Match: "plan", Command: "echo pre"}, {Match: "apply", Command: "echo apply"}},
+			postHooks: []Hook{{Match: "plan", Command: "echo post $PUG_TASK_STATUS"}},
+		})
+		assert.Equal(t, Exited, task.State)
+		assert.Equal(t, []string{"echo pre"}, task.PreHooks)
+
+		// Hook output is excluded from stdout
+		got, err := io.ReadAll(task.NewReader(false))
+		require.NoError(t, err)
+		assert.Equal(t, "foo\nbar\nbaz\nbye\n", string(got))
+
+		got, err = io.ReadAll(task.NewReader(true))
+		require.NoError(t, err)
+		assert.Regexp(t, `(?s)^pre\n.*bye\n.*post exited\n$`, string(got))
+	})
+
+	t.Run("failing pre-hook aborts task", func(t *testing.T) {
+		task := run(t, &factory{
+			counter:   internal.Int(0),
+			program:   "./testdata/task",
+			publisher: &fakePublisher[*Task]{},
+			preHooks:  []Hook{{Match: "plan", Command: "exit 1"}},
+			postHooks: []Hook{{Match: "plan", Command: "echo post"}},
+		})
+		assert.Equal(t, Errored, task.State)
+		assert.ErrorContains(t, task.Err, "pre-hook failed: exit 1")
+
+		got, err := io.ReadAll(task.NewReader(true))
+		require.NoError(t, err)
+		assert.Empty(t, string(got))
+	})
+
+	t.Run("failing post-hook fails task", func(t *testing.T) {
+		task := run(t, &factory{
+			counter:   internal.Int(0),
+			program:   "./testdata/task",
+			publisher: &fakePublisher[*Task]{},
+			postHooks: []Hook{{Match: "plan", Command: "exit 1"}},
+		})
+		assert.Equal(t, Errored, task.State)
+		assert.ErrorContains(t, task.Err, "post-hook failed: exit 1")
+	})
+
+	t.Run("run post-hook after timeout", func(t *testing.T) {
+		task := run(t, &factory{
+			counter:     internal.Int(0),
+			program:     "./testdata/killme",
+			publisher:   &fakePublisher[*Task]{},
+			gracePeriod: time.Second,
+			timeouts:    map[Identifier]time.Duration{"plan": 100 * time.Millisecond},
+			postHooks:   []Hook{{Match: "plan", Command: "echo post $PUG_TASK_STATUS"}},
+		})
+		assert.Equal(t, TimedOut, task.State)
+
+		got, err := io.ReadAll(task.NewReader(true))
+		require.NoError(t, err)
+		assert.Regexp(t, `(?s)post timed out\n$`, string(got))
+	})
+
+	t.Run("kill post-hook after grace period following timeout", func(t *testing.T) {
+		start := time.Now()
+		task := run(t, &factory{
+			counter:     internal.Int(0),
+			program:     "./testdata/killme",
+			publisher:   &fakePublisher[*Task]{},
+			gracePeriod: 100 * time.Millisecond,
+			timeouts:    map[Identifier]time.Duration{"plan": 100 * time.Millisecond},
+			postHooks:   []Hook{{Match: "plan", Command: "sleep 10"}},
+		})
+		assert.Equal(t, TimedOut, task.State)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
+
+func TestTask_WrapError(t *testing.T) {
+	t.Parallel()
+
+	f := &factory{
+		counter:   internal.Int(0),
+		program:   "false",
+		publisher: &fakePublisher[*Task]{},
+	}
+	wrapped := errors.New("wrapped")
+	task, err := f.newTask(Spec{
+		Identifier: "plan",
+		WrapError: func(_ *Task, err error) error {
+			return fmt.Errorf("%w: %w", wrapped, err)
+		},
+	})
+	require.NoError(t, err)
+	task.updateState(Queued)
+	waitfn, err := task.start(context.Background())
+	require.NoError(t, err)
+	waitfn()
+
+	assert.Equal(t, Errored, task.State)
+	assert.ErrorIs(t, task.Err, wrapped)
+	assert.ErrorContains(t, task.Err, "task failed: exit status 1")
+}
+
+func TestTask_RenderStdout(t *testing.T) {
+	t.Parallel()
+
+	f := factory{
+		counter:   internal.Int(0),
+		program:   "./testdata/task",
+		publisher: &fakePublisher[*Task]{},
+	}
+	task, err := f.newTask(Spec{
+		RenderStdout: func(_ *Task, w io.Writer) io.WriteCloser { return &upperWriter{w: w} },
+	})
+	require.NoError(t, err)
+	task.updateState(Queued)
+	waitfn, err := task.start(context.Background())
+	require.NoError(t, err)
+	waitfn()
+
+	// Stdout is unaltered
+	got, err := io.ReadAll(task.NewReader(false))
+	require.NoError(t, err)
+	assert.Equal(t, "foo\nbar\nbaz\nbye\n", string(got))
+
+	// Combined output contains rendered stdout
+	got, err = io.ReadAll(task.NewReader(true))
+	require.NoError(t, err)
+	assert.Contains(t, string(got), "FOO\n")
+	assert.Contains(t, string(got), "BYE\n")
+	assert.NotContains(t, string(got), "foo")
+	assert.Contains(t, string(got), "err\n")
+}
+
+type upperWriter struct {
+	w io.Writer
+}
+
+func (u *upperWriter) Write(p []byte) (int, error) {
+	return u.w.Write(bytes.ToUpper(p))
+}
+
+func (u *upperWriter) Close() error { return nil }
+
+func TestTask_AdditionalExecution(t *testing.T) {
+	t.Parallel()
+
+	f := factory{
+		counter:   internal.Int(0),
+		program:   "./testdata/task",
+		publisher: &fakePublisher[*Task]{},
+	}
+	task, err := f.newTask(Spec{
+		Execution: Execution{TerraformCommand: []string{"plan"}},
+		AdditionalExecution: &Execution{
+			TerraformCommand: []string{"show"},
+			Args:             []string{"plan.out"},
+		},
+		RenderStdout: func(_ *Task, w io.Writer) io.WriteCloser { return &upperWriter{w: w} },
+	})
+	require.NoError(t, err)
+	// An additional terraform execution runs the configured program.
+	assert.Equal(t, &Execution{Program: "./testdata/task", Args: []string{"show", "plan.out"}}, task.AdditionalExecution)
+
+	task.updateState(Queued)
+	waitfn, err := task.start(context.Background())
+	require.NoError(t, err)
+	waitfn()
+	assert.Equal(t, Exited, task.State)
+
+	// Output of the additional execution is not rendered.
+	got, err := io.ReadAll(task.NewReader(true))
+	require.NoError(t, err)
+	assert.Regexp(t, `(?s)^FOO\n.*BYE\n.*foo\n.*bye\n`, string(got))
+}
+
+func TestTask_ReportProgress(t *testing.T) {
+	t.Parallel()
+
+	f := factory{counter: internal.Int(0)}
+	task, err := f.newTask(Spec{})
+	require.NoError(t, err)
+	var published int
+	task.afterUpdate = func(*Task) { published++ }
+
+	for _, p := range []string{"0/2 resources", "0/2 resources", "1/2 resources", "1/2 resources", "2/2 resources"} {
+		task.ReportProgress(fakeProgress(p))
+	}
+	assert.Equal(t, "2/2 resources", task.Progress().String())
+	// Subscribers are only informed when the summary changes.
+	assert.Equal(t, 3, published)
+}
+
+type fakeProgress string
+
+func (p fakeProgress) String() string { return string(p) }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
@@ -31,6 +32,10 @@ type Helpers struct {
 	Tasks      *task.Service
 	States     *state.Service
 	Logger     logging.Interface
+
+	// searchQuery is the most recent query with which state resources were
+	// searched.
+	searchQuery string
 }
 
 func (h *Helpers) ModuleCurrentWorkspace(mod *module.Module) *workspace.Workspace {
@@ -99,6 +104,30 @@ func (h *Helpers) WorkspaceCost(ws *workspace.Workspace) string {
 	return fmt.Sprintf("$%.2f", ws.Cost)
 }
 
+// WorkspaceDrift renders the outcome of the last check for drift in the
+// workspace, along with when drift first appeared.
+func (h *Helpers) WorkspaceDrift(ws *workspace.Workspace) string {
+	if ws.Drift == nil {
+		return "-"
+	}
+	if !ws.Drift.Drifted {
+		return Regular.Foreground(Green).Render("none")
+	}
+	return Regular.Foreground(Red).Render(
+		fmt.Sprintf("%s since %s", ws.Drift.Summary, Ago(time.Now(), *ws.Drift.Since)),
+	)
+}
+
+// WorkspaceVarFiles lists the workspace's variables files, separated by
+// commas.
+func (h *Helpers) WorkspaceVarFiles(ws *workspace.Workspace) string {
+	files := h.Workspaces.VarFiles(ws)
+	if len(files) == 0 {
+		return "-"
+	}
+	return strings.Join(files, ",")
+}
+
 func (h *Helpers) WorkspaceResourceCount(ws *workspace.Workspace) string {
 	state, err := h.States.Get(ws.ID)
 	if errors.Is(err, resource.ErrNotFound) {
@@ -124,6 +153,9 @@ func (h *Helpers) TaskModule(t *task.Task) *module.Module {
 }
 
 func (h *Helpers) TaskModulePath(t *task.Task) string {
+	if t.History != nil {
+		return t.History.ModulePath
+	}
 	if mod := h.TaskModule(t); mod != nil {
 		return mod.Path
 	}
@@ -144,6 +176,9 @@ func (h *Helpers) TaskWorkspace(t *task.Task) *workspace.Workspace {
 }
 
 func (h *Helpers) TaskWorkspaceName(t *task.Task) string {
+	if t.History != nil {
+		return t.History.WorkspaceName
+	}
 	if ws := h.TaskWorkspace(t); ws != nil {
 		return ws.Name
 	}
@@ -181,6 +216,8 @@ func (h *Helpers) TaskStatus(t *task.Task, background bool) string {
 		color = GreenBlue
 	case task.Errored:
 		color = Red
+	case task.TimedOut:
+		color = DarkRed
 	}
 
 	if background {
@@ -190,11 +227,9 @@ func (h *Helpers) TaskStatus(t *task.Task, background bool) string {
 	}
 }
 
-// TaskSummary renders a summary of the task's outcome.
+// TaskSummary renders a summary of the task's outcome, or of its progress
+// whilst it is running.
 func (h *Helpers) TaskSummary(t *task.Task, table bool) string {
-	if t.Summary == nil {
-		return ""
-	}
 	var style lipgloss.Style
 	if !table {
 		style = lipgloss.NewStyle().Background(TaskSummaryBackgroundColor)
@@ -202,6 +237,13 @@ func (h *Helpers) TaskSummary(t *task.Task, table bool) string {
 	// Render special resource report
 	var content string
 	switch summary := t.Summary.(type) {
+	case nil:
+		// Summarise the progress of a running task instead.
+		progress := t.Progress()
+		if progress == nil || t.State != task.Running {
+			return ""
+		}
+		content = progress.String()
 	case plan.Report:
 		content = h.ResourceReport(summary, style)
 	case workspace.ReloadSummary:
@@ -222,6 +264,9 @@ func (h *Helpers) TaskSummary(t *task.Task, table bool) string {
 // ResourceReport renders a colored summary of resource changes as a result of a
 // plan or apply.
 func (h *Helpers) ResourceReport(report plan.Report, inherit lipgloss.Style) string {
+	if report.RefreshOnly {
+		return h.DriftReport(report, inherit)
+	}
 	additions := Regular.Foreground(Green).Inherit(inherit).Render(fmt.Sprintf("+%d", report.Additions))
 	changes := Regular.Foreground(Blue).Inherit(inherit).Render(fmt.Sprintf("~%d", report.Changes))
 	destructions := Regular.Foreground(Red).Inherit(inherit).Render(fmt.Sprintf("-%d", report.Destructions))
@@ -229,6 +274,16 @@ func (h *Helpers) ResourceReport(report plan.Report, inherit lipgloss.Style) str
 	return fmt.Sprintf("%s%s%s", additions, changes, destructions)
 }
 
+// DriftReport renders a colored summary of resources that have drifted, as a
+// result of a refresh-only plan or apply.
+func (h *Helpers) DriftReport(report plan.Report, inherit lipgloss.Style) string {
+	label := Regular.Inherit(inherit).Render("drift: ")
+	changes := Regular.Foreground(Blue).Inherit(inherit).Render(fmt.Sprintf("~%d", report.Changes))
+	destructions := Regular.Foreground(Red).Inherit(inherit).Render(fmt.Sprintf("-%d", report.Destructions))
+
+	return fmt.Sprintf("%s%s%s", label, changes, destructions)
+}
+
 // WorkspaceReloadReport renders a colored summary of workspaces added or
 // removed as a result of a workspace reload.
 func (h *Helpers) WorkspaceReloadReport(report workspace.ReloadSummary, inherit lipgloss.Style) string {
@@ -330,14 +385,183 @@ func (h *Helpers) CreateTasksWithSpecs(specs ...task.Spec) tea.Cmd {
 	}
 }
 
+// createTaskGroup creates a task group that continues regardless of the
+// failure of its tasks. Its failure policy can be changed on the task group's
+// page.
 func (h *Helpers) createTaskGroup(specs ...task.Spec) tea.Msg {
-	group, err := h.Tasks.CreateGroup(specs...)
+	group, err := h.Tasks.CreateGroup(task.ContinueOnFailure, specs...)
 	if err != nil {
 		return ReportError(fmt.Errorf("creating task group: %w", err))
 	}
 	return NewNavigationMsg(TaskGroupKind, WithParent(group.ID))
 }
 
+// SetGroupFailurePolicy prompts the user to change the failure policy of a
+// task group.
+func (h *Helpers) SetGroupFailurePolicy(group *task.Group) tea.Cmd {
+	return CmdHandler(PromptMsg{
+		Prompt:       "Set task group failure policy (continue, fail-fast, or max failures): ",
+		InitialValue: group.Policy().String(),
+		Action: func(v string) tea.Cmd {
+			policy, err := task.ParseFailurePolicy(v)
+			if err != nil {
+				return ReportError(err)
+			}
+			if _, err := h.Tasks.SetGroupFailurePolicy(group.ID, policy); err != nil {
+				return ReportError(fmt.Errorf("setting task group failure policy: %w", err))
+			}
+			return ReportInfo("Set task group failure policy: %s", policy)
+		},
+		Key:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "confirm")),
+		Cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+	})
+}
+
+// GroupFailurePolicyBadge renders a task group's failure policy, or an empty
+// string if the group continues regardless of failures.
+func (h *Helpers) GroupFailurePolicyBadge(group *task.Group) string {
+	switch {
+	case group.Halted():
+		return Padded.Background(Red).Foreground(White).Render("halted")
+	case group.Policy() != task.ContinueOnFailure:
+		return Padded.Background(Orange).Foreground(White).Render(group.Policy().String())
+	default:
+		return ""
+	}
+}
+
+// overrideConfirmation is the text the user must type to apply a plan that
+// violates policy.
+const overrideConfirmation = "override"
+
+// ApplyPlan prompts the user to confirm applying the plan created by the plan
+// task with the given ID. If the plan is stale then the user must confirm
+// applying it anyway, and if the plan violates policy then the user must
+// type a confirmation in order to override the policy.
+func (h *Helpers) ApplyPlan(taskID resource.ID) tea.Cmd {
+	return h.applyPlan(taskID, plan.ApplyPlanOptions{})
+}
+
+func (h *Helpers) applyPlan(taskID resource.ID, opts plan.ApplyPlanOptions) tea.Cmd {
+	// Checking whether the plan is stale hashes its inputs, so do so off the
+	// UI thread.
+	return func() tea.Msg {
+		return h.confirmApplyPlan(taskID, opts)()
+	}
+}
+
+func (h *Helpers) confirmApplyPlan(taskID resource.ID, opts plan.ApplyPlanOptions) tea.Cmd {
+	spec, err := h.Plans.ApplyPlan(taskID, opts)
+	var (
+		staleErr     *plan.StalePlanError
+		violationErr *plan.PolicyViolationError
+	)
+	switch {
+	case errors.As(err, &staleErr):
+		opts.IgnoreStale = true
+		return YesNoPrompt(
+			fmt.Sprintf("Plan is stale: %s. Apply anyway?", staleErr.Reason),
+			h.applyPlan(taskID, opts),
+		)
+	case errors.As(err, &violationErr):
+		return CmdHandler(PromptMsg{
+			Prompt: fmt.Sprintf("Plan violates %d policy rule(s). Type %q to apply anyway: ",
+				len(violationErr.Violations), overrideConfirmation),
+			Action: func(v string) tea.Cmd {
+				if v != overrideConfirmation {
+					return ReportInfo("canceled operation")
+				}
+				h.Logger.Warn("overriding policy", "task", taskID, "violations", len(violationErr.Violations))
+				opts.OverridePolicy = true
+				return h.applyPlan(taskID, opts)
+			},
+			Key:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "confirm")),
+			Cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+		})
+	case err != nil:
+		return ReportError(err)
+	}
+	if opts.IgnoreStale || opts.OverridePolicy {
+		// User has already confirmed applying the plan.
+		return h.CreateTasksWithSpecs(spec)
+	}
+	return YesNoPrompt("Apply plan?", h.CreateTasksWithSpecs(spec))
+}
+
+// PlanWithVariables prompts the user for variable overrides with which to
+// plan the workspaces. The prompt is pre-filled with the overrides most
+// recently used to plan the first workspace.
+func (h *Helpers) PlanWithVariables(workspaceIDs ...resource.ID) tea.Cmd {
+	if len(workspaceIDs) == 0 {
+		return nil
+	}
+	return CmdHandler(PromptMsg{
+		Prompt:       fmt.Sprintf("Variables for %d workspace(s): ", len(workspaceIDs)),
+		InitialValue: h.Plans.RecentVariables(workspaceIDs[0]).String(),
+		Placeholder:  "key=value extra.tfvars",
+		Action: func(v string) tea.Cmd {
+			vars, err := plan.ParseVariables(v)
+			if err != nil {
+				return ReportError(err)
+			}
+			fn := func(workspaceID resource.ID) (task.Spec, error) {
+				return h.Plans.Plan(workspaceID, plan.CreateOptions{Variables: vars})
+			}
+			return h.CreateTasks(fn, workspaceIDs...)
+		},
+		Key:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "confirm")),
+		Cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+	})
+}
+
+// Search prompts the user for a query with which to search the resources in
+// the state of every workspace, and then shows the results. The prompt is
+// pre-filled with the most recent query.
+func (h *Helpers) Search() tea.Cmd {
+	return CmdHandler(PromptMsg{
+		Prompt:       "Search state: ",
+		InitialValue: h.searchQuery,
+		Placeholder:  "type:aws_s3_bucket versioning.enabled=false",
+		Action: func(v string) tea.Cmd {
+			if strings.TrimSpace(v) == "" {
+				return nil
+			}
+			h.searchQuery = v
+			return tea.Sequence(NavigateTo(SearchKind), CmdHandler(SearchMsg(v)))
+		},
+		Key:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "search")),
+		Cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+	})
+}
+
+const forceUnlockConfirmation = "force-unlock"
+
+// ForceUnlock prompts the user to type a confirmation before forcibly
+// removing the lock on the workspace's state. The prompt shows who holds the
+// lock and for how long, so that a lock held by a running operation is not
+// removed by mistake.
+func (h *Helpers) ForceUnlock(workspaceID resource.ID, lock state.LockInfo) tea.Cmd {
+	age := "at an unknown time"
+	if !lock.Created.IsZero() {
+		age = Ago(time.Now(), lock.Created)
+	}
+	return CmdHandler(PromptMsg{
+		Prompt: fmt.Sprintf("State locked %s by %s (%s). Type %q to remove lock: ",
+			age, lock.Who, lock.Operation, forceUnlockConfirmation),
+		Action: func(v string) tea.Cmd {
+			if v != forceUnlockConfirmation {
+				return ReportInfo("canceled operation")
+			}
+			h.Logger.Warn("force-unlocking state", "workspace", workspaceID, "lock", lock.ID, "who", lock.Who)
+			return h.CreateTasks(func(workspaceID resource.ID) (task.Spec, error) {
+				return h.States.ForceUnlock(workspaceID, lock.ID)
+			}, workspaceID)
+		},
+		Key:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "confirm")),
+		Cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+	})
+}
+
 func (h *Helpers) Move(workspaceID resource.ID, from state.ResourceAddress) tea.Cmd {
 	return CmdHandler(PromptMsg{
 		Prompt:       "Enter destination address: ",
@@ -361,6 +585,18 @@ func (h *Helpers) Breadcrumbs(title string, res resource.Resource, crumbs ...str
 	switch res := res.(type) {
 	case *task.Task:
 		cmd := TitleCommand.Render(res.String())
+		if res.History != nil {
+			// Historical task from a previous session, which has no
+			// references to modules or workspaces in this session.
+			if name := res.History.WorkspaceName; name != "" {
+				crumbs = append(crumbs, TitleWorkspace.Render(name))
+			}
+			if path := res.History.ModulePath; path != "" {
+				crumbs = append(crumbs, TitlePath.Render(path))
+			}
+			crumbs = append([]string{cmd}, crumbs...)
+			return fmt.Sprintf("%s%s%s", Title.Render(title), strings.Join(crumbs, ""), TitleHistorical.Render("historical"))
+		}
 		if res.WorkspaceID != nil {
 			ws, err := h.Workspaces.Get(*res.WorkspaceID)
 			if err != nil {
@@ -399,6 +635,14 @@ func (h *Helpers) Breadcrumbs(title string, res resource.Resource, crumbs ...str
 			return ""
 		}
 		return h.Breadcrumbs(title, mod, append(crumbs, name)...)
+	case *plan.Archived:
+		// Archived plan, possibly from a previous session, which has no
+		// references to modules or workspaces in this session.
+		crumbs = append(crumbs,
+			TitleWorkspace.Render(res.WorkspaceName),
+			TitlePath.Render(res.ModulePath),
+		)
+		return fmt.Sprintf("%s%s%s", Title.Render(title), strings.Join(crumbs, ""), TitleHistorical.Render("archived"))
 	case *module.Module:
 		crumbs = append(crumbs, TitlePath.Render(res.String()))
 	}
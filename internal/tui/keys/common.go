@@ -3,23 +3,26 @@ package keys
 import "github.com/charmbracelet/bubbles/key"
 
 type common struct {
-	Plan        key.Binding
-	PlanDestroy key.Binding
-	Apply       key.Binding
-	Destroy     key.Binding
-	Cancel      key.Binding
-	Delete      key.Binding
-	State       key.Binding
-	Retry       key.Binding
-	Reload      key.Binding
-	Module      key.Binding
-	Workspace   key.Binding
-	Edit        key.Binding
-	Init        key.Binding
-	InitUpgrade key.Binding
-	Validate    key.Binding
-	Format      key.Binding
-	Cost        key.Binding
+	Plan         key.Binding
+	PlanDestroy  key.Binding
+	PlanRefresh  key.Binding
+	PlanVars     key.Binding
+	Apply        key.Binding
+	Destroy      key.Binding
+	ApplyRefresh key.Binding
+	Cancel       key.Binding
+	Delete       key.Binding
+	State        key.Binding
+	Retry        key.Binding
+	Reload       key.Binding
+	Module       key.Binding
+	Workspace    key.Binding
+	Edit         key.Binding
+	Init         key.Binding
+	InitUpgrade  key.Binding
+	Validate     key.Binding
+	Format       key.Binding
+	Cost         key.Binding
 }
 
 // Keys shared by several models.
@@ -32,6 +35,14 @@ var Common = common{
 		key.WithKeys("P"),
 		key.WithHelp("P", "plan destroy"),
 	),
+	PlanRefresh: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "plan refresh-only"),
+	),
+	PlanVars: key.NewBinding(
+		key.WithKeys("V"),
+		key.WithHelp("V", "plan with variables"),
+	),
 	Apply: key.NewBinding(
 		key.WithKeys("a"),
 		key.WithHelp("a", "apply"),
@@ -40,6 +51,10 @@ var Common = common{
 		key.WithKeys("d"),
 		key.WithHelp("d", "destroy"),
 	),
+	ApplyRefresh: key.NewBinding(
+		key.WithKeys("O"),
+		key.WithHelp("O", "apply refresh-only"),
+	),
 	Cancel: key.NewBinding(
 		key.WithKeys("c"),
 		key.WithHelp("c", "cancel"),
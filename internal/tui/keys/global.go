@@ -10,6 +10,8 @@ type global struct {
 	Tasks       key.Binding
 	TaskGroups  key.Binding
 	Logs        key.Binding
+	Plans       key.Binding
+	Search      key.Binding
 	Back        key.Binding
 	Select      key.Binding
 	SelectAll   key.Binding
@@ -17,6 +19,7 @@ type global struct {
 	SelectRange key.Binding
 	Filter      key.Binding
 	Autoscroll  key.Binding
+	PauseQueue  key.Binding
 	Quit        key.Binding
 	Suspend     key.Binding
 	Help        key.Binding
@@ -43,6 +46,14 @@ var Global = global{
 		key.WithKeys("l"),
 		key.WithHelp("l", "logs"),
 	),
+	Plans: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "archived plans"),
+	),
+	Search: key.NewBinding(
+		key.WithKeys("ctrl+f"),
+		key.WithHelp("ctrl+f", "search state"),
+	),
 	Back: key.NewBinding(
 		key.WithKeys("esc"),
 		key.WithHelp("esc", "back"),
@@ -71,6 +82,10 @@ var Global = global{
 		key.WithKeys("ctrl+s"),
 		key.WithHelp("ctrl+s", "toggle autoscroll"),
 	),
+	PauseQueue: key.NewBinding(
+		key.WithKeys("ctrl+p"),
+		key.WithHelp("ctrl+p", "pause/resume task queue"),
+	),
 	Quit: key.NewBinding(
 		key.WithKeys("ctrl+c"),
 		key.WithHelp("ctrl+c", "exit"),
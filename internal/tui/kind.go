@@ -15,4 +15,13 @@ const (
 	ResourceKind
 	LogListKind
 	LogKind
+	PlanKind
+	ArchiveListKind
+	ArchivedPlanKind
+	OutputListKind
+	StateHistoryKind
+	StateDiffKind
+	SearchKind
+	ResourceTreeKind
+	DriftHistoryKind
 )
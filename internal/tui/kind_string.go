@@ -18,11 +18,20 @@ func _() {
 	_ = x[ResourceKind-7]
 	_ = x[LogListKind-8]
 	_ = x[LogKind-9]
+	_ = x[PlanKind-10]
+	_ = x[ArchiveListKind-11]
+	_ = x[ArchivedPlanKind-12]
+	_ = x[OutputListKind-13]
+	_ = x[StateHistoryKind-14]
+	_ = x[StateDiffKind-15]
+	_ = x[SearchKind-16]
+	_ = x[ResourceTreeKind-17]
+	_ = x[DriftHistoryKind-18]
 }
 
-const _Kind_name = "ModuleListKindWorkspaceListKindTaskListKindTaskKindTaskGroupListKindTaskGroupKindResourceListKindResourceKindLogListKindLogKind"
+const _Kind_name = "ModuleListKindWorkspaceListKindTaskListKindTaskKindTaskGroupListKindTaskGroupKindResourceListKindResourceKindLogListKindLogKindPlanKindArchiveListKindArchivedPlanKindOutputListKindStateHistoryKindStateDiffKindSearchKindResourceTreeKindDriftHistoryKind"
 
-var _Kind_index = [...]uint8{0, 14, 31, 43, 51, 68, 81, 97, 109, 120, 127}
+var _Kind_index = [...]uint8{0, 14, 31, 43, 51, 68, 81, 97, 109, 120, 127, 135, 150, 166, 180, 196, 209, 219, 235, 251}
 
 func (i Kind) String() string {
 	if i < 0 || i >= Kind(len(_Kind_index)-1) {
@@ -29,6 +29,9 @@ func WithParent(parent resource.ID) NavigateOption {
 
 type InfoMsg string
 
+// SearchMsg is a request to search state resources with the given query.
+type SearchMsg string
+
 // FilterFocusReqMsg is a request to focus the filter widget.
 type FilterFocusReqMsg struct{}
 
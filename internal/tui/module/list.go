@@ -176,7 +176,8 @@ func (m list) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, keys.Common.PlanDestroy):
 			createPlanOpts.Destroy = true
 			fallthrough
-		case key.Matches(msg, keys.Common.Plan):
+		case key.Matches(msg, keys.Common.Plan, keys.Common.PlanRefresh):
+			createPlanOpts.RefreshOnly = key.Matches(msg, keys.Common.PlanRefresh)
 			// Create specs here, de-selecting any modules where an error is
 			// returned.
 			specs, err := m.table.Prune(func(mod *module.Module) (task.Spec, error) {
@@ -192,11 +193,25 @@ func (m list) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, tui.ReportError(err)
 			}
 			return m, m.CreateTasksWithSpecs(specs...)
+		case key.Matches(msg, keys.Common.PlanVars):
+			var workspaceIDs []resource.ID
+			for _, row := range m.table.SelectedOrCurrent() {
+				if workspaceID := row.Value.CurrentWorkspaceID; workspaceID == nil {
+					return m, tui.ReportError(fmt.Errorf("module %s does not have a current workspace", row.Value))
+				} else {
+					workspaceIDs = append(workspaceIDs, *workspaceID)
+				}
+			}
+			return m, m.PlanWithVariables(workspaceIDs...)
 		case key.Matches(msg, keys.Common.Destroy):
 			createPlanOpts.Destroy = true
 			applyPrompt = "Destroy resources of %d modules?"
 			fallthrough
-		case key.Matches(msg, keys.Common.Apply):
+		case key.Matches(msg, keys.Common.Apply, keys.Common.ApplyRefresh):
+			if key.Matches(msg, keys.Common.ApplyRefresh) {
+				createPlanOpts.RefreshOnly = true
+				applyPrompt = "Refresh state of %d modules?"
+			}
 			// Create specs here, de-selecting any modules where an error is
 			// returned.
 			specs, err := m.table.Prune(func(mod *module.Module) (task.Spec, error) {
@@ -261,8 +276,11 @@ func (m list) HelpBindings() (bindings []key.Binding) {
 		keys.Common.Validate,
 		keys.Common.Plan,
 		keys.Common.PlanDestroy,
+		keys.Common.PlanRefresh,
+		keys.Common.PlanVars,
 		keys.Common.Apply,
 		keys.Common.Destroy,
+		keys.Common.ApplyRefresh,
 		keys.Common.Edit,
 		localKeys.Execute,
 		localKeys.ReloadModules,
@@ -21,4 +21,6 @@ var (
 	TitleAddress   = Padded.Foreground(White).Background(Blue)
 	TitleSerial    = Padded.Foreground(Black).Background(Orange)
 	TitleTainted   = Padded.Foreground(White).Background(Red)
+
+	TitleHistorical = Padded.Foreground(White).Background(DarkGrey)
 )
@@ -0,0 +1,156 @@
+package task
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/tui/keys"
+	"github.com/leg100/pug/internal/tui/split"
+	"github.com/leg100/pug/internal/tui/table"
+)
+
+var archivedColumn = table.Column{
+	Key:   "created",
+	Title: "CREATED",
+	Width: len("2006-01-02 15:04"),
+}
+
+// ArchiveListMaker makes models that list archived plans.
+type ArchiveListMaker struct {
+	Plans   *plan.Service
+	Helpers *tui.Helpers
+}
+
+func (mm *ArchiveListMaker) Make(_ resource.ID, width, height int) (tea.Model, error) {
+	columns := []table.Column{
+		table.ModuleColumn,
+		table.WorkspaceColumn,
+		table.SummaryColumn,
+		archivedColumn,
+		ageColumn,
+	}
+
+	renderer := func(a *plan.Archived) table.RenderedRow {
+		summary := mm.Helpers.ResourceReport(a.Report, tui.Regular)
+		if a.Destroy {
+			summary += " (destroy)"
+		}
+		return table.RenderedRow{
+			table.ModuleColumn.Key:    a.ModulePath,
+			table.WorkspaceColumn.Key: a.WorkspaceName,
+			table.SummaryColumn.Key:   summary,
+			archivedColumn.Key:        a.Created.Local().Format("2006-01-02 15:04"),
+			ageColumn.Key:             tui.Ago(time.Now(), a.Created),
+		}
+	}
+
+	table := table.New(columns, renderer, width, height,
+		table.WithSortFunc(plan.SortArchived),
+	)
+
+	return archiveList{
+		table:   table,
+		plans:   mm.Plans,
+		Helpers: mm.Helpers,
+	}, nil
+}
+
+type archiveList struct {
+	*tui.Helpers
+
+	table table.Model[*plan.Archived]
+	plans *plan.Service
+}
+
+func (m archiveList) Init() tea.Cmd {
+	return func() tea.Msg {
+		return table.BulkInsertMsg[*plan.Archived](m.plans.ListArchived())
+	}
+}
+
+func (m archiveList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var (
+		cmd  tea.Cmd
+		cmds []tea.Cmd
+	)
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, archiveListKeys.Enter):
+			if row, ok := m.table.CurrentRow(); ok {
+				return m, tui.NavigateTo(tui.ArchivedPlanKind, tui.WithParent(row.ID))
+			}
+		}
+	}
+	// Handle keyboard and mouse events in the table widget
+	m.table, cmd = m.table.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m archiveList) Title() string {
+	return m.Breadcrumbs("Plans", nil)
+}
+
+func (m archiveList) View() string {
+	return m.table.View()
+}
+
+func (m archiveList) HelpBindings() []key.Binding {
+	return []key.Binding{archiveListKeys.Enter}
+}
+
+// ArchivedPlanMaker makes models that list the resource changes in an
+// archived plan.
+type ArchivedPlanMaker struct {
+	Plans   *plan.Service
+	Helpers *tui.Helpers
+}
+
+func (mm *ArchivedPlanMaker) Make(id resource.ID, width, height int) (tea.Model, error) {
+	archived, err := mm.Plans.GetArchived(id)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := mm.Plans.ArchivedChanges(id)
+	if err != nil {
+		return nil, err
+	}
+	return archivedPlanModel{
+		Model:    newChangesModel(mm.Plans, changes, width, height),
+		Helpers:  mm.Helpers,
+		archived: archived,
+	}, nil
+}
+
+type archivedPlanModel struct {
+	split.Model[*plan.ResourceChange]
+	*tui.Helpers
+
+	archived *plan.Archived
+}
+
+func (m archivedPlanModel) Init() tea.Cmd {
+	// Trigger the creation of a preview for the current row.
+	return tui.CmdHandler(changesLoadedMsg{})
+}
+
+func (m archivedPlanModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.Model, cmd = m.Model.Update(msg)
+	return m, cmd
+}
+
+func (m archivedPlanModel) Title() string {
+	return m.Breadcrumbs("Plan", m.archived)
+}
+
+func (m archivedPlanModel) HelpBindings() []key.Binding {
+	return keys.KeyMapToSlice(split.Keys)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/leg100/pug/internal/plan"
 	"github.com/leg100/pug/internal/resource"
 	"github.com/leg100/pug/internal/task"
@@ -87,6 +88,10 @@ func (m groupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	)
 
 	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if key.Matches(msg, localKeys.FailurePolicy) {
+			return m, m.SetGroupFailurePolicy(m.group)
+		}
 	case table.BulkInsertMsg[*task.Task]:
 		if m.skip(([]*task.Task)(msg)...) {
 			return m, nil
@@ -121,7 +126,10 @@ func (m groupModel) Title() string {
 }
 
 func (m groupModel) Status() string {
-	return m.GroupReport(m.group, false)
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		m.GroupFailurePolicyBadge(m.group),
+		m.GroupReport(m.group, false),
+	)
 }
 
 func (m groupModel) HelpBindings() []key.Binding {
@@ -130,6 +138,11 @@ func (m groupModel) HelpBindings() []key.Binding {
 		keys.Common.Apply,
 		keys.Common.State,
 		keys.Common.Retry,
+		localKeys.Hold,
+		localKeys.Release,
+		localKeys.MoveUp,
+		localKeys.MoveDown,
+		localKeys.FailurePolicy,
 	}
 	return append(bindings, keys.KeyMapToSlice(split.Keys)...)
 }
@@ -3,8 +3,17 @@ package task
 import "github.com/charmbracelet/bubbles/key"
 
 type keyMap struct {
-	ToggleInfo key.Binding
-	Enter      key.Binding
+	ToggleInfo    key.Binding
+	Enter         key.Binding
+	PrevAttempt   key.Binding
+	NextAttempt   key.Binding
+	Hold          key.Binding
+	Release       key.Binding
+	MoveUp        key.Binding
+	MoveDown      key.Binding
+	ViewPlan      key.Binding
+	ForceUnlock   key.Binding
+	FailurePolicy key.Binding
 }
 
 var localKeys = keyMap{
@@ -16,6 +25,42 @@ var localKeys = keyMap{
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "view task"),
 	),
+	PrevAttempt: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "previous attempt"),
+	),
+	NextAttempt: key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "next attempt"),
+	),
+	Hold: key.NewBinding(
+		key.WithKeys("h"),
+		key.WithHelp("h", "hold"),
+	),
+	Release: key.NewBinding(
+		key.WithKeys("H"),
+		key.WithHelp("H", "release"),
+	),
+	MoveUp: key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "move up queue"),
+	),
+	MoveDown: key.NewBinding(
+		key.WithKeys("J"),
+		key.WithHelp("J", "move down queue"),
+	),
+	ViewPlan: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "view plan changes"),
+	),
+	ForceUnlock: key.NewBinding(
+		key.WithKeys("U"),
+		key.WithHelp("U", "force-unlock state"),
+	),
+	FailurePolicy: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "failure policy"),
+	),
 }
 
 type groupListKeyMap struct {
@@ -28,3 +73,14 @@ var groupListKeys = groupListKeyMap{
 		key.WithHelp("enter", "view group"),
 	),
 }
+
+type archiveListKeyMap struct {
+	Enter key.Binding
+}
+
+var archiveListKeys = archiveListKeyMap{
+	Enter: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "view plan"),
+	),
+}
@@ -79,11 +79,24 @@ func (mm *ListMaker) Make(_ resource.ID, width, height int) (tea.Model, error) {
 	}
 
 	renderer := func(t *task.Task) table.RenderedRow {
+		cmd := t.String()
+		if t.History != nil {
+			cmd += " (historical)"
+		}
+		if t.Attempt > 1 {
+			cmd += fmt.Sprintf(" (attempt %d)", t.Attempt)
+		}
+		if t.Held {
+			cmd += " (held)"
+		}
+		if w := t.Warning(); w != "" {
+			cmd += fmt.Sprintf(" (%s)", w)
+		}
 		return table.RenderedRow{
 			taskIDColumn.Key:          t.ID.String(),
 			table.ModuleColumn.Key:    mm.Helpers.TaskModulePath(t),
 			table.WorkspaceColumn.Key: mm.Helpers.TaskWorkspaceName(t),
-			commandColumn.Key:         t.String(),
+			commandColumn.Key:         cmd,
 			ageColumn.Key:             tui.Ago(time.Now(), t.Updated),
 			statusColumn.Key:          mm.Helpers.TaskStatus(t, false),
 			table.SummaryColumn.Key:   mm.Helpers.TaskSummary(t, true),
@@ -129,6 +142,20 @@ func (m List) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, keys.Common.Cancel):
 			taskIDs := m.Table.SelectedOrCurrentIDs()
 			return m, cancel(m.tasks, taskIDs...)
+		case key.Matches(msg, localKeys.Hold):
+			taskIDs := m.Table.SelectedOrCurrentIDs()
+			return m, hold(m.tasks, taskIDs...)
+		case key.Matches(msg, localKeys.Release):
+			taskIDs := m.Table.SelectedOrCurrentIDs()
+			return m, release(m.tasks, taskIDs...)
+		case key.Matches(msg, localKeys.MoveUp):
+			if row, ok := m.Table.CurrentRow(); ok {
+				return m, move(m.tasks, row.ID, -1)
+			}
+		case key.Matches(msg, localKeys.MoveDown):
+			if row, ok := m.Table.CurrentRow(); ok {
+				return m, move(m.tasks, row.ID, 1)
+			}
 		case key.Matches(msg, localKeys.Enter):
 			if row, ok := m.Table.CurrentRow(); ok {
 				return m, tui.NavigateTo(tui.TaskKind, tui.WithParent(row.ID))
@@ -136,7 +163,7 @@ func (m List) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, keys.Common.Apply):
 			specs, err := m.Table.Prune(func(t *task.Task) (task.Spec, error) {
 				// Task must be a plan in order to be applied
-				return m.plans.ApplyPlan(t.ID)
+				return m.plans.ApplyPlan(t.ID, plan.ApplyPlanOptions{})
 			})
 			if err != nil {
 				return m, tui.ReportError(fmt.Errorf("applying tasks: %w", err))
@@ -157,6 +184,9 @@ func (m List) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			rows := m.Table.SelectedOrCurrent()
 			specs := make([]task.Spec, len(rows))
 			for i, row := range rows {
+				if row.Value.History != nil {
+					return m, tui.ReportError(errors.New("cannot retry historical tasks"))
+				}
 				specs[i] = row.Value.Spec
 			}
 			return m, tui.YesNoPrompt(
@@ -181,6 +211,10 @@ func (m List) HelpBindings() []key.Binding {
 		keys.Common.Apply,
 		keys.Common.State,
 		keys.Common.Retry,
+		localKeys.Hold,
+		localKeys.Release,
+		localKeys.MoveUp,
+		localKeys.MoveDown,
 	}
 	return append(bindings, keys.KeyMapToSlice(split.Keys)...)
 }
@@ -0,0 +1,49 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/tui"
+)
+
+// lockError returns the task's error if it failed because the state is
+// locked, otherwise nil.
+func (m model) lockError() *state.LockError {
+	var lockErr *state.LockError
+	if errors.As(m.task.Err, &lockErr) {
+		return lockErr
+	}
+	return nil
+}
+
+// lockView renders the lock held on the state if the task failed because the
+// state is locked. An empty string is returned if the task did not fail
+// because of a lock.
+func (m model) lockView() string {
+	lockErr := m.lockError()
+	if lockErr == nil {
+		return ""
+	}
+	created := "-"
+	if !lockErr.Created.IsZero() {
+		created = fmt.Sprintf("%s (%s)", lockErr.Created.Local().Format(time.DateTime), tui.Ago(time.Now(), lockErr.Created))
+	}
+	lines := []string{
+		tui.Bold.Foreground(tui.Red).Render("State is locked"),
+		fmt.Sprintf("ID: %s", lockErr.ID),
+		fmt.Sprintf("Who: %s", lockErr.Who),
+		fmt.Sprintf("Operation: %s", lockErr.Operation),
+		fmt.Sprintf("Created: %s", created),
+	}
+	return tui.Regular.
+		// Border beneath, dividing the lock from the output
+		Border(lipgloss.NormalBorder(), false, false, true, false).
+		BorderForeground(tui.Red).
+		MaxWidth(m.viewportWidth()).
+		Render(strings.Join(lines, "\n"))
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
@@ -66,6 +67,7 @@ func (mm *Maker) make(id resource.ID, width, height int, border bool) (tea.Model
 		Height:     m.height,
 		Spinner:    m.spinner,
 	})
+	m.resizeViewport()
 
 	return m, nil
 }
@@ -116,6 +118,14 @@ type model struct {
 	width  int
 }
 
+// retryShow re-runs the showing of the plan created by the task.
+func (m model) retryShow() tea.Msg {
+	if err := m.plans.RetryShow(m.task.ID); err != nil {
+		return tui.ErrorMsg(fmt.Errorf("showing plan: %w", err))
+	}
+	return tui.InfoMsg("Showing plan")
+}
+
 func (m model) Init() tea.Cmd {
 	return m.getOutput
 }
@@ -132,21 +142,44 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, keys.Common.Cancel):
 			return m, cancel(m.tasks, m.task.ID)
 		case key.Matches(msg, keys.Common.Apply):
-			spec, err := m.plans.ApplyPlan(m.task.ID)
-			if err != nil {
-				return m, tui.ReportError(err)
-			}
-			return m, tui.YesNoPrompt(
-				"Apply plan?",
-				m.CreateTasksWithSpecs(spec),
-			)
+			return m, m.ApplyPlan(m.task.ID)
 		case key.Matches(msg, keys.Common.State):
 			if ws := m.TaskWorkspaceOrCurrentWorkspace(m.task); ws != nil {
 				return m, tui.NavigateTo(tui.ResourceListKind, tui.WithParent(ws.GetID()))
 			} else {
 				return m, tui.ReportError(errors.New("task not associated with a workspace"))
 			}
+		case key.Matches(msg, localKeys.ForceUnlock):
+			lockErr := m.lockError()
+			if lockErr == nil || m.task.WorkspaceID == nil {
+				return m, tui.ReportError(errors.New("task did not fail because the state is locked"))
+			}
+			return m, m.ForceUnlock(*m.task.WorkspaceID, lockErr.LockInfo)
+		case key.Matches(msg, localKeys.ViewPlan):
+			if m.task.Identifier != plan.PlanTask {
+				return m, tui.ReportError(errors.New("task is not a plan"))
+			}
+			if m.plans.ShowError(m.task.ID) != nil {
+				return m, tui.YesNoPrompt("Showing plan failed. Retry?", m.retryShow)
+			}
+			if _, err := m.plans.ResourceChanges(m.task.ID); err != nil {
+				return m, tui.ReportError(err)
+			}
+			return m, tui.NavigateTo(tui.PlanKind, tui.WithParent(m.task.ID))
+		case key.Matches(msg, localKeys.PrevAttempt):
+			if m.task.RetryOf == nil {
+				return m, tui.ReportError(errors.New("task is not a retry of another task"))
+			}
+			return m, tui.NavigateTo(tui.TaskKind, tui.WithParent(m.task.RetryOf.ID))
+		case key.Matches(msg, localKeys.NextAttempt):
+			if m.task.RetriedBy() == nil {
+				return m, tui.ReportError(errors.New("task has not been retried"))
+			}
+			return m, tui.NavigateTo(tui.TaskKind, tui.WithParent(m.task.RetriedBy().ID))
 		case key.Matches(msg, keys.Common.Retry):
+			if m.task.History != nil {
+				return m, tui.ReportError(errors.New("cannot retry historical task"))
+			}
 			return m, tui.YesNoPrompt(
 				"Retry task?",
 				m.CreateTasksWithSpecs(m.task.Spec),
@@ -157,7 +190,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case toggleTaskInfoMsg:
 		m.showInfo = !m.showInfo
 		// adjust width of viewport to accomodate info
-		m.viewport.SetDimensions(m.viewportWidth(), m.height)
+		m.resizeViewport()
 	case outputMsg:
 		// Ensure output is for this model
 		if msg.modelID != m.id {
@@ -170,15 +203,22 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, m.getOutput)
 		}
 	case resource.Event[*task.Task]:
+		if msg.Payload.Identifier == plan.ShowTask {
+			// The show task evaluates the plan against policy, so make room
+			// for any violations.
+			m.resizeViewport()
+		}
 		if msg.Payload.ID != m.task.ID {
 			// Ignore event for different task.
 			return m, nil
 		}
 		m.task = msg.Payload
+		// adjust height of viewport to accomodate any apply progress
+		m.resizeViewport()
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.setHeight(msg.Height)
-		m.viewport.SetDimensions(m.viewportWidth(), m.height)
+		m.resizeViewport()
 		return m, nil
 	}
 
@@ -199,6 +239,29 @@ func (m model) viewportWidth() int {
 	return max(0, m.width)
 }
 
+// resizeViewport sets the dimensions of the viewport, making room for any
+// header above it.
+func (m *model) resizeViewport() {
+	height := m.height
+	if header := m.headerView(); header != "" {
+		height -= lipgloss.Height(header)
+	}
+	m.viewport.SetDimensions(m.viewportWidth(), max(0, height))
+}
+
+// headerView renders any warning, state lock, policy violations and apply
+// progress above the viewport. An empty string is returned if there is
+// nothing to render.
+func (m model) headerView() string {
+	var sections []string
+	for _, section := range []string{m.warningView(), m.lockView(), m.violationsView(), m.progressView()} {
+		if section != "" {
+			sections = append(sections, section)
+		}
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, sections...)
+}
+
 func (m *model) setHeight(height int) {
 	if m.border {
 		height -= 2
@@ -252,6 +315,59 @@ func (m model) View() string {
 			"",
 			fmt.Sprintf("Dependencies: %v", m.task.DependsOn),
 		)
+		if attempts := m.attempts(); attempts != "" {
+			content = lipgloss.JoinVertical(lipgloss.Top,
+				content,
+				"",
+				tui.Bold.Render("Attempts"),
+				attempts,
+			)
+		}
+		if m.task.Priority != task.DefaultPriority {
+			content = lipgloss.JoinVertical(lipgloss.Top,
+				content,
+				"",
+				fmt.Sprintf("Priority: %d", m.task.Priority),
+			)
+		}
+		if m.task.Timeout > 0 {
+			content = lipgloss.JoinVertical(lipgloss.Top,
+				content,
+				"",
+				fmt.Sprintf("Timeout: %s", m.task.Timeout),
+			)
+		}
+		if len(m.task.Variables) > 0 {
+			content = lipgloss.JoinVertical(lipgloss.Top,
+				content,
+				"",
+				tui.Bold.Render("Variable overrides"),
+				strings.Join(m.task.Variables, "\n"),
+			)
+		}
+		if len(m.task.PreHooks) > 0 {
+			content = lipgloss.JoinVertical(lipgloss.Top,
+				content,
+				"",
+				tui.Bold.Render("Pre-hooks"),
+				strings.Join(m.task.PreHooks, "\n"),
+			)
+		}
+		if len(m.task.PostHooks) > 0 {
+			content = lipgloss.JoinVertical(lipgloss.Top,
+				content,
+				"",
+				tui.Bold.Render("Post-hooks"),
+				strings.Join(m.task.PostHooks, "\n"),
+			)
+		}
+		if m.task.History != nil {
+			content = lipgloss.JoinVertical(lipgloss.Top,
+				content,
+				"",
+				fmt.Sprintf("Historical: loaded from a previous session; created %s", m.task.Created.Format(time.DateTime)),
+			)
+		}
 
 		// Word wrap task info to ensure it wraps "cleanly".
 		wrapper := wordwrap.NewWriter(infoContentWidth)
@@ -272,7 +388,11 @@ func (m model) View() string {
 			Render(wrapped)
 		components = append(components, container)
 	}
-	components = append(components, m.viewport.View())
+	if header := m.headerView(); header != "" {
+		components = append(components, lipgloss.JoinVertical(lipgloss.Left, header, m.viewport.View()))
+	} else {
+		components = append(components, m.viewport.View())
+	}
 	content := lipgloss.JoinHorizontal(lipgloss.Left, components...)
 	if m.border {
 		return tui.Border.Render(content)
@@ -280,6 +400,40 @@ func (m model) View() string {
 	return content
 }
 
+// warningView renders any warning attached to the task, e.g. that the plan
+// it created is stale.
+func (m model) warningView() string {
+	warning := m.task.Warning()
+	if warning == "" {
+		return ""
+	}
+	return tui.Bold.Foreground(tui.Red).MaxWidth(m.viewportWidth()).Render("Warning: " + warning)
+}
+
+// attempts renders the task's automatic retry attempts, or an empty string if
+// the task has not been retried.
+func (m model) attempts() string {
+	first := m.task
+	for first.RetryOf != nil {
+		first = first.RetryOf
+	}
+	if first.RetriedBy() == nil && !first.WillRetry() {
+		return ""
+	}
+	var lines []string
+	for t := first; t != nil; t = t.RetriedBy() {
+		line := fmt.Sprintf("#%d %s %s", t.Attempt, t.ID, t.State)
+		if t == m.task {
+			line = tui.Bold.Render(line)
+		}
+		lines = append(lines, line)
+		if t.RetriedBy() == nil && t.WillRetry() {
+			lines = append(lines, fmt.Sprintf("#%d pending", t.Attempt+1))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 func boolToOnOff(b bool) string {
 	if b {
 		return "on"
@@ -305,6 +459,12 @@ func (m model) HelpBindings() []key.Binding {
 		keys.Common.Retry,
 		localKeys.ToggleInfo,
 	}
+	if m.task.RetryOf != nil {
+		bindings = append(bindings, localKeys.PrevAttempt)
+	}
+	if m.task.RetriedBy() != nil {
+		bindings = append(bindings, localKeys.NextAttempt)
+	}
 	if moduleID := m.task.ModuleID; moduleID != nil {
 		bindings = append(bindings, keys.Common.Module)
 	}
@@ -314,6 +474,12 @@ func (m model) HelpBindings() []key.Binding {
 	if m.task.Identifier == plan.ApplyTask {
 		bindings = append(bindings, keys.Common.Apply)
 	}
+	if m.lockError() != nil {
+		bindings = append(bindings, localKeys.ForceUnlock)
+	}
+	if m.task.Identifier == plan.PlanTask {
+		bindings = append(bindings, localKeys.ViewPlan)
+	}
 	return bindings
 }
 
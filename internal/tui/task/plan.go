@@ -0,0 +1,222 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/tui/keys"
+	"github.com/leg100/pug/internal/tui/split"
+	"github.com/leg100/pug/internal/tui/table"
+)
+
+var (
+	actionColumn = table.Column{
+		Key:   "action",
+		Title: "ACTION",
+		Width: len("replace"),
+	}
+	addressColumn = table.Column{
+		Key:        "address",
+		Title:      "ADDRESS",
+		FlexFactor: 1,
+	}
+)
+
+// actionColors are the colors in which resource change actions are rendered.
+var actionColors = map[plan.ChangeAction]lipgloss.TerminalColor{
+	plan.CreateAction:  tui.Green,
+	plan.UpdateAction:  tui.Blue,
+	plan.ReplaceAction: tui.Orange,
+	plan.DeleteAction:  tui.Red,
+}
+
+// PlanMaker makes models that list the resource changes in a plan, previewing
+// the attribute diff of the currently highlighted change.
+type PlanMaker struct {
+	Plans   *plan.Service
+	Tasks   *task.Service
+	Helpers *tui.Helpers
+}
+
+func (mm *PlanMaker) Make(id resource.ID, width, height int) (tea.Model, error) {
+	planTask, err := mm.Tasks.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := mm.Plans.ResourceChanges(id)
+	if err != nil {
+		return nil, err
+	}
+	return planModel{
+		Model:   newChangesModel(mm.Plans, changes, width, height),
+		Helpers: mm.Helpers,
+		plans:   mm.Plans,
+		task:    planTask,
+	}, nil
+}
+
+// newChangesModel constructs a split model listing resource changes, with a
+// preview of the attribute diff of the currently highlighted change.
+func newChangesModel(plans *plan.Service, changes []*plan.ResourceChange, width, height int) split.Model[*plan.ResourceChange] {
+	renderer := func(rc *plan.ResourceChange) table.RenderedRow {
+		action := rc.Action()
+		return table.RenderedRow{
+			actionColumn.Key:  tui.Regular.Foreground(actionColors[action]).Render(string(action)),
+			addressColumn.Key: rc.Address,
+		}
+	}
+	m := split.New(split.Options[*plan.ResourceChange]{
+		Columns:  []table.Column{actionColumn, addressColumn},
+		Renderer: renderer,
+		TableOptions: []table.Option[*plan.ResourceChange]{
+			table.WithSortFunc(plan.SortChanges),
+		},
+		Width:  width,
+		Height: height,
+		Maker:  &changeMaker{plans: plans},
+	})
+	m.Table.SetItems(changes...)
+	return m
+}
+
+type planModel struct {
+	split.Model[*plan.ResourceChange]
+	*tui.Helpers
+
+	plans *plan.Service
+	task  *task.Task
+}
+
+func (m planModel) Init() tea.Cmd {
+	// Trigger the creation of a preview for the current row.
+	return tui.CmdHandler(changesLoadedMsg{})
+}
+
+// changesLoadedMsg is sent once the plan model has been populated with
+// resource changes.
+type changesLoadedMsg struct{}
+
+func (m planModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var (
+		cmd  tea.Cmd
+		cmds []tea.Cmd
+	)
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Common.Apply):
+			return m, m.ApplyPlan(m.task.ID)
+		}
+	}
+
+	// Handle keyboard and mouse events in the table widget
+	m.Model, cmd = m.Model.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m planModel) Title() string {
+	return m.Breadcrumbs("Plan", m.task)
+}
+
+func (m planModel) HelpBindings() []key.Binding {
+	bindings := []key.Binding{
+		keys.Common.Apply,
+	}
+	return append(bindings, keys.KeyMapToSlice(split.Keys)...)
+}
+
+// changeMaker makes models that show the attribute diff of a resource change.
+type changeMaker struct {
+	plans *plan.Service
+}
+
+func (mm *changeMaker) Make(id resource.ID, width, height int) (tea.Model, error) {
+	rc, err := mm.plans.GetResourceChange(id)
+	if err != nil {
+		return nil, err
+	}
+	m := changeModel{
+		viewport: tui.NewViewport(tui.ViewportOptions{
+			Width:  width,
+			Height: height,
+		}),
+	}
+	m.viewport.AppendContent([]byte(renderDiff(rc)), true)
+	return m, nil
+}
+
+type changeModel struct {
+	viewport tui.Viewport
+}
+
+func (m changeModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m changeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.viewport.SetDimensions(msg.Width, msg.Height)
+		return m, nil
+	}
+
+	// Handle keyboard and mouse events in the viewport
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m changeModel) View() string {
+	return m.viewport.View()
+}
+
+// renderDiff renders the attribute diff of a resource change, in a similar
+// fashion to terraform.
+func renderDiff(rc *plan.ResourceChange) string {
+	var (
+		action = rc.Action()
+		lines  []string
+	)
+	header := fmt.Sprintf("%s will be %s", tui.Bold.Render(rc.Address), actionDescriptions[action])
+	if rc.ActionReason != "" {
+		header += fmt.Sprintf(" (%s)", strings.ReplaceAll(rc.ActionReason, "_", " "))
+	}
+	lines = append(lines, header, "")
+
+	var (
+		added   = tui.Regular.Foreground(tui.Green)
+		changed = tui.Regular.Foreground(tui.Yellow)
+		removed = tui.Regular.Foreground(tui.Red)
+	)
+	for _, diff := range rc.Diff() {
+		var line string
+		switch {
+		case diff.Before == "":
+			line = added.Render("+") + fmt.Sprintf(" %s = %s", diff.Path, diff.After)
+		case diff.After == "":
+			line = removed.Render("-") + fmt.Sprintf(" %s = %s", diff.Path, diff.Before)
+		default:
+			line = changed.Render("~") + fmt.Sprintf(" %s = %s -> %s", diff.Path, diff.Before, diff.After)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+var actionDescriptions = map[plan.ChangeAction]string{
+	plan.CreateAction:  "created",
+	plan.UpdateAction:  "updated in-place",
+	plan.ReplaceAction: "replaced",
+	plan.DeleteAction:  "destroyed",
+}
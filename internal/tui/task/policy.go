@@ -0,0 +1,57 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/tui"
+)
+
+// violationsView renders the policy violations of a plan task, or the reason
+// its plan file could not be shown and checked against policy. An empty string
+// is returned if there are no violations.
+func (m model) violationsView() string {
+	if m.task.Identifier != plan.PlanTask {
+		return ""
+	}
+	if err := m.plans.ShowError(m.task.ID); err != nil {
+		// The plan's changes are unavailable, so they could not be checked
+		// against policy.
+		return m.showErrorView(err)
+	}
+	violations := m.plans.Violations(m.task.ID)
+	if len(violations) == 0 {
+		return ""
+	}
+	lines := []string{
+		tui.Bold.Foreground(tui.Red).Render(
+			fmt.Sprintf("Plan violates %d policy rule(s)", len(violations)),
+		),
+	}
+	for _, v := range violations {
+		lines = append(lines, fmt.Sprintf("%s %s", tui.Regular.Foreground(tui.Red).Render("✗"), v))
+	}
+	return tui.Regular.
+		// Border beneath, dividing the violations from the output
+		Border(lipgloss.NormalBorder(), false, false, true, false).
+		BorderForeground(tui.Red).
+		MaxWidth(m.viewportWidth()).
+		Render(strings.Join(lines, "\n"))
+}
+
+// showErrorView renders the reason the plan file could not be shown.
+func (m model) showErrorView(err error) string {
+	lines := []string{
+		tui.Bold.Foreground(tui.Red).Render("Failed to show plan"),
+		err.Error(),
+		fmt.Sprintf("Press %s to retry", localKeys.ViewPlan.Help().Key),
+	}
+	return tui.Regular.
+		// Border beneath, dividing the error from the output
+		Border(lipgloss.NormalBorder(), false, false, true, false).
+		BorderForeground(tui.Red).
+		MaxWidth(m.viewportWidth()).
+		Render(strings.Join(lines, "\n"))
+}
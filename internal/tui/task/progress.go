@@ -0,0 +1,95 @@
+package task
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/tui"
+)
+
+// maxProgressRows is the maximum number of resources to show in the apply
+// progress table.
+const maxProgressRows = 10
+
+// progressOrder orders resources by status, with those being applied
+// first, because they're the ones the user is waiting upon.
+var progressOrder = map[plan.ResourceStatus]int{
+	plan.ResourceApplying: 0,
+	plan.ResourceErrored:  1,
+	plan.ResourcePending:  2,
+	plan.ResourceComplete: 3,
+}
+
+// progressView renders a table of the progress of each resource being
+// changed by a running apply task. An empty string is returned if there is
+// no progress to render.
+func (m model) progressView() string {
+	if m.task.State != task.Running {
+		return ""
+	}
+	progress, ok := m.task.Progress().(*plan.ApplyProgress)
+	if !ok {
+		return ""
+	}
+	resources := progress.Resources()
+	if len(resources) == 0 {
+		return ""
+	}
+	now := time.Now()
+	// Show resources that have been applying the longest first.
+	slices.SortStableFunc(resources, func(a, b plan.ResourceProgress) int {
+		if n := cmp.Compare(progressOrder[a.Status], progressOrder[b.Status]); n != 0 {
+			return n
+		}
+		return a.Started.Compare(b.Started)
+	})
+	// Limit number of rows to leave space for the output.
+	rows := min(len(resources), maxProgressRows, max(1, m.height/3))
+
+	addrWidth := 0
+	for _, r := range resources[:rows] {
+		addrWidth = max(addrWidth, len(r.Addr))
+	}
+	lines := []string{
+		fmt.Sprintf("%s %s", tui.Bold.Render("Apply progress"), progress.String()),
+	}
+	for _, r := range resources[:rows] {
+		var (
+			symbol  string
+			elapsed = r.Elapsed
+		)
+		switch r.Status {
+		case plan.ResourceApplying:
+			symbol = m.spinner.View()
+			elapsed = now.Sub(r.Started)
+		case plan.ResourceComplete:
+			symbol = tui.Regular.Foreground(tui.Green).Render("✓")
+		case plan.ResourceErrored:
+			symbol = tui.Regular.Foreground(tui.Red).Render("✗")
+		default:
+			symbol = tui.Regular.Foreground(tui.LightGrey).Render("•")
+		}
+		line := fmt.Sprintf("%s %-*s %-8s", symbol, addrWidth, r.Addr, r.Action)
+		if r.Status != plan.ResourcePending {
+			line += " " + elapsed.Round(time.Second).String()
+		}
+		lines = append(lines, line)
+	}
+	if remaining := len(resources) - rows; remaining > 0 {
+		lines = append(lines, tui.Regular.Foreground(tui.LightGrey).Render(
+			fmt.Sprintf("...and %d more", remaining),
+		))
+	}
+	return tui.Regular.
+		// Border beneath, dividing the progress from the output
+		Border(lipgloss.NormalBorder(), false, false, true, false).
+		BorderForeground(tui.LighterGrey).
+		MaxWidth(m.viewportWidth()).
+		Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,58 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/tui"
+)
+
+// hold task(s)
+func hold(tasks *task.Service, taskIDs ...resource.ID) tea.Cmd {
+	return holdOrRelease(tasks.Hold, "held", taskIDs...)
+}
+
+// release held task(s)
+func release(tasks *task.Service, taskIDs ...resource.ID) tea.Cmd {
+	return holdOrRelease(tasks.Release, "released", taskIDs...)
+}
+
+func holdOrRelease(fn func(resource.ID) (*task.Task, error), action string, taskIDs ...resource.ID) tea.Cmd {
+	switch len(taskIDs) {
+	case 0:
+		return nil
+	case 1:
+		return func() tea.Msg {
+			if _, err := fn(taskIDs[0]); err != nil {
+				return tui.ErrorMsg(err)
+			}
+			return tui.InfoMsg(action + " task")
+		}
+	default:
+		return func() tea.Msg {
+			var errored bool
+			for _, id := range taskIDs {
+				if _, err := fn(id); err != nil {
+					errored = true
+				}
+			}
+			if errored {
+				return tui.ErrorMsg(errors.New("one or more tasks could not be " + action + "; see logs"))
+			}
+			return tui.InfoMsg(fmt.Sprintf("%s %d tasks", action, len(taskIDs)))
+		}
+	}
+}
+
+// move a pending task up or down the queue
+func move(tasks *task.Service, taskID resource.ID, offset int) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := tasks.Move(taskID, offset); err != nil {
+			return tui.ErrorMsg(fmt.Errorf("moving task: %w", err))
+		}
+		return nil
+	}
+}
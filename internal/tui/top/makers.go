@@ -17,16 +17,7 @@ type updateableMaker interface {
 }
 
 // makeMakers makes model makers for making models
-func makeMakers(cfg app.Config, app *app.App, spinner *spinner.Model) map[tui.Kind]tui.Maker {
-	helpers := &tui.Helpers{
-		Modules:    app.Modules,
-		Workspaces: app.Workspaces,
-		Plans:      app.Plans,
-		States:     app.States,
-		Tasks:      app.Tasks,
-		Logger:     app.Logger,
-	}
-
+func makeMakers(cfg app.Config, app *app.App, spinner *spinner.Model, helpers *tui.Helpers) map[tui.Kind]tui.Maker {
 	workspaceListMaker := &workspacetui.ListMaker{
 		Workspaces: app.Workspaces,
 		Modules:    app.Modules,
@@ -91,6 +82,50 @@ func makeMakers(cfg app.Config, app *app.App, spinner *spinner.Model) map[tui.Ki
 			Plans:   app.Plans,
 			Helpers: helpers,
 		},
+		tui.PlanKind: &tasktui.PlanMaker{
+			Plans:   app.Plans,
+			Tasks:   app.Tasks,
+			Helpers: helpers,
+		},
+		tui.ArchiveListKind: &tasktui.ArchiveListMaker{
+			Plans:   app.Plans,
+			Helpers: helpers,
+		},
+		tui.ArchivedPlanKind: &tasktui.ArchivedPlanMaker{
+			Plans:   app.Plans,
+			Helpers: helpers,
+		},
+		tui.OutputListKind: &workspacetui.OutputListMaker{
+			Workspaces: app.Workspaces,
+			States:     app.States,
+			Workdir:    cfg.Workdir,
+			DataDir:    cfg.DataDir,
+			Helpers:    helpers,
+		},
+		tui.StateHistoryKind: &workspacetui.HistoryMaker{
+			Workspaces: app.Workspaces,
+			States:     app.States,
+			Helpers:    helpers,
+		},
+		tui.ResourceTreeKind: &workspacetui.ResourceTreeMaker{
+			Workspaces: app.Workspaces,
+			States:     app.States,
+			Plans:      app.Plans,
+			Helpers:    helpers,
+		},
+		tui.DriftHistoryKind: &workspacetui.DriftHistoryMaker{
+			Workspaces: app.Workspaces,
+			Helpers:    helpers,
+		},
+		tui.SearchKind: &workspacetui.SearchMaker{
+			States:  app.States,
+			Helpers: helpers,
+		},
+		tui.StateDiffKind: &workspacetui.DiffMaker{
+			Workspaces: app.Workspaces,
+			States:     app.States,
+			Helpers:    helpers,
+		},
 	}
 	return makers
 }
@@ -49,6 +49,7 @@ type model struct {
 	spinner  *spinner.Model
 	spinning bool
 	maxTasks int
+	helpers  *tui.Helpers
 }
 
 func newModel(cfg app.Config, app *app.App) (model, error) {
@@ -66,9 +67,18 @@ func newModel(cfg app.Config, app *app.App) (model, error) {
 	_ = lipgloss.HasDarkBackground()
 
 	spinner := spinner.New(spinner.WithSpinner(spinner.Line))
-	makers := makeMakers(cfg, app, &spinner)
+	helpers := &tui.Helpers{
+		Modules:    app.Modules,
+		Workspaces: app.Workspaces,
+		Plans:      app.Plans,
+		States:     app.States,
+		Tasks:      app.Tasks,
+		Logger:     app.Logger,
+	}
+	makers := makeMakers(cfg, app, &spinner, helpers)
 
 	m := model{
+		helpers:  helpers,
 		modules:  app.Modules,
 		spinner:  &spinner,
 		tasks:    app.Tasks,
@@ -212,6 +222,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, keys.Global.Logs):
 			// show logs
 			return m, tui.NavigateTo(tui.LogListKind)
+		case key.Matches(msg, keys.Global.Plans):
+			// list archived plans
+			return m, tui.NavigateTo(tui.ArchiveListKind)
+		case key.Matches(msg, keys.Global.Search):
+			// search state resources in all workspaces
+			return m, m.helpers.Search()
 		case key.Matches(msg, keys.Global.Modules):
 			// list all modules
 			return m, tui.NavigateTo(tui.ModuleListKind)
@@ -224,6 +240,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, keys.Global.TaskGroups):
 			// list all taskgroups
 			return m, tui.NavigateTo(tui.TaskGroupListKind)
+		case key.Matches(msg, keys.Global.PauseQueue):
+			// pause or resume the task queue
+			if m.tasks.Paused() {
+				m.tasks.Resume()
+				return m, tui.ReportInfo("resumed task queue")
+			}
+			m.tasks.Pause()
+			return m, tui.ReportInfo("paused task queue: running tasks continue to completion")
 		default:
 			// Send other keys to current model.
 			if cmd := m.updateCurrent(msg); cmd != nil {
@@ -353,11 +377,17 @@ func (m model) View() string {
 			Background(tui.EvenLighterGrey).
 			Render(m.info)
 	}
+	// Show whether task queue is paused.
+	var paused string
+	if m.tasks.Paused() {
+		paused = tui.Padded.Background(tui.Red).Foreground(tui.White).Render("queue paused")
+	}
 	workdir := tui.Padded.Background(tui.LightGrey).Foreground(tui.White).Render(m.workdir)
 	version := tui.Padded.Background(tui.DarkGrey).Foreground(tui.White).Render(version.Version)
 	// Fill in left over space with background color
-	leftover = m.width - tui.Width(footer) - tui.Width(workdir) - tui.Width(version)
+	leftover = m.width - tui.Width(footer) - tui.Width(paused) - tui.Width(workdir) - tui.Width(version)
 	footer += tui.Regular.Width(leftover).Background(tui.EvenLighterGrey).Render()
+	footer += paused
 	footer += workdir
 	footer += version
 
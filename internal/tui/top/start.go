@@ -141,6 +141,16 @@ func setupSubscriptions(app *app.App, cfg app.Config) (chan tea.Msg, func()) {
 		}()
 
 	}
+	{
+		sub := app.Plans.ArchiveBroker.Subscribe(ctx)
+		wg.Add(1)
+		go func() {
+			for ev := range sub {
+				ch <- ev
+			}
+			wg.Done()
+		}()
+	}
 	{
 		sub := app.Tasks.TaskBroker.Subscribe(ctx)
 		wg.Add(1)
@@ -0,0 +1,110 @@
+package workspace
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/tui/keys"
+)
+
+// resourceActions creates tasks that act upon resources in a workspace's
+// state, and is shared by the pages listing those resources.
+type resourceActions struct {
+	*tui.Helpers
+
+	states      *state.Service
+	plans       *plan.Service
+	workspaceID resource.ID
+}
+
+// update handles a key for an action upon the given resources, which are
+// either the selected resources or the current resource. Current is the
+// address of the current resource, or empty if there is none. False is
+// returned if the key is not for an action.
+func (a resourceActions) update(msg tea.KeyMsg, addrs []state.ResourceAddress, current state.ResourceAddress) (tea.Cmd, bool) {
+	var (
+		createRunOptions plan.CreateOptions
+		applyPrompt      = "Auto-apply %d resources?"
+	)
+	switch {
+	case key.Matches(msg, keys.Common.Delete):
+		if len(addrs) == 0 {
+			// no rows; do nothing
+			return nil, true
+		}
+		fn := func(workspaceID resource.ID) (task.Spec, error) {
+			return a.states.Delete(workspaceID, addrs...)
+		}
+		return tui.YesNoPrompt(
+			fmt.Sprintf("Delete %d resource(s)?", len(addrs)),
+			a.CreateTasks(fn, a.workspaceID),
+		), true
+	case key.Matches(msg, resourcesKeys.Taint):
+		return a.createStateCommand(a.states.Taint, addrs...), true
+	case key.Matches(msg, resourcesKeys.Untaint):
+		return a.createStateCommand(a.states.Untaint, addrs...), true
+	case key.Matches(msg, resourcesKeys.Move):
+		if current == "" {
+			return nil, true
+		}
+		return a.Move(a.workspaceID, current), true
+	case key.Matches(msg, keys.Common.PlanDestroy):
+		// Create a targeted destroy plan.
+		createRunOptions.Destroy = true
+		fallthrough
+	case key.Matches(msg, keys.Common.Plan):
+		// Create a targeted plan.
+		createRunOptions.TargetAddrs = addrs
+		// NOTE: even if the user hasn't selected any rows, we still proceed
+		// to create a run without targeted resources.
+		fn := func(workspaceID resource.ID) (task.Spec, error) {
+			return a.plans.Plan(workspaceID, createRunOptions)
+		}
+		return a.CreateTasks(fn, a.workspaceID), true
+	case key.Matches(msg, resourcesKeys.Replace):
+		// Create a plan replacing resources.
+		createRunOptions.ReplaceAddrs = addrs
+		if len(createRunOptions.ReplaceAddrs) == 0 {
+			// no rows; do nothing
+			return nil, true
+		}
+		fn := func(workspaceID resource.ID) (task.Spec, error) {
+			return a.plans.Plan(workspaceID, createRunOptions)
+		}
+		return a.CreateTasks(fn, a.workspaceID), true
+	case key.Matches(msg, keys.Common.Destroy):
+		createRunOptions.Destroy = true
+		applyPrompt = "Destroy %d resources?"
+		fallthrough
+	case key.Matches(msg, keys.Common.Apply):
+		// Create a targeted apply.
+		createRunOptions.TargetAddrs = addrs
+		fn := func(workspaceID resource.ID) (task.Spec, error) {
+			return a.plans.Apply(workspaceID, createRunOptions)
+		}
+		return tui.YesNoPrompt(
+			fmt.Sprintf(applyPrompt, len(addrs)),
+			a.CreateTasks(fn, a.workspaceID),
+		), true
+	}
+	return nil, false
+}
+
+// actionBindings are the key bindings for actions upon resources.
+var actionBindings = []key.Binding{
+	keys.Common.Plan,
+	keys.Common.PlanDestroy,
+	keys.Common.Apply,
+	keys.Common.Destroy,
+	keys.Common.Delete,
+	resourcesKeys.Move,
+	resourcesKeys.Replace,
+	resourcesKeys.Taint,
+	resourcesKeys.Untaint,
+}
@@ -0,0 +1,127 @@
+package workspace
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/tui/keys"
+	"github.com/leg100/pug/internal/workspace"
+)
+
+// DriftHistoryMaker makes models that show the history of drift detected in a
+// workspace.
+type DriftHistoryMaker struct {
+	Workspaces *workspace.Service
+	Helpers    *tui.Helpers
+}
+
+func (mm *DriftHistoryMaker) Make(id resource.ID, width, height int) (tea.Model, error) {
+	ws, err := mm.Workspaces.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	m := driftModel{
+		Helpers:   mm.Helpers,
+		workspace: ws,
+		width:     width,
+		height:    height,
+	}
+	m.viewport = m.newViewport()
+	return m, nil
+}
+
+type driftModel struct {
+	*tui.Helpers
+
+	viewport  tui.Viewport
+	workspace *workspace.Workspace
+	width     int
+	height    int
+}
+
+func (m driftModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m driftModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Common.State):
+			return m, tui.NavigateTo(tui.ResourceListKind, tui.WithParent(m.workspace.ID))
+		}
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.viewport.SetDimensions(msg.Width, msg.Height)
+		return m, nil
+	case resource.Event[*workspace.Workspace]:
+		if msg.Payload.ID != m.workspace.ID {
+			return m, nil
+		}
+		// A check for drift may have been recorded, so re-render.
+		m.workspace = msg.Payload
+		m.viewport = m.newViewport()
+		return m, nil
+	}
+
+	// Handle keyboard and mouse events in the viewport
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m driftModel) newViewport() tui.Viewport {
+	viewport := tui.NewViewport(tui.ViewportOptions{
+		Width:  m.width,
+		Height: m.height,
+	})
+	viewport.AppendContent([]byte(renderDriftHistory(m.workspace.Drift, time.Now())), true)
+	return viewport
+}
+
+func (m driftModel) View() string {
+	return m.viewport.View()
+}
+
+func (m driftModel) Title() string {
+	return m.Breadcrumbs("Drift History", m.workspace)
+}
+
+func (m driftModel) HelpBindings() []key.Binding {
+	return []key.Binding{keys.Common.State}
+}
+
+// renderDriftHistory renders when drift was last checked, followed by each
+// occasion drift appeared or was resolved, newest first.
+func renderDriftHistory(drift *workspace.Drift, now time.Time) string {
+	if drift == nil {
+		return "Drift has not been checked"
+	}
+	var (
+		appeared = tui.Regular.Foreground(tui.Red)
+		resolved = tui.Regular.Foreground(tui.Green)
+		lines    []string
+	)
+	header := fmt.Sprintf("Last checked %s", tui.Ago(now, drift.Checked))
+	lines = append(lines, tui.Bold.Render(header), "")
+	if len(drift.History) == 0 {
+		lines = append(lines, "No drift detected")
+		return strings.Join(lines, "\n")
+	}
+	for i := len(drift.History) - 1; i >= 0; i-- {
+		event := drift.History[i]
+		when := event.Time.Format(time.DateTime)
+		if event.Drifted {
+			lines = append(lines, fmt.Sprintf("%s %s: %s", when, appeared.Render("drift detected"), event.Summary))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s %s", when, resolved.Render("drift resolved")))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,264 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/tui/keys"
+	"github.com/leg100/pug/internal/tui/table"
+	"github.com/leg100/pug/internal/workspace"
+)
+
+var (
+	serialColumn = table.Column{
+		Key:   "serial",
+		Title: "SERIAL",
+		Width: len("SERIAL"),
+	}
+	lineageColumn = table.Column{
+		Key:        "lineage",
+		Title:      "LINEAGE",
+		FlexFactor: 1,
+	}
+	resourcesColumn = table.Column{
+		Key:   "resources",
+		Title: "RESOURCES",
+		Width: len("RESOURCES"),
+	}
+	recordedColumn = table.Column{
+		Key:   "recorded",
+		Title: "RECORDED",
+		Width: 10,
+	}
+)
+
+// HistoryMaker makes models that list the states retained for a workspace.
+type HistoryMaker struct {
+	Workspaces *workspace.Service
+	States     *state.Service
+	Helpers    *tui.Helpers
+}
+
+func (mm *HistoryMaker) Make(id resource.ID, width, height int) (tea.Model, error) {
+	ws, err := mm.Workspaces.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	renderer := func(s *state.State) table.RenderedRow {
+		return table.RenderedRow{
+			serialColumn.Key:    fmt.Sprintf("%d", s.Serial),
+			lineageColumn.Key:   s.Lineage,
+			resourcesColumn.Key: fmt.Sprintf("%d", len(s.Resources)),
+			recordedColumn.Key:  tui.Ago(time.Now(), mm.States.Recorded(s.ID)),
+		}
+	}
+	return historyList{
+		Model: table.New(
+			[]table.Column{serialColumn, lineageColumn, resourcesColumn, recordedColumn},
+			renderer,
+			width,
+			height,
+			table.WithSortFunc(state.SortHistory),
+		),
+		Helpers:   mm.Helpers,
+		states:    mm.States,
+		workspace: ws,
+	}, nil
+}
+
+type historyList struct {
+	table.Model[*state.State]
+	*tui.Helpers
+
+	states    *state.Service
+	workspace *workspace.Workspace
+}
+
+func (m historyList) Init() tea.Cmd {
+	return func() tea.Msg {
+		return table.BulkInsertMsg[*state.State](m.states.History(m.workspace.ID))
+	}
+}
+
+func (m historyList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Common.State):
+			return m, tui.NavigateTo(tui.ResourceListKind, tui.WithParent(m.workspace.ID))
+		case key.Matches(msg, historyKeys.Diff):
+			return m, m.diff()
+		}
+	case resource.Event[*state.State]:
+		if msg.Payload.WorkspaceID != m.workspace.ID {
+			return m, nil
+		}
+		switch msg.Type {
+		case resource.CreatedEvent, resource.UpdatedEvent:
+			// A new state may have been retained, and an old state discarded.
+			m.SetItems(m.states.History(m.workspace.ID)...)
+		}
+	}
+
+	// Handle keyboard and mouse events in the table widget
+	m.Model, cmd = m.Model.Update(msg)
+	return m, cmd
+}
+
+// diff navigates to the diff between two selected states, or if none are
+// selected, between the current state and the state retained before it.
+func (m historyList) diff() tea.Cmd {
+	var (
+		diff *state.Diff
+		err  error
+	)
+	switch ids := m.SelectedOrCurrentIDs(); len(ids) {
+	case 0:
+		return nil
+	case 1:
+		diff, err = m.states.DiffPrevious(ids[0])
+	case 2:
+		diff, err = m.states.Diff(ids[0], ids[1])
+	default:
+		err = errors.New("select two states to diff")
+	}
+	if err != nil {
+		return tui.ReportError(fmt.Errorf("diffing states: %w", err))
+	}
+	return tui.NavigateTo(tui.StateDiffKind, tui.WithParent(diff.ID))
+}
+
+func (m historyList) Title() string {
+	return m.Breadcrumbs("State History", m.workspace)
+}
+
+func (m historyList) HelpBindings() []key.Binding {
+	return []key.Binding{
+		historyKeys.Diff,
+		keys.Common.State,
+	}
+}
+
+// DiffMaker makes models that show the difference between two states.
+type DiffMaker struct {
+	Workspaces *workspace.Service
+	States     *state.Service
+	Helpers    *tui.Helpers
+}
+
+func (mm *DiffMaker) Make(id resource.ID, width, height int) (tea.Model, error) {
+	diff, err := mm.States.GetDiff(id)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := mm.Workspaces.Get(diff.To.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	m := diffModel{
+		Helpers: mm.Helpers,
+		viewport: tui.NewViewport(tui.ViewportOptions{
+			Width:  width,
+			Height: height,
+		}),
+		diff:      diff,
+		workspace: ws,
+	}
+	m.viewport.AppendContent([]byte(renderStateDiff(diff)), true)
+	return m, nil
+}
+
+type diffModel struct {
+	*tui.Helpers
+
+	viewport  tui.Viewport
+	diff      *state.Diff
+	workspace *workspace.Workspace
+}
+
+func (m diffModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m diffModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Common.State):
+			return m, tui.NavigateTo(tui.ResourceListKind, tui.WithParent(m.workspace.ID))
+		}
+	case tea.WindowSizeMsg:
+		m.viewport.SetDimensions(msg.Width, msg.Height)
+		return m, nil
+	}
+
+	// Handle keyboard and mouse events in the viewport
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m diffModel) View() string {
+	return m.viewport.View()
+}
+
+func (m diffModel) Title() string {
+	serials := tui.TitleSerial.Render(m.diff.String())
+	return m.Breadcrumbs("State Diff", m.workspace, serials)
+}
+
+func (m diffModel) HelpBindings() []key.Binding {
+	return []key.Binding{keys.Common.State}
+}
+
+// renderStateDiff renders the resources added and removed, and the attribute
+// changes of resources present in both states.
+func renderStateDiff(diff *state.Diff) string {
+	var (
+		added   = tui.Regular.Foreground(tui.Green)
+		changed = tui.Regular.Foreground(tui.Yellow)
+		removed = tui.Regular.Foreground(tui.Red)
+		lines   []string
+	)
+	header := fmt.Sprintf("Changes from serial %d to %d", diff.From.Serial, diff.To.Serial)
+	if diff.From.Lineage != diff.To.Lineage {
+		header += " (lineage differs)"
+	}
+	lines = append(lines, tui.Bold.Render(header), "")
+	if diff.IsEmpty() {
+		lines = append(lines, "No resources changed")
+		return strings.Join(lines, "\n")
+	}
+	for _, addr := range diff.Added {
+		lines = append(lines, added.Render("+")+" "+string(addr))
+	}
+	for _, addr := range diff.Removed {
+		lines = append(lines, removed.Render("-")+" "+string(addr))
+	}
+	for _, rd := range diff.Changed {
+		lines = append(lines, changed.Render("~")+" "+string(rd.Address))
+		for _, attr := range rd.Attributes {
+			var line string
+			switch {
+			case attr.Before == "":
+				line = added.Render("+") + fmt.Sprintf(" %s = %s", attr.Path, attr.After)
+			case attr.After == "":
+				line = removed.Render("-") + fmt.Sprintf(" %s = %s", attr.Path, attr.Before)
+			default:
+				line = changed.Render("~") + fmt.Sprintf(" %s = %s -> %s", attr.Path, attr.Before, attr.After)
+			}
+			lines = append(lines, "    "+line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
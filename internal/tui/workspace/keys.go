@@ -6,6 +6,7 @@ import (
 
 type keyMap struct {
 	SetCurrent key.Binding
+	Drift      key.Binding
 	Enter      key.Binding
 }
 
@@ -14,6 +15,10 @@ var localKeys = keyMap{
 		key.WithKeys("C"),
 		key.WithHelp("C", "set current"),
 	),
+	Drift: key.NewBinding(
+		key.WithKeys("H"),
+		key.WithHelp("H", "drift history"),
+	),
 	Enter: key.NewBinding(
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "state"),
@@ -24,7 +29,11 @@ type resourcesKeyMap struct {
 	Taint   key.Binding
 	Untaint key.Binding
 	Move    key.Binding
+	Replace key.Binding
 	Reload  key.Binding
+	Outputs key.Binding
+	History key.Binding
+	Tree    key.Binding
 	Enter   key.Binding
 }
 
@@ -41,12 +50,71 @@ var resourcesKeys = resourcesKeyMap{
 		key.WithKeys("M"),
 		key.WithHelp("M", "move"),
 	),
+	Replace: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "plan replace"),
+	),
 	Reload: key.NewBinding(
 		key.WithKeys("ctrl+r"),
 		key.WithHelp("ctrl+r", "reload"),
 	),
+	Outputs: key.NewBinding(
+		key.WithKeys("ctrl+o"),
+		key.WithHelp("ctrl+o", "outputs"),
+	),
+	History: key.NewBinding(
+		key.WithKeys("H"),
+		key.WithHelp("H", "history"),
+	),
+	Tree: key.NewBinding(
+		key.WithKeys("ctrl+g"),
+		key.WithHelp("ctrl+g", "toggle tree"),
+	),
 	Enter: key.NewBinding(
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "view resource"),
 	),
 }
+
+type outputsKeyMap struct {
+	Reveal key.Binding
+	Copy   key.Binding
+	Export key.Binding
+}
+
+var outputsKeys = outputsKeyMap{
+	Reveal: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "reveal sensitive"),
+	),
+	Copy: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy value"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "export"),
+	),
+}
+
+type historyKeyMap struct {
+	Diff key.Binding
+}
+
+var historyKeys = historyKeyMap{
+	Diff: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "diff"),
+	),
+}
+
+type treeKeyMap struct {
+	Toggle key.Binding
+}
+
+var treeKeys = treeKeyMap{
+	Toggle: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "expand/collapse"),
+	),
+}
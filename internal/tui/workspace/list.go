@@ -21,6 +21,18 @@ var currentColumn = table.Column{
 	Width: len("CURRENT"),
 }
 
+var varFilesColumn = table.Column{
+	Key:        "var_files",
+	Title:      "VAR FILES",
+	FlexFactor: 1,
+}
+
+var driftColumn = table.Column{
+	Key:        "drift",
+	Title:      "DRIFT",
+	FlexFactor: 1,
+}
+
 type ListMaker struct {
 	Modules    *module.Service
 	Workspaces *workspace.Service
@@ -34,6 +46,8 @@ func (m *ListMaker) Make(_ resource.ID, width, height int) (tea.Model, error) {
 		table.WorkspaceColumn,
 		currentColumn,
 		table.CostColumn,
+		driftColumn,
+		varFilesColumn,
 		table.ResourceCountColumn,
 	}
 
@@ -44,6 +58,8 @@ func (m *ListMaker) Make(_ resource.ID, width, height int) (tea.Model, error) {
 			table.ResourceCountColumn.Key: m.Helpers.WorkspaceResourceCount(ws),
 			table.CostColumn.Key:          m.Helpers.WorkspaceCost(ws),
 			currentColumn.Key:             m.Helpers.WorkspaceCurrentCheckmark(ws),
+			driftColumn.Key:               m.Helpers.WorkspaceDrift(ws),
+			varFilesColumn.Key:            m.Helpers.WorkspaceVarFiles(ws),
 		}
 	}
 
@@ -144,17 +160,24 @@ func (m list) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, keys.Common.PlanDestroy):
 			createRunOptions.Destroy = true
 			fallthrough
-		case key.Matches(msg, keys.Common.Plan):
+		case key.Matches(msg, keys.Common.Plan, keys.Common.PlanRefresh):
+			createRunOptions.RefreshOnly = key.Matches(msg, keys.Common.PlanRefresh)
 			workspaceIDs := m.table.SelectedOrCurrentIDs()
 			fn := func(workspaceID resource.ID) (task.Spec, error) {
 				return m.Plans.Plan(workspaceID, createRunOptions)
 			}
 			return m, m.CreateTasks(fn, workspaceIDs...)
+		case key.Matches(msg, keys.Common.PlanVars):
+			return m, m.PlanWithVariables(m.table.SelectedOrCurrentIDs()...)
 		case key.Matches(msg, keys.Common.Destroy):
 			createRunOptions.Destroy = true
 			applyPrompt = "Destroy resources of %d workspaces?"
 			fallthrough
-		case key.Matches(msg, keys.Common.Apply):
+		case key.Matches(msg, keys.Common.Apply, keys.Common.ApplyRefresh):
+			if key.Matches(msg, keys.Common.ApplyRefresh) {
+				createRunOptions.RefreshOnly = true
+				applyPrompt = "Refresh state of %d workspaces?"
+			}
 			workspaceIDs := m.table.SelectedOrCurrentIDs()
 			fn := func(workspaceID resource.ID) (task.Spec, error) {
 				return m.Plans.Apply(workspaceID, createRunOptions)
@@ -167,6 +190,10 @@ func (m list) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if row, ok := m.table.CurrentRow(); ok {
 				return m, tui.NavigateTo(tui.ResourceListKind, tui.WithParent(row.ID))
 			}
+		case key.Matches(msg, localKeys.Drift):
+			if row, ok := m.table.CurrentRow(); ok {
+				return m, tui.NavigateTo(tui.DriftHistoryKind, tui.WithParent(row.ID))
+			}
 		case key.Matches(msg, keys.Common.Cost):
 			workspaceIDs := m.table.SelectedOrCurrentIDs()
 			spec, err := m.Workspaces.Cost(workspaceIDs...)
@@ -200,11 +227,15 @@ func (m list) HelpBindings() []key.Binding {
 		keys.Common.Validate,
 		keys.Common.Plan,
 		keys.Common.PlanDestroy,
+		keys.Common.PlanRefresh,
+		keys.Common.PlanVars,
 		keys.Common.Apply,
 		keys.Common.Destroy,
+		keys.Common.ApplyRefresh,
 		keys.Common.Delete,
 		keys.Common.Cost,
 		localKeys.SetCurrent,
+		localKeys.Drift,
 		keys.Common.State,
 	}
 }
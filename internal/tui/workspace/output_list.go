@@ -0,0 +1,271 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/tui/keys"
+	"github.com/leg100/pug/internal/tui/table"
+	"github.com/leg100/pug/internal/workspace"
+	"golang.org/x/exp/maps"
+)
+
+var (
+	outputNameColumn = table.Column{
+		Key:        "name",
+		Title:      "NAME",
+		FlexFactor: 1,
+	}
+	outputValueColumn = table.Column{
+		Key:        "value",
+		Title:      "VALUE",
+		FlexFactor: 2,
+	}
+)
+
+// maskedOutputValue is rendered in place of the value of a sensitive output
+// until sensitive values are revealed.
+const maskedOutputValue = "<sensitive>"
+
+// OutputListMaker makes models that list the outputs in a workspace's state.
+type OutputListMaker struct {
+	Workspaces *workspace.Service
+	States     *state.Service
+	Workdir    internal.Workdir
+	Helpers    *tui.Helpers
+	// DataDir is the directory to which outputs are exported by default.
+	DataDir string
+}
+
+func (mm *OutputListMaker) Make(id resource.ID, width, height int) (tea.Model, error) {
+	ws, err := mm.Workspaces.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	// revealed is shared with the renderer, which masks sensitive values
+	// unless they have been revealed.
+	revealed := new(bool)
+	renderer := func(out *state.Output) table.RenderedRow {
+		value := out.Plain()
+		if out.Sensitive && !*revealed {
+			value = tui.Regular.Foreground(tui.Grey).Render(maskedOutputValue)
+		}
+		return table.RenderedRow{
+			outputNameColumn.Key:  out.Name,
+			outputValueColumn.Key: value,
+		}
+	}
+	return outputList{
+		Model: table.New(
+			[]table.Column{outputNameColumn, outputValueColumn},
+			renderer,
+			width,
+			height,
+			table.WithSortFunc(state.SortOutputs),
+		),
+		Helpers:   mm.Helpers,
+		states:    mm.States,
+		workdir:   mm.Workdir,
+		dataDir:   mm.DataDir,
+		workspace: ws,
+		revealed:  revealed,
+		width:     width,
+	}, nil
+}
+
+type outputList struct {
+	table.Model[*state.Output]
+	*tui.Helpers
+
+	states    *state.Service
+	workdir   internal.Workdir
+	dataDir   string
+	workspace *workspace.Workspace
+	state     *state.State
+	revealed  *bool
+	width     int
+}
+
+func (m outputList) Init() tea.Cmd {
+	return func() tea.Msg {
+		state, err := m.states.Get(m.workspace.ID)
+		if err != nil {
+			return tui.ReportError(fmt.Errorf("initializing outputs model: %w", err))
+		}
+		return initState(state)
+	}
+}
+
+func (m outputList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Common.State):
+			return m, tui.NavigateTo(tui.ResourceListKind, tui.WithParent(m.workspace.ID))
+		case key.Matches(msg, outputsKeys.Reveal):
+			*m.revealed = !*m.revealed
+			if m.state != nil {
+				// Re-render rows with or without sensitive values.
+				m.SetItems(maps.Values(m.state.Outputs)...)
+			}
+			return m, nil
+		case key.Matches(msg, outputsKeys.Copy):
+			row, ok := m.CurrentRow()
+			if !ok {
+				return m, nil
+			}
+			if row.Value.Sensitive && !*m.revealed {
+				// Don't copy a value the user hasn't chosen to see.
+				return m, tui.ReportError(fmt.Errorf("%s is sensitive: reveal sensitive values before copying", row.Value.Name))
+			}
+			if err := clipboard.WriteAll(row.Value.Plain()); err != nil {
+				return m, tui.ReportError(fmt.Errorf("copying output value: %w", err))
+			}
+			return m, tui.ReportInfo("copied value of %s to clipboard", row.Value.Name)
+		case key.Matches(msg, outputsKeys.Export):
+			if m.state == nil || len(m.state.Outputs) == 0 {
+				return m, tui.ReportError(errors.New("no outputs to export"))
+			}
+			return m, m.export()
+		}
+	case initState:
+		if msg.WorkspaceID != m.workspace.ID {
+			return m, nil
+		}
+		m.state = (*state.State)(msg)
+		m.SetItems(maps.Values(m.state.Outputs)...)
+	case resource.Event[*state.State]:
+		if msg.Payload.WorkspaceID != m.workspace.ID {
+			return m, nil
+		}
+		switch msg.Type {
+		case resource.CreatedEvent, resource.UpdatedEvent:
+			m.state = msg.Payload
+			m.SetItems(maps.Values(m.state.Outputs)...)
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	}
+
+	// Handle keyboard and mouse events in the table widget
+	m.Model, cmd = m.Model.Update(msg)
+	return m, cmd
+}
+
+// export prompts the user for the path of a file to which to export the
+// outputs, defaulting to a file in the data directory. A relative path is
+// relative to the module directory. The format is JSON if the path ends in
+// .json, otherwise it is dotenv. Sensitive outputs are only exported if
+// sensitive values have been revealed, and the user must confirm writing them
+// into the working directory, where they might be committed.
+func (m outputList) export() tea.Cmd {
+	return tui.CmdHandler(tui.PromptMsg{
+		Prompt:       "Export outputs to file (.json or .env): ",
+		InitialValue: filepath.Join(m.dataDir, "outputs", m.workspace.ModulePath, m.workspace.Name+".env"),
+		Action: func(v string) tea.Cmd {
+			if v == "" {
+				return nil
+			}
+			var (
+				outputs   []*state.Output
+				omitted   int
+				sensitive int
+			)
+			for _, out := range m.state.Outputs {
+				if out.Sensitive {
+					if !*m.revealed {
+						omitted++
+						continue
+					}
+					sensitive++
+				}
+				outputs = append(outputs, out)
+			}
+			var (
+				body []byte
+				err  error
+			)
+			if strings.HasSuffix(v, ".json") {
+				body, err = state.ExportOutputsJSON(outputs)
+				if err != nil {
+					return tui.ReportError(err)
+				}
+			} else {
+				body = state.ExportOutputsDotenv(outputs)
+			}
+			path := v
+			if !filepath.IsAbs(path) {
+				path = m.workdir.Join(m.workspace.ModulePath, v)
+			}
+			write := func() tea.Msg {
+				if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+					return tui.ErrorMsg(fmt.Errorf("exporting outputs: %w", err))
+				}
+				if err := os.WriteFile(path, body, 0o600); err != nil {
+					return tui.ErrorMsg(fmt.Errorf("exporting outputs: %w", err))
+				}
+				if omitted > 0 {
+					return tui.InfoMsg(fmt.Sprintf("exported %d outputs to %s; omitted %d sensitive outputs", len(outputs), v, omitted))
+				}
+				return tui.InfoMsg(fmt.Sprintf("exported %d outputs to %s", len(outputs), v))
+			}
+			if sensitive > 0 && m.inWorkdir(path) {
+				return tui.YesNoPrompt(
+					fmt.Sprintf("Write %d sensitive outputs into the working directory?", sensitive),
+					write,
+				)
+			}
+			return write
+		},
+		Key:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "confirm")),
+		Cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+	})
+}
+
+// inWorkdir determines whether the path is within the working directory.
+func (m outputList) inWorkdir(path string) bool {
+	rel, err := filepath.Rel(m.workdir.String(), path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func (m outputList) View() string {
+	if m.state == nil || m.state.Serial < 0 {
+		return tui.Regular.
+			Padding(0, 1).
+			Border(lipgloss.NormalBorder()).
+			// Subtract 2 to accomodate borders
+			Width(m.width - 2).
+			Render("No state found")
+	}
+	return m.Model.View()
+}
+
+func (m outputList) Title() string {
+	var serial string
+	if m.state != nil {
+		serial = serialBreadcrumb(m.state.Serial)
+	}
+	return m.Breadcrumbs("Outputs", m.workspace, serial)
+}
+
+func (m outputList) HelpBindings() []key.Binding {
+	return []key.Binding{
+		outputsKeys.Reveal,
+		outputsKeys.Copy,
+		outputsKeys.Export,
+		keys.Common.State,
+	}
+}
@@ -94,6 +94,13 @@ func (m resourceModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				"Delete resource?",
 				m.CreateTasks(fn, m.resource.WorkspaceID),
 			)
+		case key.Matches(msg, resourcesKeys.Replace):
+			// Create a plan replacing the resource.
+			createRunOptions.ReplaceAddrs = []state.ResourceAddress{m.resource.Address}
+			fn := func(workspaceID resource.ID) (task.Spec, error) {
+				return m.plans.Plan(workspaceID, createRunOptions)
+			}
+			return m, m.CreateTasks(fn, m.resource.WorkspaceID)
 		case key.Matches(msg, keys.Common.PlanDestroy):
 			// Create a targeted destroy plan.
 			createRunOptions.Destroy = true
@@ -153,6 +160,7 @@ func (m resourceModel) HelpBindings() []key.Binding {
 		keys.Common.PlanDestroy,
 		keys.Common.Delete,
 		resourcesKeys.Move,
+		resourcesKeys.Replace,
 		resourcesKeys.Taint,
 		resourcesKeys.Untaint,
 	}
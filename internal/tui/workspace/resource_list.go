@@ -3,6 +3,7 @@ package workspace
 import (
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
@@ -11,7 +12,6 @@ import (
 	"github.com/leg100/pug/internal/plan"
 	"github.com/leg100/pug/internal/resource"
 	"github.com/leg100/pug/internal/state"
-	"github.com/leg100/pug/internal/task"
 	"github.com/leg100/pug/internal/tui"
 	"github.com/leg100/pug/internal/tui/keys"
 	"github.com/leg100/pug/internal/tui/split"
@@ -73,6 +73,12 @@ func (m *ResourceListMaker) Make(id resource.ID, width, height int) (tea.Model,
 		width:     width,
 		height:    height,
 		Helpers:   m.Helpers,
+		actions: resourceActions{
+			Helpers:     m.Helpers,
+			states:      m.States,
+			plans:       m.Plans,
+			workspaceID: ws.ID,
+		},
 	}, nil
 }
 
@@ -87,6 +93,7 @@ type resourceList struct {
 	reloading bool
 	height    int
 	width     int
+	actions   resourceActions
 
 	spinner *spinner.Model
 }
@@ -111,10 +118,8 @@ type reloadedMsg struct {
 
 func (m resourceList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var (
-		cmd              tea.Cmd
-		cmds             []tea.Cmd
-		createRunOptions plan.CreateOptions
-		applyPrompt      = "Auto-apply %d resources?"
+		cmd  tea.Cmd
+		cmds []tea.Cmd
 	)
 
 	switch msg := msg.(type) {
@@ -130,6 +135,12 @@ func (m resourceList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if row, ok := m.Table.CurrentRow(); ok {
 				return m, tui.NavigateTo(tui.ResourceKind, tui.WithParent(row.ID))
 			}
+		case key.Matches(msg, resourcesKeys.Outputs):
+			return m, tui.NavigateTo(tui.OutputListKind, tui.WithParent(m.workspace.GetID()))
+		case key.Matches(msg, resourcesKeys.History):
+			return m, tui.NavigateTo(tui.StateHistoryKind, tui.WithParent(m.workspace.GetID()))
+		case key.Matches(msg, resourcesKeys.Tree):
+			return m, tui.NavigateTo(tui.ResourceTreeKind, tui.WithParent(m.workspace.GetID()))
 		case key.Matches(msg, resourcesKeys.Reload):
 			if m.reloading {
 				return m, tui.ReportError(errors.New("reloading in progress"))
@@ -149,58 +160,13 @@ func (m resourceList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				return msg
 			}
-		case key.Matches(msg, keys.Common.Delete):
-			addrs := m.selectedOrCurrentAddresses()
-			if len(addrs) == 0 {
-				// no rows; do nothing
-				return m, nil
-			}
-			fn := func(workspaceID resource.ID) (task.Spec, error) {
-				return m.states.Delete(workspaceID, addrs...)
-			}
-			return m, tui.YesNoPrompt(
-				fmt.Sprintf("Delete %d resource(s)?", len(addrs)),
-				m.CreateTasks(fn, m.workspace.GetID()),
-			)
-		case key.Matches(msg, resourcesKeys.Taint):
-			addrs := m.selectedOrCurrentAddresses()
-			return m, m.createStateCommand(m.states.Taint, addrs...)
-		case key.Matches(msg, resourcesKeys.Untaint):
-			addrs := m.selectedOrCurrentAddresses()
-			return m, m.createStateCommand(m.states.Untaint, addrs...)
-		case key.Matches(msg, resourcesKeys.Move):
-			if row, ok := m.Table.CurrentRow(); ok {
-				from := row.Value.Address
-				return m, m.Move(m.workspace.GetID(), from)
-			}
-		case key.Matches(msg, keys.Common.PlanDestroy):
-			// Create a targeted destroy plan.
-			createRunOptions.Destroy = true
-			fallthrough
-		case key.Matches(msg, keys.Common.Plan):
-			// Create a targeted plan.
-			createRunOptions.TargetAddrs = m.selectedOrCurrentAddresses()
-			// NOTE: even if the user hasn't selected any rows, we still proceed
-			// to create a run without targeted resources.
-			fn := func(workspaceID resource.ID) (task.Spec, error) {
-				return m.plans.Plan(workspaceID, createRunOptions)
-			}
-			return m, m.CreateTasks(fn, m.workspace.GetID())
-		case key.Matches(msg, keys.Common.Destroy):
-			createRunOptions.Destroy = true
-			applyPrompt = "Destroy %d resources?"
-			fallthrough
-		case key.Matches(msg, keys.Common.Apply):
-			// Create a targeted apply.
-			createRunOptions.TargetAddrs = m.selectedOrCurrentAddresses()
-			resourceIDs := m.Table.SelectedOrCurrentIDs()
-			fn := func(workspaceID resource.ID) (task.Spec, error) {
-				return m.plans.Apply(workspaceID, createRunOptions)
-			}
-			return m, tui.YesNoPrompt(
-				fmt.Sprintf(applyPrompt, len(resourceIDs)),
-				m.CreateTasks(fn, m.workspace.GetID()),
-			)
+		}
+		var current state.ResourceAddress
+		if row, ok := m.Table.CurrentRow(); ok {
+			current = row.Value.Address
+		}
+		if cmd, ok := m.actions.update(msg, m.selectedOrCurrentAddresses(), current); ok {
+			return m, cmd
 		}
 	case initState:
 		if msg.WorkspaceID != m.workspace.GetID() {
@@ -269,17 +235,12 @@ func (m resourceList) Title() string {
 }
 
 func (m resourceList) HelpBindings() []key.Binding {
-	bindings := []key.Binding{
-		keys.Common.Plan,
-		keys.Common.PlanDestroy,
-		keys.Common.Apply,
-		keys.Common.Destroy,
-		keys.Common.Delete,
-		resourcesKeys.Move,
-		resourcesKeys.Taint,
-		resourcesKeys.Untaint,
+	bindings := append(slices.Clone(actionBindings),
 		resourcesKeys.Reload,
-	}
+		resourcesKeys.Outputs,
+		resourcesKeys.History,
+		resourcesKeys.Tree,
+	)
 	return append(bindings, keys.KeyMapToSlice(split.Keys)...)
 }
 
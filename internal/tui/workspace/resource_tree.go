@@ -0,0 +1,229 @@
+package workspace
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/tui/table"
+	"github.com/leg100/pug/internal/workspace"
+)
+
+var countColumn = table.Column{
+	Key:   "count",
+	Title: "RESOURCES",
+	Width: len("RESOURCES"),
+}
+
+// ResourceTreeMaker makes models that show the resources in a workspace's
+// state as a tree, grouped by module call path and then by resource type.
+type ResourceTreeMaker struct {
+	Workspaces *workspace.Service
+	States     *state.Service
+	Plans      *plan.Service
+	Helpers    *tui.Helpers
+}
+
+func (mm *ResourceTreeMaker) Make(id resource.ID, width, height int) (tea.Model, error) {
+	ws, err := mm.Workspaces.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	// toggled is shared with the renderer, which renders whether a node is
+	// collapsed.
+	toggled := make(map[resource.ID]bool)
+	renderer := func(n *state.Node) table.RenderedRow {
+		var (
+			indent = strings.Repeat("  ", n.Depth)
+			name   = n.Name
+			count  string
+		)
+		switch n.Kind {
+		case state.ResourceNode:
+			name = "  " + name
+			if n.Resource.Tainted {
+				name += " (tainted)"
+			}
+		default:
+			if collapsed(n, toggled) {
+				name = "▸ " + name
+			} else {
+				name = "▾ " + name
+			}
+			count = fmt.Sprintf("%d", n.Count)
+		}
+		return table.RenderedRow{
+			resourceColumn.Key: indent + name,
+			countColumn.Key:    count,
+		}
+	}
+	return resourceTree{
+		Model: table.New(
+			[]table.Column{resourceColumn, countColumn},
+			renderer,
+			width,
+			height,
+			table.WithSortFunc(state.SortNodes),
+		),
+		Helpers:   mm.Helpers,
+		states:    mm.States,
+		workspace: ws,
+		toggled:   toggled,
+		width:     width,
+		actions: resourceActions{
+			Helpers:     mm.Helpers,
+			states:      mm.States,
+			plans:       mm.Plans,
+			workspaceID: ws.ID,
+		},
+	}, nil
+}
+
+// collapsed determines whether a node is collapsed. Type nodes are collapsed
+// and module nodes expanded unless toggled by the user.
+func collapsed(n *state.Node, toggled map[resource.ID]bool) bool {
+	if n.Kind == state.ResourceNode {
+		return true
+	}
+	return (n.Kind == state.TypeNode) != toggled[n.ID]
+}
+
+type resourceTree struct {
+	table.Model[*state.Node]
+	*tui.Helpers
+
+	states    *state.Service
+	workspace *workspace.Workspace
+	state     *state.State
+	tree      *state.Tree
+	toggled   map[resource.ID]bool
+	actions   resourceActions
+	width     int
+}
+
+func (m resourceTree) Init() tea.Cmd {
+	return func() tea.Msg {
+		state, err := m.states.Get(m.workspace.ID)
+		if err != nil {
+			return tui.ReportError(fmt.Errorf("initializing state tree model: %w", err))
+		}
+		return initState(state)
+	}
+}
+
+func (m resourceTree) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, resourcesKeys.Tree):
+			return m, tui.NavigateTo(tui.ResourceListKind, tui.WithParent(m.workspace.ID))
+		case key.Matches(msg, treeKeys.Toggle):
+			row, ok := m.CurrentRow()
+			if !ok {
+				return m, nil
+			}
+			if row.Value.Kind == state.ResourceNode {
+				return m, tui.NavigateTo(tui.ResourceKind, tui.WithParent(row.Value.Resource.ID))
+			}
+			m.toggled[row.ID] = !m.toggled[row.ID]
+			m.populate()
+			return m, nil
+		}
+		var current state.ResourceAddress
+		if row, ok := m.CurrentRow(); ok && row.Value.Kind == state.ResourceNode {
+			current = row.Value.Resource.Address
+		}
+		if cmd, ok := m.actions.update(msg, m.selectedOrCurrentAddresses(), current); ok {
+			return m, cmd
+		}
+	case initState:
+		if msg.WorkspaceID != m.workspace.ID {
+			return m, nil
+		}
+		m.setState((*state.State)(msg))
+	case resource.Event[*state.State]:
+		if msg.Payload.WorkspaceID != m.workspace.ID {
+			return m, nil
+		}
+		switch msg.Type {
+		case resource.CreatedEvent, resource.UpdatedEvent:
+			m.setState(msg.Payload)
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	}
+
+	// Handle keyboard and mouse events in the table widget
+	m.Model, cmd = m.Model.Update(msg)
+	return m, cmd
+}
+
+// setState rebuilds the tree from the state, retaining the IDs of existing
+// nodes so that they remain collapsed, expanded, or selected.
+func (m *resourceTree) setState(s *state.State) {
+	m.state = s
+	m.tree = state.NewTree(s, m.tree)
+	m.populate()
+}
+
+// populate populates the table with the nodes that are not beneath a
+// collapsed node.
+func (m *resourceTree) populate() {
+	if m.tree == nil {
+		return
+	}
+	m.SetItems(m.tree.Walk(func(n *state.Node) bool {
+		return collapsed(n, m.toggled)
+	})...)
+}
+
+// selectedOrCurrentAddresses returns the addresses of the resources beneath
+// the selected nodes, or if no nodes are selected, beneath the current node.
+func (m resourceTree) selectedOrCurrentAddresses() []state.ResourceAddress {
+	var addrs []state.ResourceAddress
+	for _, row := range m.SelectedOrCurrent() {
+		for _, res := range row.Value.Resources() {
+			addrs = append(addrs, res.Address)
+		}
+	}
+	// A resource is beneath more than one node if both a node and one of its
+	// ancestors are selected.
+	slices.Sort(addrs)
+	return slices.Compact(addrs)
+}
+
+func (m resourceTree) View() string {
+	if m.state == nil || m.state.Serial < 0 {
+		return tui.Regular.
+			Padding(0, 1).
+			Border(lipgloss.NormalBorder()).
+			// Subtract 2 to accomodate borders
+			Width(m.width - 2).
+			Render("No state found")
+	}
+	return m.Model.View()
+}
+
+func (m resourceTree) Title() string {
+	var serial string
+	if m.state != nil {
+		serial = serialBreadcrumb(m.state.Serial)
+	}
+	return m.Breadcrumbs("State", m.workspace, serial)
+}
+
+func (m resourceTree) HelpBindings() []key.Binding {
+	return append(slices.Clone(actionBindings),
+		treeKeys.Toggle,
+		resourcesKeys.Tree,
+	)
+}
@@ -0,0 +1,115 @@
+package workspace
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/tui/table"
+)
+
+var typeColumn = table.Column{
+	Key:        "type",
+	Title:      "TYPE",
+	FlexFactor: 1,
+}
+
+// SearchMaker makes models that list the results of searching the resources
+// in the state of every workspace.
+type SearchMaker struct {
+	States  *state.Service
+	Helpers *tui.Helpers
+}
+
+func (mm *SearchMaker) Make(_ resource.ID, width, height int) (tea.Model, error) {
+	columns := []table.Column{
+		table.ModuleColumn,
+		table.WorkspaceColumn,
+		resourceColumn,
+		typeColumn,
+	}
+	renderer := func(res *state.Resource) table.RenderedRow {
+		row := table.RenderedRow{
+			resourceColumn.Key: string(res.Address),
+			typeColumn.Key:     res.Type,
+		}
+		if ws, err := mm.Helpers.Workspaces.Get(res.WorkspaceID); err == nil {
+			row[table.ModuleColumn.Key] = ws.ModulePath
+			row[table.WorkspaceColumn.Key] = ws.Name
+		}
+		return row
+	}
+	return search{
+		Model: table.New(columns, renderer, width, height,
+			table.WithSortFunc(state.Sort),
+		),
+		Helpers: mm.Helpers,
+		states:  mm.States,
+	}, nil
+}
+
+type search struct {
+	table.Model[*state.Resource]
+	*tui.Helpers
+
+	states *state.Service
+	query  string
+}
+
+func (m search) Init() tea.Cmd {
+	return nil
+}
+
+func (m search) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, resourcesKeys.Enter):
+			if row, ok := m.CurrentRow(); ok {
+				return m, tui.NavigateTo(tui.ResourceKind, tui.WithParent(row.ID))
+			}
+		}
+	case tui.SearchMsg:
+		m.query = string(msg)
+		n, err := m.search()
+		if err != nil {
+			return m, tui.ReportError(fmt.Errorf("searching state: %w", err))
+		}
+		return m, tui.ReportInfo("found %d resource(s)", n)
+	case resource.Event[*state.State]:
+		// Re-run the search whenever a state is updated, because its
+		// resources have been replaced.
+		if m.query != "" {
+			_, _ = m.search()
+		}
+		return m, nil
+	}
+
+	// Handle keyboard and mouse events in the table widget
+	m.Model, cmd = m.Model.Update(msg)
+	return m, cmd
+}
+
+// search populates the table with the resources matching the query,
+// returning the number of matches.
+func (m *search) search() (int, error) {
+	results, err := m.states.Search(m.query)
+	if err != nil {
+		return 0, err
+	}
+	m.SetItems(results...)
+	return len(results), nil
+}
+
+func (m search) Title() string {
+	return m.Breadcrumbs("Search", nil, tui.TitleCommand.Render(m.query))
+}
+
+func (m search) HelpBindings() []key.Binding {
+	return []key.Binding{resourcesKeys.Enter}
+}
@@ -9,14 +9,14 @@ import (
 
 type stateFunc func(workspaceID resource.ID, addr state.ResourceAddress) (task.Spec, error)
 
-func (m resourceList) createStateCommand(fn stateFunc, addrs ...state.ResourceAddress) tea.Cmd {
+func (a resourceActions) createStateCommand(fn stateFunc, addrs ...state.ResourceAddress) tea.Cmd {
 	// Make N copies of the workspace ID where N is the number of addresses
 	workspaceIDs := make([]resource.ID, len(addrs))
 	for i := range workspaceIDs {
-		workspaceIDs[i] = m.workspace.GetID()
+		workspaceIDs[i] = a.workspaceID
 	}
 	f := newStateTaskFunc(fn, addrs...)
-	return m.CreateTasks(f.createTask, workspaceIDs...)
+	return a.CreateTasks(f.createTask, workspaceIDs...)
 }
 
 func newStateTaskFunc(fn stateFunc, addrs ...state.ResourceAddress) *stateTaskFunc {
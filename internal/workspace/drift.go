@@ -0,0 +1,78 @@
+package workspace
+
+import (
+	"time"
+
+	"github.com/leg100/pug/internal/resource"
+)
+
+// Drift is the outcome of drift detection for a workspace.
+type Drift struct {
+	// Checked is when drift was last checked.
+	Checked time.Time
+	// Drifted is true if the last check detected drift.
+	Drifted bool
+	// Summary summarises the changes detected by the last check.
+	Summary string
+	// Since is when drift first appeared, i.e. the time of the first of the
+	// consecutive checks that have detected drift. Nil if the last check did
+	// not detect drift.
+	Since *time.Time
+	// History records each occasion drift appeared or was resolved.
+	History []DriftEvent
+}
+
+// DriftEvent records drift appearing or being resolved.
+type DriftEvent struct {
+	Time    time.Time
+	Drifted bool
+	Summary string
+}
+
+// RecordDrift records the outcome of a check for drift in a workspace.
+func (s *Service) RecordDrift(workspaceID resource.ID, drifted bool, summary string) (*Workspace, error) {
+	// appeared is true if drift has appeared since the previous check.
+	var appeared bool
+	ws, err := s.table.Update(workspaceID, func(existing *Workspace) error {
+		appeared = drifted && (existing.Drift == nil || !existing.Drift.Drifted)
+		existing.Drift = existing.Drift.record(time.Now(), drifted, summary)
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("recording drift", "error", err, "workspace_id", workspaceID)
+		return nil, err
+	}
+	if appeared {
+		s.logger.Warn("drift detected", "workspace", ws, "summary", summary)
+	}
+	return ws, nil
+}
+
+// record returns a new record of drift following a check for drift.
+func (d *Drift) record(checked time.Time, drifted bool, summary string) *Drift {
+	next := &Drift{
+		Checked: checked,
+		Drifted: drifted,
+		Summary: summary,
+	}
+	var previouslyDrifted bool
+	if d != nil {
+		previouslyDrifted = d.Drifted
+		next.Since = d.Since
+		next.History = d.History
+	}
+	if drifted != previouslyDrifted {
+		next.History = append(next.History, DriftEvent{
+			Time:    checked,
+			Drifted: drifted,
+			Summary: summary,
+		})
+	}
+	switch {
+	case !drifted:
+		next.Since = nil
+	case !previouslyDrifted:
+		next.Since = &checked
+	}
+	return next
+}
@@ -0,0 +1,44 @@
+package workspace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrift_record(t *testing.T) {
+	t.Parallel()
+
+	t1 := time.Now()
+	t2 := t1.Add(time.Minute)
+	t3 := t2.Add(time.Minute)
+	t4 := t3.Add(time.Minute)
+
+	var drift *Drift
+
+	drift = drift.record(t1, false, "+0/~0/-0")
+	assert.False(t, drift.Drifted)
+	assert.Nil(t, drift.Since)
+	assert.Empty(t, drift.History)
+
+	drift = drift.record(t2, true, "+0/~1/-0")
+	assert.True(t, drift.Drifted)
+	assert.Equal(t, &t2, drift.Since)
+	assert.Equal(t, []DriftEvent{{Time: t2, Drifted: true, Summary: "+0/~1/-0"}}, drift.History)
+
+	// Drift persists, so it is still considered to have first appeared at t2.
+	drift = drift.record(t3, true, "+0/~2/-0")
+	assert.Equal(t, t3, drift.Checked)
+	assert.Equal(t, "+0/~2/-0", drift.Summary)
+	assert.Equal(t, &t2, drift.Since)
+	assert.Len(t, drift.History, 1)
+
+	drift = drift.record(t4, false, "+0/~0/-0")
+	assert.False(t, drift.Drifted)
+	assert.Nil(t, drift.Since)
+	assert.Equal(t, []DriftEvent{
+		{Time: t2, Drifted: true, Summary: "+0/~1/-0"},
+		{Time: t4, Drifted: false, Summary: "+0/~0/-0"},
+	}, drift.History)
+}
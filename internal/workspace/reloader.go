@@ -58,6 +58,8 @@ func (r *reloader) Reload(moduleID resource.ID) (task.Spec, error) {
 		Execution: task.Execution{
 			TerraformCommand: []string{"workspace", "list"},
 		},
+		// Listing workspaces is interactive, so run it ahead of bulk tasks.
+		Priority: task.HighPriority,
 		BeforeExited: func(t *task.Task) (task.Summary, error) {
 			found, current, err := parseList(t.NewReader(false))
 			if err != nil {
@@ -19,6 +19,8 @@ type Service struct {
 	tasks   *task.Service
 	datadir string
 	workdir internal.Workdir
+	// varFiles are templates for the paths of workspaces' variables files.
+	varFiles []VarFileTemplate
 
 	*pubsub.Broker[*Workspace]
 	*reloader
@@ -31,6 +33,10 @@ type ServiceOptions struct {
 	Logger  logging.Interface
 	DataDir string
 	Workdir internal.Workdir
+	// VarFiles are templates for the paths of workspaces' variables files,
+	// in order of precedence, lowest first. Defaults to
+	// DefaultVarFileTemplates.
+	VarFiles []VarFileTemplate
 }
 
 type workspaceTable interface {
@@ -68,6 +74,10 @@ func NewService(opts ServiceOptions) *Service {
 		datadir: opts.DataDir,
 		workdir: opts.Workdir,
 	}
+	s.varFiles = opts.VarFiles
+	if len(s.varFiles) == 0 {
+		s.varFiles = DefaultVarFileTemplates
+	}
 	s.reloader = &reloader{s}
 	s.costTaskSpecCreator = &costTaskSpecCreator{s}
 	return s
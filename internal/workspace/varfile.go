@@ -0,0 +1,77 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/leg100/pug/internal"
+)
+
+// workspacePlaceholder is replaced in a var file template with the name of a
+// workspace.
+const workspacePlaceholder = "{workspace}"
+
+// VarFileTemplate is a template for the path of a terraform variables file,
+// relative to a module directory, e.g. env/{workspace}/*.tfvars. The
+// placeholder {workspace} is replaced with the name of a workspace, and the
+// path may contain glob wildcards.
+type VarFileTemplate string
+
+// DefaultVarFileTemplates are used when no templates are configured.
+var DefaultVarFileTemplates = []VarFileTemplate{"{workspace}.tfvars"}
+
+// ParseVarFileTemplate parses a var file template.
+func ParseVarFileTemplate(s string) (VarFileTemplate, error) {
+	if s == "" {
+		return "", errors.New("invalid var file template: empty")
+	}
+	if filepath.IsAbs(s) {
+		return "", fmt.Errorf("invalid var file template: %s: must be relative to the module directory", s)
+	}
+	tmpl := VarFileTemplate(s)
+	if _, err := filepath.Match(tmpl.resolve("default"), ""); err != nil {
+		return "", fmt.Errorf("invalid var file template: %s: %w", s, err)
+	}
+	return tmpl, nil
+}
+
+func (t VarFileTemplate) resolve(workspaceName string) string {
+	return strings.ReplaceAll(string(t), workspacePlaceholder, workspaceName)
+}
+
+// VarFiles resolves the templates to the paths of the workspace's existing
+// terraform variables files, relative to the module directory. The paths are
+// in order of precedence, lowest first: the files of a template take
+// precedence over those of preceding templates, and files matching a glob
+// are in lexical order. A file matching more than one template is only
+// included once, at the precedence of the last template it matches.
+func (ws *Workspace) VarFiles(workdir internal.Workdir, templates []VarFileTemplate) []string {
+	moduleDir := workdir.Join(ws.ModulePath)
+
+	var files []string
+	for _, tmpl := range templates {
+		// Patterns were validated upon parsing the templates, so any error
+		// is ignored.
+		matches, _ := filepath.Glob(filepath.Join(moduleDir, tmpl.resolve(ws.Name)))
+		for _, path := range matches {
+			rel, err := filepath.Rel(moduleDir, path)
+			if err != nil {
+				continue
+			}
+			// Move a file matched by a previous template to the
+			// precedence of this template.
+			files = slices.DeleteFunc(files, func(f string) bool { return f == rel })
+			files = append(files, rel)
+		}
+	}
+	return files
+}
+
+// VarFiles resolves the paths of the workspace's terraform variables files,
+// relative to the module directory, lowest precedence first.
+func (s *Service) VarFiles(ws *Workspace) []string {
+	return ws.VarFiles(s.workdir, s.varFiles)
+}
@@ -4,10 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/url"
-	"os"
-	"path/filepath"
 
-	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/module"
 	"github.com/leg100/pug/internal/resource"
 )
@@ -19,6 +16,9 @@ type Workspace struct {
 	ModuleID   resource.ID
 	ModulePath string
 	Cost       float64
+	// Drift is the outcome of the last check for drift. Nil if drift has
+	// not been checked.
+	Drift *Drift
 }
 
 func New(mod *module.Module, name string) (*Workspace, error) {
@@ -47,15 +47,6 @@ func (ws *Workspace) LogValue() slog.Value {
 	)
 }
 
-// VarsFile returns the filename of the workspace's terraform variables file
-// and whether it exists or not.
-func (ws *Workspace) VarsFile(workdir internal.Workdir) (string, bool) {
-	fname := fmt.Sprintf("%s.tfvars", ws.Name)
-	path := filepath.Join(workdir.String(), ws.ModulePath, fname)
-	_, err := os.Stat(path)
-	return fname, err == nil
-}
-
 func TerraformEnv(workspaceName string) string {
 	return fmt.Sprintf("TF_WORKSPACE=%s", workspaceName)
 }
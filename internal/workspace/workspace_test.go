@@ -18,19 +18,75 @@ func TestWorkspace_TerraformEnv(t *testing.T) {
 	assert.Equal(t, "TF_WORKSPACE=dev", ws.TerraformEnv())
 }
 
-func TestWorkspace_VarsFile(t *testing.T) {
+func TestWorkspace_VarFiles(t *testing.T) {
 	workdir := internal.NewTestWorkdir(t)
 	mod := module.New(module.Options{Path: "a/b/c"})
 	ws, err := New(mod, "dev")
 	require.NoError(t, err)
 
-	// Create a workspace tfvars file for dev
-	path := workdir.Join(mod.Path, "dev.tfvars")
-	os.MkdirAll(filepath.Dir(path), 0o755)
-	_, err = os.Create(path)
+	for _, fname := range []string{
+		"dev.tfvars",
+		"common.tfvars",
+		"env/dev/b.tfvars",
+		"env/dev/a.tfvars",
+		"env/prod/a.tfvars",
+		"vars/dev.tfvars.json",
+	} {
+		path := workdir.Join(mod.Path, fname)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		_, err = os.Create(path)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name      string
+		templates []VarFileTemplate
+		want      []string
+	}{
+		{
+			"default",
+			DefaultVarFileTemplates,
+			[]string{"dev.tfvars"},
+		},
+		{
+			"ordered precedence",
+			[]VarFileTemplate{"common.tfvars", "env/{workspace}/*.tfvars", "vars/{workspace}.tfvars.json"},
+			[]string{"common.tfvars", "env/dev/a.tfvars", "env/dev/b.tfvars", "vars/dev.tfvars.json"},
+		},
+		{
+			"missing file",
+			[]VarFileTemplate{"{workspace}.auto.tfvars"},
+			nil,
+		},
+		{
+			"file matching several templates",
+			[]VarFileTemplate{"*.tfvars", "common.tfvars"},
+			[]string{"dev.tfvars", "common.tfvars"},
+		},
+		{
+			"file matching several templates already in order",
+			[]VarFileTemplate{"*.tfvars", "{workspace}.tfvars"},
+			[]string{"common.tfvars", "dev.tfvars"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ws.VarFiles(workdir, tt.templates))
+		})
+	}
+}
+
+func TestParseVarFileTemplate(t *testing.T) {
+	got, err := ParseVarFileTemplate("env/{workspace}/*.tfvars")
 	require.NoError(t, err)
+	assert.Equal(t, VarFileTemplate("env/{workspace}/*.tfvars"), got)
+
+	_, err = ParseVarFileTemplate("")
+	assert.Error(t, err)
+
+	_, err = ParseVarFileTemplate("/etc/{workspace}.tfvars")
+	assert.Error(t, err)
 
-	got, ok := ws.VarsFile(workdir)
-	require.True(t, ok)
-	assert.Equal(t, "dev.tfvars", got)
+	_, err = ParseVarFileTemplate("vars/[{workspace}.tfvars")
+	assert.Error(t, err)
 }